@@ -0,0 +1,23 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/jhonathanssegura/ticket-notification/internal/push"
+)
+
+// fakepush levanta un servidor que emula APNs y FCM para probar el canal push sin
+// credenciales. Se usa configurando PUSH_APNS_URL y PUSH_FCM_URL con su dirección.
+func main() {
+	addr := os.Getenv("FAKE_PUSH_ADDR")
+	if addr == "" {
+		addr = ":8086"
+	}
+
+	log.Printf("📲 Servidor push falso escuchando en %s (GET /messages para ver lo recibido)", addr)
+	if err := http.ListenAndServe(addr, push.NewFakeServer()); err != nil {
+		log.Fatalf("Error iniciando servidor push falso: %v", err)
+	}
+}
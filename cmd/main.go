@@ -1,17 +1,34 @@
 package main
 
 import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
 	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/aws/aws-sdk-go-v2/service/ses"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
 	"github.com/aws/aws-sdk-go-v2/service/sqs"
 	"github.com/gin-gonic/gin"
 	"github.com/jhonathanssegura/ticket-notification/internal/awsconfig"
+	"github.com/jhonathanssegura/ticket-notification/internal/channel"
 	"github.com/jhonathanssegura/ticket-notification/internal/db"
+	"github.com/jhonathanssegura/ticket-notification/internal/feedback"
 	"github.com/jhonathanssegura/ticket-notification/internal/handler"
+	"github.com/jhonathanssegura/ticket-notification/internal/model"
+	"github.com/jhonathanssegura/ticket-notification/internal/push"
 	"github.com/jhonathanssegura/ticket-notification/internal/queue"
+	"github.com/jhonathanssegura/ticket-notification/internal/realtime"
 	"github.com/jhonathanssegura/ticket-notification/internal/service"
+	"github.com/jhonathanssegura/ticket-notification/internal/worker"
 )
 
 func main() {
@@ -25,10 +42,16 @@ func main() {
 	reservationQueueURL := "http://localhost:4566/000000000000/reservation-notifications"
 	reminderQueueURL := "http://localhost:4566/000000000000/reminder-notifications"
 
+	// Colas de mensajes fallidos (DLQ): reciben los mensajes que agotan sus reintentos
+	eventDLQURL := "http://localhost:4566/000000000000/event-notifications-dlq"
+	reservationDLQURL := "http://localhost:4566/000000000000/reservation-notifications-dlq"
+	reminderDLQURL := "http://localhost:4566/000000000000/reminder-notifications-dlq"
+
 	// Crear clientes AWS
 	sqsClient := sqs.NewFromConfig(cfg)
 	sesClient := ses.NewFromConfig(cfg)
 	dynamoClient := dynamodb.NewFromConfig(cfg)
+	snsClient := sns.NewFromConfig(cfg)
 
 	// Crear clientes de cola
 	eventQueue := &queue.SQSClient{
@@ -49,12 +72,59 @@ func main() {
 		Client: dynamoClient,
 	}
 
+	// Reparto en tiempo real de las notificaciones guardadas (SSE/WebSocket)
+	hub := realtime.NewHub()
+	broker := realtime.NewBroker(hub, snsClient, sqsClient, os.Getenv("REALTIME_TOPIC_ARN"))
+	dbClient.OnNotificationSaved = broker.Publish
+
+	// Registrar canales de entrega
+	channels := channel.NewRegistry(
+		channel.NewEmailChannel(sesClient, "notifications@ticket-system.com", dbClient, splitEnv("ATTACHMENT_ALLOWED_HOSTS")),
+		channel.NewSMSChannel(newSMSTransport(snsClient), envInt("SMS_MAX_SEGMENTS", 3), os.Getenv("SMS_LONG_MESSAGES") == "split"),
+		channel.NewWebhookChannel(dbClient),
+	)
+	if pushTransports := newPushTransports(); len(pushTransports) > 0 {
+		channels.Register(channel.NewPushChannel(dbClient, pushTransports, pushNotificationTypes()))
+	} else {
+		log.Println("ℹ️  Canal push deshabilitado: configure APNs o FCM para habilitarlo")
+	}
+
 	// Crear servicio de notificaciones
-	notificationService := service.NewNotificationService(sesClient, eventQueue, reservationQueue, reminderQueue)
+	notificationService := service.NewNotificationService(channels, eventQueue, reservationQueue, reminderQueue, dbClient)
+	notificationService.ConfigureRetries(retryPolicy(), map[string]*queue.SQSClient{
+		"events":       {Client: sqsClient, QueueURL: eventDLQURL},
+		"reservations": {Client: sqsClient, QueueURL: reservationDLQURL},
+		"reminders":    {Client: sqsClient, QueueURL: reminderDLQURL},
+	})
 
 	// Crear handlers
 	notificationHandler := handler.NewNotificationHandler(notificationService, dbClient)
 	queueHandler := handler.NewQueueHandler(notificationService, dbClient)
+	templateHandler := handler.NewTemplateHandler(dbClient)
+	webhookHandler := handler.NewWebhookHandler(dbClient)
+	inboxHandler := handler.NewInboxHandler(dbClient)
+	deviceHandler := handler.NewDeviceHandler(dbClient)
+	preferencesHandler := handler.NewPreferencesHandler(dbClient)
+	reminderHandler := handler.NewReminderHandler(notificationService, dbClient)
+	suppressionHandler := handler.NewSuppressionHandler(dbClient)
+	// Sin tópicos permitidos cualquiera podría suscribir el endpoint y suprimir direcciones
+	var sesFeedbackHandler *handler.SESFeedbackHandler
+	if topicARNs := splitEnv("SES_NOTIFICATION_TOPIC_ARNS"); len(topicARNs) > 0 {
+		sesFeedbackHandler = handler.NewSESFeedbackHandler(notificationService, snsClient, newSNSVerifier(), topicARNs)
+	} else {
+		log.Println("⚠️  SES_NOTIFICATION_TOPIC_ARNS no configurado: los eventos de SES por SNS quedan deshabilitados")
+	}
+	// Sin secreto no se puede autenticar a quien se conecta, así que no se ofrece streaming
+	var streamHandler *handler.StreamHandler
+	if streamTokenSecret := os.Getenv("STREAM_TOKEN_SECRET"); streamTokenSecret != "" {
+		streamHandler = handler.NewStreamHandler(hub, dbClient, streamTokenSecret, splitEnv("STREAM_ALLOWED_ORIGINS"),
+			time.Duration(envInt("STREAM_HEARTBEAT_SECONDS", 25))*time.Second)
+	} else {
+		log.Println("⚠️  STREAM_TOKEN_SECRET no configurado: el streaming de notificaciones queda deshabilitado")
+	}
+
+	// Los envíos con Idempotency-Key devuelven la respuesta original al repetirse
+	idempotent := handler.Idempotency(dbClient, time.Duration(envInt("IDEMPOTENCY_TTL_HOURS", 24))*time.Hour)
 
 	// Configurar rutas
 	r := gin.Default()
@@ -63,7 +133,7 @@ func main() {
 	r.Use(func(c *gin.Context) {
 		c.Header("Access-Control-Allow-Origin", "*")
 		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Origin, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization")
+		c.Header("Access-Control-Allow-Headers", "Origin, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, Idempotency-Key")
 
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(204)
@@ -86,34 +156,293 @@ func main() {
 	api := r.Group("/api/v1")
 	{
 		// Notification endpoints
-		api.POST("/notifications/send", notificationHandler.SendNotification)
-		api.POST("/notifications/bulk", notificationHandler.SendBulkNotifications)
+		api.POST("/notifications/send", idempotent, notificationHandler.SendNotification)
+		api.POST("/notifications/bulk", idempotent, notificationHandler.SendBulkNotifications)
 		api.GET("/notifications/:id", notificationHandler.GetNotification)
 		api.GET("/notifications", notificationHandler.ListNotifications)
+		api.GET("/notifications/scheduled", notificationHandler.ListScheduledNotifications)
+		api.PUT("/notifications/:id/schedule", notificationHandler.RescheduleNotification)
+		api.DELETE("/notifications/:id/schedule", notificationHandler.CancelScheduledNotification)
+		if streamHandler != nil {
+			api.GET("/notifications/stream", streamHandler.StreamSSE)
+			api.GET("/notifications/ws", streamHandler.StreamWebSocket)
+		}
 		api.PUT("/notifications/:id", notificationHandler.UpdateNotification)
 		api.DELETE("/notifications/:id", notificationHandler.DeleteNotification)
 
 		// Event notification endpoints
-		api.POST("/notifications/events", notificationHandler.NotifyEventCreated)
-		api.POST("/notifications/events/:id/reminder", notificationHandler.SendEventReminder)
-		api.POST("/notifications/events/:id/cancelled", notificationHandler.NotifyEventCancelled)
+		api.POST("/notifications/events", idempotent, notificationHandler.NotifyEventCreated)
+		api.POST("/notifications/events/:id/reminder", idempotent, notificationHandler.SendEventReminder)
+		api.POST("/notifications/events/:id/cancelled", idempotent, notificationHandler.NotifyEventCancelled)
+		api.POST("/notifications/events/:id/updated", idempotent, notificationHandler.NotifyEventUpdated)
+		api.POST("/notifications/events/:id/reminders", idempotent, reminderHandler.ScheduleReminders)
+		api.GET("/notifications/events/:id/reminders", reminderHandler.ListReminders)
+		api.PUT("/notifications/events/:id/reminders", reminderHandler.RescheduleReminders)
+		api.DELETE("/notifications/events/:id/reminders", reminderHandler.CancelReminders)
+		api.GET("/notifications/events/:id/audience", notificationHandler.ListEventAudience)
+
+		// Envíos masivos a la audiencia de un evento
+		api.GET("/fanout-jobs/:id", notificationHandler.GetFanoutJob)
+		api.POST("/fanout-jobs/:id/resume", notificationHandler.ResumeFanoutJob)
 
 		// Reservation notification endpoints
-		api.POST("/notifications/reservations", notificationHandler.NotifyReservationCreated)
-		api.POST("/notifications/reservations/:id/confirmed", notificationHandler.NotifyReservationConfirmed)
-		api.POST("/notifications/reservations/:id/cancelled", notificationHandler.NotifyReservationCancelled)
+		api.POST("/notifications/reservations", idempotent, notificationHandler.NotifyReservationCreated)
+		api.POST("/notifications/reservations/:id/confirmed", idempotent, notificationHandler.NotifyReservationConfirmed)
+		api.POST("/notifications/reservations/:id/cancelled", idempotent, notificationHandler.NotifyReservationCancelled)
+		api.POST("/notifications/tickets", idempotent, notificationHandler.NotifyTicketGenerated)
+
+		// Payment and account notification endpoints
+		api.POST("/notifications/payments/received", idempotent, notificationHandler.NotifyPaymentReceived)
+		api.POST("/notifications/payments/failed", idempotent, notificationHandler.NotifyPaymentFailed)
+		api.POST("/notifications/users/welcome", idempotent, notificationHandler.NotifyWelcome)
+		api.POST("/notifications/users/password-reset", idempotent, notificationHandler.NotifyPasswordReset)
+
+		// Template endpoints
+		api.POST("/templates", templateHandler.CreateTemplate)
+		api.GET("/templates", templateHandler.ListTemplates)
+		api.GET("/templates/:id", templateHandler.GetTemplate)
+		api.PUT("/templates/:id", templateHandler.UpdateTemplate)
+		api.POST("/templates/:id/activate", templateHandler.ActivateTemplate)
+		api.POST("/templates/:id/deactivate", templateHandler.DeactivateTemplate)
+		api.DELETE("/templates/:id", templateHandler.DeleteTemplate)
+		api.GET("/templates/:id/versions", templateHandler.ListTemplateVersions)
+		api.GET("/templates/:id/versions/:version", templateHandler.GetTemplateVersion)
+		api.GET("/templates/:id/diff", templateHandler.DiffTemplateVersions)
+		api.POST("/templates/:id/rollback", templateHandler.RollbackTemplate)
+
+		// In-app inbox endpoints
+		api.GET("/users/:user_id/inbox", inboxHandler.ListInbox)
+		api.GET("/users/:user_id/inbox/unread-count", inboxHandler.GetUnreadCount)
+		api.POST("/users/:user_id/inbox/read", inboxHandler.MarkManyRead)
+		api.POST("/users/:user_id/inbox/read-all", inboxHandler.MarkAllRead)
+		api.POST("/users/:user_id/inbox/:id/read", inboxHandler.MarkRead)
+
+		// Notification preferences endpoints
+		api.GET("/users/:user_id/preferences", preferencesHandler.GetPreferences)
+		api.PUT("/users/:user_id/preferences", preferencesHandler.UpdatePreferences)
+		api.DELETE("/users/:user_id/preferences", preferencesHandler.ResetPreferences)
+
+		// Device registry endpoints (push)
+		api.POST("/users/:user_id/devices", deviceHandler.RegisterDevice)
+		api.GET("/users/:user_id/devices", deviceHandler.ListDevices)
+		api.POST("/users/:user_id/devices/refresh", deviceHandler.RefreshDevice)
+		api.DELETE("/users/:user_id/devices", deviceHandler.UnregisterDevices)
+		api.DELETE("/users/:user_id/devices/:token", deviceHandler.UnregisterDevice)
+
+		// Webhook endpoints
+		api.POST("/webhooks", webhookHandler.CreateWebhook)
+		api.GET("/webhooks", webhookHandler.ListWebhooks)
+		api.GET("/webhooks/:id", webhookHandler.GetWebhook)
+		api.PUT("/webhooks/:id", webhookHandler.UpdateWebhook)
+		api.DELETE("/webhooks/:id", webhookHandler.DeleteWebhook)
+		api.GET("/webhooks/:id/deliveries", webhookHandler.ListWebhookDeliveries)
+
+		// Lista de supresión de emails y eventos de SES (rebotes, quejas, entregas)
+		api.GET("/suppressions", suppressionHandler.ListSuppressions)
+		api.POST("/suppressions", suppressionHandler.CreateSuppression)
+		api.GET("/suppressions/:email", suppressionHandler.GetSuppression)
+		api.DELETE("/suppressions/:email", suppressionHandler.DeleteSuppression)
+		if sesFeedbackHandler != nil {
+			api.POST("/ses/notifications", sesFeedbackHandler.HandleSNSNotification)
+		}
 
 		// Queue processing endpoints
 		api.POST("/queue/process", queueHandler.ProcessNotificationQueue)
 		api.GET("/queue/status", queueHandler.GetQueueStatus)
+		api.GET("/queue/metrics", queueHandler.GetQueueMetrics)
+		api.POST("/queue/purge", queueHandler.PurgeQueue)
+		api.POST("/queue/retry", queueHandler.RetryFailedNotifications)
+		api.GET("/queue/dead-letters/:type", queueHandler.ListDeadLetters)
+		api.POST("/queue/dead-letters/:type/redrive", queueHandler.RedriveDeadLetters)
+		api.DELETE("/queue/dead-letters/:type", queueHandler.DiscardDeadLetters)
+	}
+
+	// Apagado ordenado con SIGINT/SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	// Workers de consumo continuo de las colas
+	workerPool := worker.NewPool(notificationService.ProcessMessage,
+		worker.QueueConfig{QueueType: "events", Queue: eventQueue, Concurrency: envInt("WORKER_EVENTS_CONCURRENCY", 2)},
+		worker.QueueConfig{QueueType: "reservations", Queue: reservationQueue, Concurrency: envInt("WORKER_RESERVATIONS_CONCURRENCY", 4)},
+		worker.QueueConfig{QueueType: "reminders", Queue: reminderQueue, Concurrency: envInt("WORKER_REMINDERS_CONCURRENCY", 2)},
+	)
+	workerPool.Start(ctx)
+
+	// Los recordatorios programados esperan en DynamoDB y pasan a la cola con el retardo
+	// que les falta cuando quedan a menos de un intervalo de su hora de envío
+	reminderInterval := time.Duration(envInt("REMINDER_DISPATCH_INTERVAL_SECONDS", 60)) * time.Second
+	if reminderInterval > queue.MaxDelay {
+		reminderInterval = queue.MaxDelay
+	}
+	reminderScheduler := worker.NewScheduler("recordatorios", reminderInterval, func(ctx context.Context) error {
+		return notificationService.DispatchDueReminders(ctx, reminderInterval)
+	})
+	reminderScheduler.Start(ctx)
+
+	// Notificaciones con send_at: se envían cuando llega su hora
+	scheduledNotifications := worker.NewScheduler("notificaciones programadas",
+		time.Duration(envInt("SCHEDULED_DISPATCH_INTERVAL_SECONDS", 30))*time.Second,
+		notificationService.DispatchScheduledNotifications)
+	scheduledNotifications.Start(ctx)
+
+	// Envíos masivos de cambios y cancelaciones de eventos a todos los que tienen reserva
+	fanoutJobs := worker.NewScheduler("envíos masivos",
+		time.Duration(envInt("FANOUT_INTERVAL_SECONDS", 5))*time.Second,
+		notificationService.RunFanoutJobs)
+	fanoutJobs.Start(ctx)
+
+	// Notificaciones aceptadas cuya entrega en segundo plano no terminó
+	outbox := worker.NewScheduler("outbox",
+		time.Duration(envInt("OUTBOX_INTERVAL_SECONDS", 10))*time.Second,
+		notificationService.DispatchOutbox)
+	outbox.Start(ctx)
+
+	if err := broker.Start(ctx); err != nil {
+		log.Printf("⚠️  Reparto en tiempo real entre instancias deshabilitado: %v", err)
+	}
+
+	server := &http.Server{
+		Addr:    ":8085",
+		Handler: r,
+	}
+	// Shutdown no espera a las conexiones de streaming; cerrar el hub las termina
+	server.RegisterOnShutdown(hub.Close)
+
+	go func() {
+		log.Println("🚀 Iniciando servicio de notificaciones en puerto 8085...")
+		log.Println("📧 Servicio de notificaciones por email y SMS configurado")
+		log.Println("📱 Colas SQS configuradas para eventos, reservas y recordatorios")
+
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("Error iniciando servidor: %v", err)
+		}
+	}()
+
+	<-ctx.Done()
+	log.Println("🛑 Apagando servicio, esperando mensajes en proceso...")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Error cerrando servidor HTTP: %v", err)
+	}
+
+	workerPool.Wait()
+	reminderScheduler.Wait()
+	scheduledNotifications.Wait()
+	fanoutJobs.Wait()
+	outbox.Wait()
+	notificationService.Wait()
+	<-broker.Done()
+	broker.Close(context.Background())
+	log.Println("✅ Servicio detenido")
+}
+
+// envInt lee un entero de una variable de entorno con valor por defecto
+func envInt(name string, defaultValue int) int {
+	if value, err := strconv.Atoi(os.Getenv(name)); err == nil && value > 0 {
+		return value
+	}
+	return defaultValue
+}
+
+// retryPolicy lee la política de reintentos de los mensajes de las colas. RETRY_MAX_URGENT,
+// RETRY_MAX_HIGH, RETRY_MAX_NORMAL y RETRY_MAX_LOW fijan los reintentos de cada prioridad.
+func retryPolicy() service.RetryPolicy {
+	policy := service.DefaultRetryPolicy()
+	policy.BaseDelay = time.Duration(envInt("RETRY_BASE_DELAY_SECONDS", int(policy.BaseDelay.Seconds()))) * time.Second
+	for priority, retries := range policy.MaxRetries {
+		policy.MaxRetries[priority] = envInt("RETRY_MAX_"+strings.ToUpper(string(priority)), retries)
+	}
+	return policy
+}
+
+// newSMSTransport elige el transporte de SMS según SMS_TRANSPORT: "sns" (por
+// defecto), "memory" o "file" (escribe en SMS_OUTBOX_FILE)
+func newSMSTransport(snsClient *sns.Client) channel.SMSTransport {
+	switch os.Getenv("SMS_TRANSPORT") {
+	case "memory":
+		log.Println("📱 SMS en memoria: los mensajes no se envían")
+		return channel.NewMemoryTransport()
+	case "file":
+		path := os.Getenv("SMS_OUTBOX_FILE")
+		if path == "" {
+			path = "sms-outbox.jsonl"
+		}
+		log.Printf("📱 SMS redirigidos al archivo %s", path)
+		return channel.NewFileTransport(path)
+	default:
+		return channel.NewSNSTransport(snsClient, os.Getenv("SMS_SENDER_ID"), os.Getenv("SMS_TYPE"))
+	}
+}
+
+// newPushTransports crea los transportes de push configurados por plataforma. PUSH_APNS_URL y
+// PUSH_FCM_URL permiten apuntar a un servidor falso (cmd/fakepush); sin
+// credenciales las peticiones no se autentican.
+func newPushTransports() map[model.DevicePlatform]push.Transport {
+	transports := make(map[model.DevicePlatform]push.Transport)
+
+	if keyFile := os.Getenv("PUSH_APNS_KEY_FILE"); keyFile != "" || os.Getenv("PUSH_APNS_URL") != "" {
+		var key *ecdsa.PrivateKey
+		if keyFile != "" {
+			loaded, err := push.LoadAPNsKey(keyFile)
+			if err != nil {
+				log.Fatalf("Error cargando clave de APNs: %v", err)
+			}
+			key = loaded
+		}
+		transports[model.DevicePlatformIOS] = push.NewAPNsTransport(os.Getenv("PUSH_APNS_URL"), os.Getenv("PUSH_APNS_TOPIC"),
+			os.Getenv("PUSH_APNS_KEY_ID"), os.Getenv("PUSH_APNS_TEAM_ID"), key)
+	}
+
+	if credentialsFile := os.Getenv("PUSH_FCM_CREDENTIALS_FILE"); credentialsFile != "" || os.Getenv("PUSH_FCM_URL") != "" {
+		var credentials *push.FCMCredentials
+		if credentialsFile != "" {
+			loaded, err := push.LoadFCMCredentials(credentialsFile)
+			if err != nil {
+				log.Fatalf("Error cargando credenciales de FCM: %v", err)
+			}
+			credentials = loaded
+		}
+		transports[model.DevicePlatformAndroid] = push.NewFCMTransport(os.Getenv("PUSH_FCM_URL"), os.Getenv("PUSH_FCM_PROJECT_ID"), credentials)
+	}
+
+	return transports
+}
+
+// pushNotificationTypes lee de PUSH_NOTIFICATION_TYPES los tipos que se envían por
+// push automáticamente, separados por comas
+func pushNotificationTypes() []model.NotificationType {
+	values := splitEnv("PUSH_NOTIFICATION_TYPES")
+	if len(values) == 0 {
+		values = []string{"event_reminder", "payment_failed"}
 	}
 
-	log.Println("🚀 Iniciando servicio de notificaciones en puerto 8085...")
-	log.Println("📧 Servicio de notificaciones por email configurado")
-	log.Println("📱 Colas SQS configuradas para eventos, reservas y recordatorios")
+	var types []model.NotificationType
+	for _, notificationType := range values {
+		types = append(types, model.NotificationType(notificationType))
+	}
+	return types
+}
 
-	if err := r.Run(":8085"); err != nil {
-		log.Fatalf("Error iniciando servidor: %v", err)
+// newSNSVerifier crea el verificador de firmas de los mensajes de SES recibidos por SNS.
+// Con SES_SNS_SKIP_VERIFY=true no se verifican (LocalStack no firma los mensajes).
+func newSNSVerifier() *feedback.Verifier {
+	if os.Getenv("SES_SNS_SKIP_VERIFY") == "true" {
+		log.Println("⚠️  Verificación de firmas de SNS deshabilitada (SES_SNS_SKIP_VERIFY)")
+		return nil
 	}
+	return feedback.NewVerifier()
 }
 
+// splitEnv lee una variable de entorno con valores separados por comas
+func splitEnv(name string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(name), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
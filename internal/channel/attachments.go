@@ -0,0 +1,192 @@
+package channel
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/jhonathanssegura/ticket-notification/internal/email"
+	"github.com/jhonathanssegura/ticket-notification/internal/model"
+)
+
+// maxAttachmentDownloadSize limita el tamaño de los adjuntos descargados por URL
+const maxAttachmentDownloadSize = 10 * 1024 * 1024
+
+// maxAttachmentRedirects limita las redirecciones al descargar un adjunto
+const maxAttachmentRedirects = 5
+
+// ErrAttachmentURLNotAllowed indica que la URL de un adjunto no es https, su host no
+// está permitido o resuelve a una dirección privada, de loopback o link-local
+var ErrAttachmentURLNotAllowed = errors.New("attachment url not allowed")
+
+// reservedPrefixes son rangos no públicos que net.IP no clasifica por sí solo
+var reservedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+}
+
+// attachmentFetcher descarga los adjuntos pasados por URL. Solo acepta https, los hosts
+// permitidos (si se configuró alguno) y direcciones públicas, comprobadas después de
+// resolver el DNS, para que las URLs no sirvan para alcanzar servicios internos.
+type attachmentFetcher struct {
+	allowedHosts map[string]bool
+	client       *http.Client
+}
+
+// newAttachmentFetcher crea el descargador de adjuntos; con allowedHosts vacío se
+// acepta cualquier host público
+func newAttachmentFetcher(allowedHosts []string) *attachmentFetcher {
+	fetcher := &attachmentFetcher{allowedHosts: make(map[string]bool)}
+	for _, host := range allowedHosts {
+		fetcher.allowedHosts[strings.ToLower(host)] = true
+	}
+
+	dialer := &net.Dialer{Timeout: 10 * time.Second, Control: dialPublicOnly}
+	fetcher.client = &http.Client{
+		Timeout: 15 * time.Second,
+		// Sin proxy: la comprobación de la dirección se hace sobre la conexión real
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: 10 * time.Second,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxAttachmentRedirects {
+				return fmt.Errorf("stopped after %d redirects", maxAttachmentRedirects)
+			}
+			return fetcher.checkURL(req.URL)
+		},
+	}
+	return fetcher
+}
+
+// checkURL comprueba el esquema y el host de la URL de un adjunto
+func (f *attachmentFetcher) checkURL(u *url.URL) error {
+	if u.Scheme != "https" || u.Hostname() == "" {
+		return fmt.Errorf("%w: only https urls are accepted", ErrAttachmentURLNotAllowed)
+	}
+	if len(f.allowedHosts) > 0 && !f.allowedHosts[strings.ToLower(u.Hostname())] {
+		return fmt.Errorf("%w: host %s is not in the allowlist", ErrAttachmentURLNotAllowed, u.Hostname())
+	}
+	return nil
+}
+
+// dialPublicOnly rechaza las conexiones a direcciones que no son públicas. Se ejecuta
+// con la IP ya resuelta, así que un DNS que apunte a la red interna no la evita.
+func dialPublicOnly(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil || !isPublicAddr(addr) {
+		return fmt.Errorf("%w: %s is not a public address", ErrAttachmentURLNotAllowed, host)
+	}
+	return nil
+}
+
+// isPublicAddr indica si la dirección es pública: no privada, de loopback, link-local,
+// multicast ni de los rangos reservados
+func isPublicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if addr.IsPrivate() || addr.IsLoopback() || addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() ||
+		addr.IsInterfaceLocalMulticast() || addr.IsMulticast() || addr.IsUnspecified() {
+		return false
+	}
+	for _, prefix := range reservedPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// resolveAttachments obtiene el contenido de cada adjunto, descargando los que vienen
+// por URL. Los adjuntos inválidos o con URLs no permitidas devuelven PermanentError.
+func (f *attachmentFetcher) resolveAttachments(ctx context.Context, attachments []model.Attachment) ([]email.Attachment, error) {
+	resolved := make([]email.Attachment, 0, len(attachments))
+
+	for _, attachment := range attachments {
+		var data []byte
+		contentType := attachment.ContentType
+
+		switch {
+		case attachment.Content != "":
+			decoded, err := base64.StdEncoding.DecodeString(attachment.Content)
+			if err != nil {
+				return nil, &PermanentError{Err: fmt.Errorf("invalid base64 content for attachment %s: %w", attachment.Filename, err)}
+			}
+			data = decoded
+		case attachment.URL != "":
+			downloaded, downloadedType, err := f.download(ctx, attachment.URL)
+			if err != nil {
+				err = fmt.Errorf("error downloading attachment %s: %w", attachment.Filename, err)
+				if errors.Is(err, ErrAttachmentURLNotAllowed) {
+					return nil, &PermanentError{Err: err}
+				}
+				return nil, err
+			}
+			data = downloaded
+			if contentType == "" {
+				contentType = downloadedType
+			}
+		default:
+			return nil, &PermanentError{Err: fmt.Errorf("attachment %s has neither content nor url", attachment.Filename)}
+		}
+
+		resolved = append(resolved, email.Attachment{
+			Filename:    attachment.Filename,
+			ContentType: contentType,
+			Data:        data,
+		})
+	}
+
+	return resolved, nil
+}
+
+// download descarga un adjunto por URL con un límite de tamaño
+func (f *attachmentFetcher) download(ctx context.Context, rawURL string) ([]byte, string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %v", ErrAttachmentURLNotAllowed, err)
+	}
+	if err := f.checkURL(u); err != nil {
+		return nil, "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, "", err
+	}
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxAttachmentDownloadSize+1))
+	if err != nil {
+		return nil, "", err
+	}
+	if len(data) > maxAttachmentDownloadSize {
+		return nil, "", fmt.Errorf("attachment exceeds %d bytes", maxAttachmentDownloadSize)
+	}
+
+	return data, resp.Header.Get("Content-Type"), nil
+}
@@ -0,0 +1,80 @@
+package channel
+
+import (
+	"errors"
+	"net/netip"
+	"net/url"
+	"testing"
+)
+
+func TestIsPublicAddr(t *testing.T) {
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{"93.184.216.34", true},
+		{"2606:2800:220:1:248:1893:25c8:1946", true},
+		{"127.0.0.1", false},
+		{"10.0.0.5", false},
+		{"172.16.3.4", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"100.64.0.1", false},
+		{"0.0.0.0", false},
+		{"::1", false},
+		{"fe80::1", false},
+		{"fd00:ec2::254", false},
+		{"::ffff:127.0.0.1", false},
+		{"64:ff9b::a9fe:a9fe", false},
+	}
+
+	for _, tt := range tests {
+		if got := isPublicAddr(netip.MustParseAddr(tt.addr)); got != tt.want {
+			t.Errorf("isPublicAddr(%s) = %v, se esperaba %v", tt.addr, got, tt.want)
+		}
+	}
+}
+
+func TestAttachmentFetcherCheckURL(t *testing.T) {
+	open := newAttachmentFetcher(nil)
+	restricted := newAttachmentFetcher([]string{"tickets.example.com"})
+
+	tests := []struct {
+		name    string
+		fetcher *attachmentFetcher
+		url     string
+		allowed bool
+	}{
+		{"https sin lista de hosts", open, "https://cdn.example.org/qr.png", true},
+		{"http", open, "http://cdn.example.org/qr.png", false},
+		{"otro esquema", open, "file:///etc/passwd", false},
+		{"sin host", open, "https:///qr.png", false},
+		{"host permitido", restricted, "https://Tickets.Example.com/t.pdf", true},
+		{"host fuera de la lista", restricted, "https://evil.example.com/t.pdf", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, err := url.Parse(tt.url)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = tt.fetcher.checkURL(u)
+			if (err == nil) != tt.allowed {
+				t.Errorf("checkURL(%s) = %v, se esperaba permitida=%v", tt.url, err, tt.allowed)
+			}
+			if err != nil && !errors.Is(err, ErrAttachmentURLNotAllowed) {
+				t.Errorf("checkURL(%s) = %v, se esperaba ErrAttachmentURLNotAllowed", tt.url, err)
+			}
+		})
+	}
+}
+
+func TestDialPublicOnly(t *testing.T) {
+	if err := dialPublicOnly("tcp", "169.254.169.254:443", nil); !errors.Is(err, ErrAttachmentURLNotAllowed) {
+		t.Errorf("dialPublicOnly(169.254.169.254) = %v, se esperaba ErrAttachmentURLNotAllowed", err)
+	}
+	if err := dialPublicOnly("tcp", "93.184.216.34:443", nil); err != nil {
+		t.Errorf("dialPublicOnly(93.184.216.34) = %v", err)
+	}
+}
@@ -0,0 +1,118 @@
+package channel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/jhonathanssegura/ticket-notification/internal/model"
+)
+
+var (
+	// ErrUnknownChannel indica que no hay ningún canal registrado con ese tipo
+	ErrUnknownChannel = errors.New("unknown channel")
+	// ErrInvalidRecipient indica que la dirección no es válida para el canal
+	ErrInvalidRecipient = errors.New("invalid recipient")
+)
+
+// SuppressedError indica que el canal no envió la notificación porque el
+// destinatario está suprimido; la entrega no debe reintentarse
+type SuppressedError struct {
+	Reason model.SuppressionReason
+}
+
+func (e *SuppressedError) Error() string {
+	return fmt.Sprintf("recipient suppressed: %s", e.Reason)
+}
+
+// PermanentError indica un fallo de entrega que no se resuelve reintentando, como un
+// rechazo definitivo del destino
+type PermanentError struct {
+	Err error
+}
+
+func (e *PermanentError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap permite comprobar con errors.Is y errors.As el error original
+func (e *PermanentError) Unwrap() error {
+	return e.Err
+}
+
+// Channel es un medio de entrega de notificaciones (email, SMS, push, webhook...)
+type Channel interface {
+	// Type devuelve el tipo de canal que implementa
+	Type() model.ChannelType
+	// Validate comprueba que la dirección del destinatario sea válida para el canal
+	Validate(recipient string) error
+	// Send entrega la notificación al destinatario
+	Send(ctx context.Context, notification *model.Notification, recipient string) (Result, error)
+}
+
+// Result representa el resultado de una entrega exitosa
+type Result struct {
+	ProviderMessageID string
+	Metadata          map[string]string
+}
+
+// Registry mantiene los canales de entrega disponibles por tipo
+type Registry struct {
+	mu       sync.RWMutex
+	channels map[model.ChannelType]Channel
+}
+
+// NewRegistry crea un registro con los canales indicados
+func NewRegistry(channels ...Channel) *Registry {
+	registry := &Registry{channels: make(map[model.ChannelType]Channel)}
+	for _, channel := range channels {
+		registry.Register(channel)
+	}
+	return registry
+}
+
+// Register añade un canal al registro, reemplazando el anterior del mismo tipo
+func (r *Registry) Register(channel Channel) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.channels[channel.Type()] = channel
+}
+
+// Get obtiene el canal registrado para un tipo
+func (r *Registry) Get(channelType model.ChannelType) (Channel, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	channel, ok := r.channels[channelType]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownChannel, channelType)
+	}
+	return channel, nil
+}
+
+// Validate comprueba que el canal exista y que la dirección sea válida para él
+func (r *Registry) Validate(channelType model.ChannelType, recipient string) error {
+	channel, err := r.Get(channelType)
+	if err != nil {
+		return err
+	}
+	if err := channel.Validate(recipient); err != nil {
+		return fmt.Errorf("%w for %s: %v", ErrInvalidRecipient, channelType, err)
+	}
+	return nil
+}
+
+// Types devuelve los tipos de canal registrados, ordenados
+func (r *Registry) Types() []model.ChannelType {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	types := make([]model.ChannelType, 0, len(r.channels))
+	for channelType := range r.channels {
+		types = append(types, channelType)
+	}
+	sort.Slice(types, func(i, j int) bool { return types[i] < types[j] })
+	return types
+}
@@ -0,0 +1,149 @@
+package channel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/mail"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ses"
+	sestypes "github.com/aws/aws-sdk-go-v2/service/ses/types"
+	"github.com/jhonathanssegura/ticket-notification/internal/db"
+	"github.com/jhonathanssegura/ticket-notification/internal/email"
+	"github.com/jhonathanssegura/ticket-notification/internal/model"
+)
+
+// EmailChannel entrega notificaciones por email usando SES. No envía a las
+// direcciones de la lista de supresión y registra el ID de cada mensaje para
+// asociar después los rebotes, quejas y entregas que informa SES.
+type EmailChannel struct {
+	client      *ses.Client
+	source      string
+	dbClient    *db.DynamoClient
+	attachments *attachmentFetcher
+}
+
+// NewEmailChannel crea el canal de email con el remitente indicado. Los adjuntos por
+// URL solo se descargan por https y de los hosts de attachmentHosts, si se indica alguno.
+func NewEmailChannel(client *ses.Client, source string, dbClient *db.DynamoClient, attachmentHosts []string) *EmailChannel {
+	return &EmailChannel{
+		client:      client,
+		source:      source,
+		dbClient:    dbClient,
+		attachments: newAttachmentFetcher(attachmentHosts),
+	}
+}
+
+// Type devuelve el tipo de canal
+func (c *EmailChannel) Type() model.ChannelType {
+	return model.ChannelEmail
+}
+
+// Validate comprueba que el destinatario sea una dirección de email
+func (c *EmailChannel) Validate(recipient string) error {
+	_, err := mail.ParseAddress(recipient)
+	return err
+}
+
+// Send envía la notificación usando SES si la dirección no está suprimida. Si
+// tiene adjuntos se construye el mensaje MIME completo.
+func (c *EmailChannel) Send(ctx context.Context, notification *model.Notification, recipient string) (Result, error) {
+	suppression, err := c.dbClient.GetEmailSuppression(recipient)
+	if err == nil {
+		log.Printf("Email a %s no enviado: dirección suprimida (%s)", recipient, suppression.Reason)
+		return Result{}, &SuppressedError{Reason: suppression.Reason}
+	}
+	if !errors.Is(err, db.ErrNotFound) {
+		return Result{}, fmt.Errorf("error checking suppression list: %w", err)
+	}
+
+	var result Result
+	if len(notification.Attachments) > 0 {
+		result, err = c.sendRaw(ctx, notification, recipient)
+	} else {
+		result, err = c.send(ctx, notification, recipient)
+	}
+	if err != nil {
+		return Result{}, err
+	}
+
+	if err := c.dbClient.SaveEmailMessage(result.ProviderMessageID, notification.ID.String(), recipient, time.Now()); err != nil {
+		log.Printf("Error registrando mensaje SES %s: %v", result.ProviderMessageID, err)
+	}
+	return result, nil
+}
+
+// send envía una notificación sin adjuntos mediante SES SendEmail. Si tiene cuerpo
+// HTML, SES la envía como multipart/alternative con el texto plano como alternativa.
+func (c *EmailChannel) send(ctx context.Context, notification *model.Notification, recipient string) (Result, error) {
+	// Configurar el email
+	emailInput := &ses.SendEmailInput{
+		Source: aws.String(c.source),
+		Destination: &sestypes.Destination{
+			ToAddresses: []string{recipient},
+		},
+		Message: &sestypes.Message{
+			Subject: &sestypes.Content{
+				Data:    aws.String(notification.Subject),
+				Charset: aws.String("UTF-8"),
+			},
+			Body: &sestypes.Body{
+				Text: &sestypes.Content{
+					Data:    aws.String(notification.Content),
+					Charset: aws.String("UTF-8"),
+				},
+			},
+		},
+	}
+
+	if notification.HTMLContent != "" {
+		emailInput.Message.Body.Html = &sestypes.Content{
+			Data:    aws.String(notification.HTMLContent),
+			Charset: aws.String("UTF-8"),
+		}
+	}
+
+	// Enviar el email
+	result, err := c.client.SendEmail(ctx, emailInput)
+	if err != nil {
+		return Result{}, fmt.Errorf("error sending email via SES: %w", err)
+	}
+
+	log.Printf("Email notification sent successfully to %s", recipient)
+	return Result{ProviderMessageID: aws.ToString(result.MessageId)}, nil
+}
+
+// sendRaw envía una notificación con adjuntos mediante SES SendRawEmail
+func (c *EmailChannel) sendRaw(ctx context.Context, notification *model.Notification, recipient string) (Result, error) {
+	attachments, err := c.attachments.resolveAttachments(ctx, notification.Attachments)
+	if err != nil {
+		return Result{}, err
+	}
+
+	raw, err := email.BuildRawMessage(email.Message{
+		From:        c.source,
+		To:          []string{recipient},
+		Subject:     notification.Subject,
+		Text:        notification.Content,
+		HTML:        notification.HTMLContent,
+		Attachments: attachments,
+	})
+	if err != nil {
+		return Result{}, fmt.Errorf("error building raw email: %w", err)
+	}
+
+	result, err := c.client.SendRawEmail(ctx, &ses.SendRawEmailInput{
+		Source:       aws.String(c.source),
+		Destinations: []string{recipient},
+		RawMessage:   &sestypes.RawMessage{Data: raw},
+	})
+	if err != nil {
+		return Result{}, fmt.Errorf("error sending raw email via SES: %w", err)
+	}
+
+	log.Printf("Email notification with %d attachments sent successfully to %s", len(attachments), recipient)
+	return Result{ProviderMessageID: aws.ToString(result.MessageId)}, nil
+}
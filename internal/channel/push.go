@@ -0,0 +1,172 @@
+package channel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/jhonathanssegura/ticket-notification/internal/db"
+	"github.com/jhonathanssegura/ticket-notification/internal/model"
+	"github.com/jhonathanssegura/ticket-notification/internal/push"
+)
+
+// maxPushBodyRunes limita el texto de la notificación push; los proveedores
+// rechazan payloads de más de 4KB y las pantallas solo muestran unas líneas
+const maxPushBodyRunes = 500
+
+// PushChannel entrega notificaciones push a todos los dispositivos registrados de
+// un usuario. El destinatario es el ID del usuario. Los tokens que el proveedor
+// rechaza como inválidos se eliminan del registro.
+type PushChannel struct {
+	dbClient   *db.DynamoClient
+	transports map[model.DevicePlatform]push.Transport
+	autoTypes  map[model.NotificationType]bool
+}
+
+// NewPushChannel crea el canal de push con un transporte por plataforma. autoTypes son
+// los tipos de notificación que se envían por push al destinatario sin pedirlo explícitamente.
+func NewPushChannel(dbClient *db.DynamoClient, transports map[model.DevicePlatform]push.Transport, autoTypes []model.NotificationType) *PushChannel {
+	types := make(map[model.NotificationType]bool, len(autoTypes))
+	for _, notificationType := range autoTypes {
+		types[notificationType] = true
+	}
+	return &PushChannel{
+		dbClient:   dbClient,
+		transports: transports,
+		autoTypes:  types,
+	}
+}
+
+// Type devuelve el tipo de canal
+func (c *PushChannel) Type() model.ChannelType {
+	return model.ChannelPush
+}
+
+// Validate comprueba que el destinatario sea un ID de usuario
+func (c *PushChannel) Validate(recipient string) error {
+	if strings.TrimSpace(recipient) == "" {
+		return errors.New("user ID is required")
+	}
+	return nil
+}
+
+// Subscribes indica si las notificaciones del tipo dado se envían por push automáticamente
+func (c *PushChannel) Subscribes(notificationType model.NotificationType) bool {
+	return c.autoTypes[notificationType]
+}
+
+// Send envía la notificación a cada dispositivo del usuario. Es exitosa si llega
+// al menos a un dispositivo o si no queda ninguno al que enviarla.
+func (c *PushChannel) Send(ctx context.Context, notification *model.Notification, recipient string) (Result, error) {
+	devices, err := c.dbClient.GetDeviceTokens(recipient, "")
+	if err != nil {
+		return Result{}, fmt.Errorf("error loading devices of %s: %w", recipient, err)
+	}
+	if len(devices) == 0 {
+		return Result{Metadata: map[string]string{"skipped": "no registered devices"}}, nil
+	}
+
+	message := pushMessage(notification)
+
+	var messageIDs, failures []string
+	pruned, unsupported := 0, 0
+	transient := false
+	for _, device := range devices {
+		transport, ok := c.transports[device.Platform]
+		if !ok {
+			// Sin proveedor para la plataforma reintentar no serviría de nada
+			unsupported++
+			continue
+		}
+
+		messageID, err := transport.Send(ctx, device.Token, message)
+		if err == nil {
+			messageIDs = append(messageIDs, messageID)
+			continue
+		}
+
+		if errors.Is(err, push.ErrInvalidToken) {
+			log.Printf("Eliminando token push inválido del usuario %s (%s): %v", recipient, device.Platform, err)
+			if err := c.dbClient.DeleteDeviceToken(recipient, device.Token); err != nil && !errors.Is(err, db.ErrNotFound) {
+				log.Printf("Error eliminando token push del usuario %s: %v", recipient, err)
+			}
+			pruned++
+			continue
+		}
+		failures = append(failures, fmt.Sprintf("%s: %v", device.Platform, err))
+		var providerErr *push.ProviderError
+		if !errors.As(err, &providerErr) || providerErr.Retryable() {
+			transient = true
+		}
+	}
+
+	metadata := map[string]string{
+		"devices": strconv.Itoa(len(devices)),
+		"sent":    strconv.Itoa(len(messageIDs)),
+		"failed":  strconv.Itoa(len(failures)),
+		"pruned":  strconv.Itoa(pruned),
+	}
+	if unsupported > 0 {
+		metadata["unsupported"] = strconv.Itoa(unsupported)
+	}
+
+	if len(messageIDs) == 0 {
+		if len(failures) > 0 {
+			err := fmt.Errorf("push failed on all devices: %s", strings.Join(failures, "; "))
+			if !transient {
+				// Todos los proveedores rechazaron el mensaje; reintentar no cambiaría el resultado
+				return Result{}, &PermanentError{Err: err}
+			}
+			return Result{}, err
+		}
+		metadata["skipped"] = "no device could receive the notification"
+		return Result{Metadata: metadata}, nil
+	}
+	if len(failures) > 0 {
+		metadata["errors"] = strings.Join(failures, "; ")
+	}
+
+	log.Printf("Push notification sent successfully to %s (%d of %d devices)", recipient, len(messageIDs), len(devices))
+	return Result{
+		ProviderMessageID: strings.Join(messageIDs, ","),
+		Metadata:          metadata,
+	}, nil
+}
+
+// pushMessage arma la notificación push a partir del asunto y el texto renderizados
+// por la plantilla, con los datos escalares como datos de la aplicación
+func pushMessage(notification *model.Notification) push.Message {
+	body := strings.Join(strings.Fields(notification.Content), " ")
+	if runes := []rune(body); len(runes) > maxPushBodyRunes {
+		body = string(runes[:maxPushBodyRunes-1]) + "…"
+	}
+
+	data := make(map[string]string, len(notification.Data)+2)
+	for key, value := range notification.Data {
+		// FCM rechaza las claves reservadas
+		if key == "from" || strings.HasPrefix(key, "google") || strings.HasPrefix(key, "gcm") {
+			continue
+		}
+		switch v := value.(type) {
+		case string:
+			data[key] = v
+		case bool, int, int64, float64:
+			data[key] = fmt.Sprint(v)
+		}
+	}
+	data["notification_id"] = notification.ID.String()
+	data["type"] = string(notification.Type)
+
+	return push.Message{
+		Title: notification.Subject,
+		Body:  body,
+		Data:  data,
+		// Solo las de prioridad baja pueden esperar a que el dispositivo salga del ahorro de energía
+		High: notification.Priority != model.NotificationPriorityLow,
+		// Los reintentos de la misma notificación reemplazan a la anterior en el dispositivo
+		CollapseID: notification.ID.String(),
+	}
+}
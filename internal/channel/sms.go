@@ -0,0 +1,89 @@
+package channel
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/jhonathanssegura/ticket-notification/internal/model"
+	"github.com/jhonathanssegura/ticket-notification/internal/sms"
+)
+
+// SMSTransport publica un mensaje de texto en un número de teléfono
+type SMSTransport interface {
+	Publish(ctx context.Context, phoneNumber, message string) (string, error)
+}
+
+// SMSChannel entrega notificaciones por SMS. Los textos que superan el máximo
+// de segmentos se truncan o, si está habilitado, se dividen en varios mensajes.
+type SMSChannel struct {
+	transport   SMSTransport
+	maxSegments int
+	split       bool
+}
+
+// NewSMSChannel crea el canal de SMS sobre el transporte indicado
+func NewSMSChannel(transport SMSTransport, maxSegments int, split bool) *SMSChannel {
+	if maxSegments < 1 {
+		maxSegments = 1
+	}
+	return &SMSChannel{
+		transport:   transport,
+		maxSegments: maxSegments,
+		split:       split,
+	}
+}
+
+// Type devuelve el tipo de canal
+func (c *SMSChannel) Type() model.ChannelType {
+	return model.ChannelSMS
+}
+
+// Validate comprueba que el destinatario sea un número en formato E.164
+func (c *SMSChannel) Validate(recipient string) error {
+	return sms.ValidateE164(recipient)
+}
+
+// Send envía el contenido de texto de la notificación por SMS
+func (c *SMSChannel) Send(ctx context.Context, notification *model.Notification, recipient string) (Result, error) {
+	text := strings.TrimSpace(notification.Content)
+	if text == "" {
+		text = notification.Subject
+	}
+
+	info := sms.Analyze(text)
+	parts := []string{text}
+	truncated := false
+	if info.Segments > c.maxSegments {
+		if c.split {
+			parts = sms.Split(text, c.maxSegments)
+		} else {
+			parts = []string{sms.Truncate(text, c.maxSegments)}
+			truncated = true
+		}
+	}
+
+	var messageIDs []string
+	segments := 0
+	for i, part := range parts {
+		messageID, err := c.transport.Publish(ctx, recipient, part)
+		if err != nil {
+			return Result{}, fmt.Errorf("error publishing SMS part %d/%d: %w", i+1, len(parts), err)
+		}
+		messageIDs = append(messageIDs, messageID)
+		segments += sms.Analyze(part).Segments
+	}
+
+	log.Printf("SMS notification sent successfully to %s (%d parts, %d segments, %s)", recipient, len(parts), segments, info.Encoding)
+	return Result{
+		ProviderMessageID: strings.Join(messageIDs, ","),
+		Metadata: map[string]string{
+			"encoding":  string(info.Encoding),
+			"segments":  strconv.Itoa(segments),
+			"parts":     strconv.Itoa(len(parts)),
+			"truncated": strconv.FormatBool(truncated),
+		},
+	}, nil
+}
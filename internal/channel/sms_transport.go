@@ -0,0 +1,152 @@
+package channel
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	snstypes "github.com/aws/aws-sdk-go-v2/service/sns/types"
+	"github.com/google/uuid"
+)
+
+// SNSTransport publica SMS mediante AWS SNS
+type SNSTransport struct {
+	client   *sns.Client
+	senderID string
+	smsType  string
+}
+
+// NewSNSTransport crea el transporte SNS. smsType es "Transactional" o "Promotional".
+func NewSNSTransport(client *sns.Client, senderID, smsType string) *SNSTransport {
+	if smsType == "" {
+		smsType = "Transactional"
+	}
+	return &SNSTransport{
+		client:   client,
+		senderID: senderID,
+		smsType:  smsType,
+	}
+}
+
+// Publish envía el SMS directamente al número de teléfono
+func (t *SNSTransport) Publish(ctx context.Context, phoneNumber, message string) (string, error) {
+	attributes := map[string]snstypes.MessageAttributeValue{
+		"AWS.SNS.SMS.SMSType": {
+			DataType:    aws.String("String"),
+			StringValue: aws.String(t.smsType),
+		},
+	}
+	if t.senderID != "" {
+		attributes["AWS.SNS.SMS.SenderID"] = snstypes.MessageAttributeValue{
+			DataType:    aws.String("String"),
+			StringValue: aws.String(t.senderID),
+		}
+	}
+
+	result, err := t.client.Publish(ctx, &sns.PublishInput{
+		PhoneNumber:       aws.String(phoneNumber),
+		Message:           aws.String(message),
+		MessageAttributes: attributes,
+	})
+	if err != nil {
+		return "", fmt.Errorf("error publishing SMS via SNS: %w", err)
+	}
+
+	return aws.ToString(result.MessageId), nil
+}
+
+// SentSMS representa un SMS capturado por un transporte local
+type SentSMS struct {
+	ID          string    `json:"id"`
+	PhoneNumber string    `json:"phone_number"`
+	Message     string    `json:"message"`
+	SentAt      time.Time `json:"sent_at"`
+}
+
+// MemoryTransport guarda los SMS en memoria en lugar de enviarlos
+type MemoryTransport struct {
+	mu       sync.Mutex
+	messages []SentSMS
+}
+
+// NewMemoryTransport crea un transporte de SMS en memoria
+func NewMemoryTransport() *MemoryTransport {
+	return &MemoryTransport{}
+}
+
+// Publish registra el SMS en memoria
+func (t *MemoryTransport) Publish(ctx context.Context, phoneNumber, message string) (string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	sent := SentSMS{
+		ID:          uuid.New().String(),
+		PhoneNumber: phoneNumber,
+		Message:     message,
+		SentAt:      time.Now(),
+	}
+	t.messages = append(t.messages, sent)
+	return sent.ID, nil
+}
+
+// Messages devuelve una copia de los SMS registrados
+func (t *MemoryTransport) Messages() []SentSMS {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	messages := make([]SentSMS, len(t.messages))
+	copy(messages, t.messages)
+	return messages
+}
+
+// Reset descarta los SMS registrados
+func (t *MemoryTransport) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.messages = nil
+}
+
+// FileTransport escribe cada SMS como una línea JSON en un archivo
+type FileTransport struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewFileTransport crea un transporte de SMS que escribe en el archivo indicado
+func NewFileTransport(path string) *FileTransport {
+	return &FileTransport{path: path}
+}
+
+// Publish añade el SMS al archivo
+func (t *FileTransport) Publish(ctx context.Context, phoneNumber, message string) (string, error) {
+	sent := SentSMS{
+		ID:          uuid.New().String(),
+		PhoneNumber: phoneNumber,
+		Message:     message,
+		SentAt:      time.Now(),
+	}
+	line, err := json.Marshal(sent)
+	if err != nil {
+		return "", err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	file, err := os.OpenFile(t.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return "", fmt.Errorf("error opening SMS outbox %s: %w", t.path, err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		return "", fmt.Errorf("error writing SMS outbox %s: %w", t.path, err)
+	}
+
+	return sent.ID, nil
+}
@@ -0,0 +1,202 @@
+package channel
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jhonathanssegura/ticket-notification/internal/db"
+	"github.com/jhonathanssegura/ticket-notification/internal/model"
+)
+
+const (
+	// WebhookSignatureHeader lleva la firma HMAC-SHA256 del cuerpo
+	WebhookSignatureHeader = "X-Webhook-Signature"
+	// WebhookTimestampHeader lleva el instante de firma en segundos Unix
+	WebhookTimestampHeader = "X-Webhook-Timestamp"
+
+	// maxWebhookResponseBody limita la respuesta que se guarda en el log de intentos
+	maxWebhookResponseBody = 1024
+)
+
+// WebhookPayload es el cuerpo JSON que reciben los suscriptores
+type WebhookPayload struct {
+	ID        string                 `json:"id"`
+	Type      model.NotificationType `json:"type"`
+	CreatedAt time.Time              `json:"created_at"`
+	Data      interface{}            `json:"data"`
+}
+
+// WebhookChannel entrega notificaciones como callbacks HTTP firmados. El
+// destinatario es el ID de la suscripción y cada intento queda registrado. Cada
+// entrega hace un solo intento: los fallos transitorios los reintenta la cola o el
+// outbox con su política de reintentos.
+type WebhookChannel struct {
+	dbClient   *db.DynamoClient
+	httpClient *http.Client
+}
+
+// NewWebhookChannel crea el canal de webhooks
+func NewWebhookChannel(dbClient *db.DynamoClient) *WebhookChannel {
+	return &WebhookChannel{
+		dbClient:   dbClient,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Type devuelve el tipo de canal
+func (c *WebhookChannel) Type() model.ChannelType {
+	return model.ChannelWebhook
+}
+
+// Validate comprueba que el destinatario sea el ID de una suscripción
+func (c *WebhookChannel) Validate(recipient string) error {
+	_, err := uuid.Parse(recipient)
+	return err
+}
+
+// Send envía la notificación a la URL de la suscripción
+func (c *WebhookChannel) Send(ctx context.Context, notification *model.Notification, recipient string) (Result, error) {
+	subscription, err := c.dbClient.GetWebhookSubscription(recipient)
+	if err != nil {
+		// Si la suscripción se eliminó o desactivó después de encolar, no hay nada que entregar
+		if errors.Is(err, db.ErrNotFound) {
+			return Result{Metadata: map[string]string{"skipped": "subscription not found"}}, nil
+		}
+		return Result{}, fmt.Errorf("error loading webhook subscription %s: %w", recipient, err)
+	}
+	if !subscription.IsActive {
+		return Result{Metadata: map[string]string{"skipped": "subscription inactive"}}, nil
+	}
+
+	body, err := json.Marshal(WebhookPayload{
+		ID:        notification.ID.String(),
+		Type:      notification.Type,
+		CreatedAt: notification.CreatedAt,
+		Data:      webhookData(notification),
+	})
+	if err != nil {
+		return Result{}, fmt.Errorf("error encoding webhook payload: %w", err)
+	}
+
+	attempt := webhookAttempt(notification, recipient)
+	record, retryable := c.post(ctx, subscription, notification, body, attempt)
+	if err := c.dbClient.SaveWebhookDeliveryAttempt(record); err != nil {
+		log.Printf("Error guardando intento de webhook %s: %v", record.ID, err)
+	}
+
+	if !record.Success {
+		err := fmt.Errorf("webhook attempt %d failed: %s", attempt, record.Error)
+		if !retryable {
+			return Result{}, &PermanentError{Err: err}
+		}
+		return Result{}, err
+	}
+
+	log.Printf("Webhook notification sent successfully to %s (attempt %d)", subscription.URL, attempt)
+	return Result{
+		ProviderMessageID: record.ID.String(),
+		Metadata: map[string]string{
+			"status_code": strconv.Itoa(record.StatusCode),
+			"attempts":    strconv.Itoa(attempt),
+		},
+	}, nil
+}
+
+// webhookAttempt devuelve el número de intento de la entrega a la suscripción, que
+// cuenta también los intentos anteriores de la misma notificación
+func webhookAttempt(notification *model.Notification, recipient string) int {
+	for _, delivery := range notification.Deliveries {
+		if delivery.Channel == model.ChannelWebhook && delivery.Recipient == recipient && delivery.Attempts > 0 {
+			return delivery.Attempts
+		}
+	}
+	return 1
+}
+
+// post realiza un intento de entrega y devuelve su registro y si el fallo es reintentable
+func (c *WebhookChannel) post(ctx context.Context, subscription *model.WebhookSubscription, notification *model.Notification, body []byte, attempt int) (model.WebhookDeliveryAttempt, bool) {
+	start := time.Now()
+	record := model.WebhookDeliveryAttempt{
+		ID:             uuid.New(),
+		SubscriptionID: subscription.ID,
+		NotificationID: notification.ID.String(),
+		EventType:      notification.Type,
+		URL:            subscription.URL,
+		Attempt:        attempt,
+		CreatedAt:      start,
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.URL, bytes.NewReader(body))
+	if err != nil {
+		record.Error = err.Error()
+		return record, false
+	}
+
+	timestamp := strconv.FormatInt(start.Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "ticket-notification-webhooks/1.0")
+	req.Header.Set("X-Webhook-ID", notification.ID.String())
+	req.Header.Set("X-Webhook-Event", string(notification.Type))
+	req.Header.Set("X-Webhook-Attempt", strconv.Itoa(attempt))
+	req.Header.Set(WebhookTimestampHeader, timestamp)
+	req.Header.Set(WebhookSignatureHeader, SignWebhookPayload(subscription.Secret, timestamp, body))
+
+	resp, err := c.httpClient.Do(req)
+	record.DurationMs = time.Since(start).Milliseconds()
+	if err != nil {
+		record.Error = err.Error()
+		return record, true
+	}
+	defer resp.Body.Close()
+
+	responseBody, _ := io.ReadAll(io.LimitReader(resp.Body, maxWebhookResponseBody))
+	record.StatusCode = resp.StatusCode
+	record.ResponseBody = string(responseBody)
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		record.Success = true
+		return record, false
+	}
+
+	record.Error = fmt.Sprintf("unexpected status %d", resp.StatusCode)
+	// Los errores del cliente son definitivos salvo el rate limiting
+	return record, resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+}
+
+// SignWebhookPayload firma "<timestamp>.<cuerpo>" con HMAC-SHA256 usando el
+// secreto de la suscripción. Los suscriptores deben recalcularla para verificar
+// el origen del webhook.
+func SignWebhookPayload(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// webhookData devuelve los datos del webhook: el mensaje de la cola que originó
+// la notificación o, en envíos directos, los datos de la notificación
+func webhookData(notification *model.Notification) interface{} {
+	if notification.Source != nil {
+		return notification.Source
+	}
+	return map[string]interface{}{
+		"recipient": notification.Recipient,
+		"subject":   notification.Subject,
+		"content":   notification.Content,
+		"priority":  notification.Priority,
+		"data":      notification.Data,
+	}
+}
@@ -0,0 +1,37 @@
+package channel
+
+import "testing"
+
+func TestSignWebhookPayload(t *testing.T) {
+	tests := []struct {
+		name      string
+		secret    string
+		timestamp string
+		body      string
+		want      string
+	}{
+		{
+			"cuerpo JSON",
+			"whsec_test", "1700000000", `{"id":"n-1"}`,
+			"sha256=2ad863fe8c1f786b16143d2d66e8a91950eea3c3b46bfc117045cf9e84f9b8af",
+		},
+		{
+			"secreto y cuerpo vacíos",
+			"", "0", "",
+			"sha256=b849d5a581847b281957065739df36df2463d1977ea8d6e1e4e6cf33fadc68c3",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SignWebhookPayload(tt.secret, tt.timestamp, []byte(tt.body)); got != tt.want {
+				t.Errorf("SignWebhookPayload() = %s, se esperaba %s", got, tt.want)
+			}
+		})
+	}
+
+	// El timestamp forma parte de la firma: otro instante no puede reutilizarla
+	if SignWebhookPayload("whsec_test", "1700000000", []byte("{}")) == SignWebhookPayload("whsec_test", "1700000001", []byte("{}")) {
+		t.Error("la firma no depende del timestamp")
+	}
+}
@@ -0,0 +1,189 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/jhonathanssegura/ticket-notification/internal/model"
+)
+
+// AddEventAudienceMember registra la reserva del destinatario en la audiencia del evento.
+// Registrar dos veces la misma reserva no tiene efecto. Si se indican canales, reemplazan
+// a los guardados.
+func (d *DynamoClient) AddEventAudienceMember(eventID, recipient, reservationID string, channels []model.ChannelRecipient, at time.Time) error {
+	updateExpression := "ADD reservation_ids :reservation_ids SET updated_at = :updated_at"
+	values := map[string]types.AttributeValue{
+		":reservation_ids": &types.AttributeValueMemberSS{Value: []string{reservationID}},
+		":updated_at":      &types.AttributeValueMemberS{Value: at.Format(time.RFC3339)},
+	}
+	if len(channels) > 0 {
+		channelsJSON, err := json.Marshal(channels)
+		if err != nil {
+			return fmt.Errorf("error codificando canales del asistente: %v", err)
+		}
+		updateExpression += ", channels = :channels"
+		values[":channels"] = &types.AttributeValueMemberS{Value: string(channelsJSON)}
+	}
+
+	_, err := d.Client.UpdateItem(context.TODO(), &dynamodb.UpdateItemInput{
+		TableName:                 aws.String("event_audience"),
+		Key:                       audienceKey(eventID, recipient),
+		UpdateExpression:          aws.String(updateExpression),
+		ExpressionAttributeValues: values,
+	})
+	if err != nil {
+		if strings.Contains(err.Error(), "ResourceNotFoundException") {
+			return errors.New("La tabla 'event_audience' no existe en DynamoDB. Verifique que LocalStack esté ejecutándose y la tabla haya sido creada.")
+		}
+		return fmt.Errorf("error registrando asistente del evento en DynamoDB: %v", err)
+	}
+	return nil
+}
+
+// RemoveEventAudienceMember quita la reserva del destinatario de la audiencia del evento.
+// Devuelve true si el destinatario todavía tiene otras reservas para el evento.
+func (d *DynamoClient) RemoveEventAudienceMember(eventID, recipient, reservationID string, at time.Time) (bool, error) {
+	result, err := d.Client.UpdateItem(context.TODO(), &dynamodb.UpdateItemInput{
+		TableName:           aws.String("event_audience"),
+		Key:                 audienceKey(eventID, recipient),
+		ConditionExpression: aws.String("attribute_exists(recipient)"),
+		UpdateExpression:    aws.String("DELETE reservation_ids :reservation_ids SET updated_at = :updated_at"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":reservation_ids": &types.AttributeValueMemberSS{Value: []string{reservationID}},
+			":updated_at":      &types.AttributeValueMemberS{Value: at.Format(time.RFC3339)},
+		},
+		ReturnValues: types.ReturnValueAllNew,
+	})
+	if err != nil {
+		if strings.Contains(err.Error(), "ConditionalCheckFailed") {
+			return false, nil
+		}
+		return false, fmt.Errorf("error quitando reserva de la audiencia en DynamoDB: %v", err)
+	}
+
+	// DynamoDB elimina el conjunto al quitarle el último elemento
+	if _, ok := result.Attributes["reservation_ids"]; ok {
+		return true, nil
+	}
+
+	// Sin reservas el destinatario sale de la audiencia, salvo que entretanto haya reservado otra vez
+	_, err = d.Client.DeleteItem(context.TODO(), &dynamodb.DeleteItemInput{
+		TableName:           aws.String("event_audience"),
+		Key:                 audienceKey(eventID, recipient),
+		ConditionExpression: aws.String("attribute_not_exists(reservation_ids)"),
+	})
+	if err != nil {
+		if strings.Contains(err.Error(), "ConditionalCheckFailed") {
+			return true, nil
+		}
+		return false, fmt.Errorf("error eliminando asistente del evento en DynamoDB: %v", err)
+	}
+	return false, nil
+}
+
+// GetEventAudience lista, ordenados por destinatario, hasta limit asistentes del evento
+// posteriores a startAfter (vacío para empezar desde el primero)
+func (d *DynamoClient) GetEventAudience(eventID, startAfter string, limit int) ([]model.EventAudienceMember, error) {
+	queryInput := &dynamodb.QueryInput{
+		TableName:              aws.String("event_audience"),
+		KeyConditionExpression: aws.String("event_id = :event_id"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":event_id": &types.AttributeValueMemberS{Value: eventID},
+		},
+	}
+	if startAfter != "" {
+		queryInput.ExclusiveStartKey = audienceKey(eventID, startAfter)
+	}
+
+	var members []model.EventAudienceMember
+	for {
+		queryInput.Limit = aws.Int32(int32(limit - len(members)))
+		result, err := d.Client.Query(context.TODO(), queryInput)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, item := range result.Items {
+			member, err := d.unmarshalEventAudienceMember(item)
+			if err != nil {
+				return nil, err
+			}
+			members = append(members, *member)
+		}
+
+		if len(result.LastEvaluatedKey) == 0 || len(members) >= limit {
+			return members, nil
+		}
+		queryInput.ExclusiveStartKey = result.LastEvaluatedKey
+	}
+}
+
+// CountEventAudience cuenta los destinatarios con reserva para el evento
+func (d *DynamoClient) CountEventAudience(eventID string) (int, error) {
+	queryInput := &dynamodb.QueryInput{
+		TableName:              aws.String("event_audience"),
+		KeyConditionExpression: aws.String("event_id = :event_id"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":event_id": &types.AttributeValueMemberS{Value: eventID},
+		},
+		Select: types.SelectCount,
+	}
+
+	total := 0
+	for {
+		result, err := d.Client.Query(context.TODO(), queryInput)
+		if err != nil {
+			return 0, err
+		}
+		total += int(result.Count)
+
+		if len(result.LastEvaluatedKey) == 0 {
+			return total, nil
+		}
+		queryInput.ExclusiveStartKey = result.LastEvaluatedKey
+	}
+}
+
+// unmarshalEventAudienceMember convierte un item de DynamoDB a EventAudienceMember
+func (d *DynamoClient) unmarshalEventAudienceMember(item map[string]types.AttributeValue) (*model.EventAudienceMember, error) {
+	member := &model.EventAudienceMember{}
+
+	if eventIDVal, ok := item["event_id"].(*types.AttributeValueMemberS); ok {
+		member.EventID = eventIDVal.Value
+	}
+
+	if recipientVal, ok := item["recipient"].(*types.AttributeValueMemberS); ok {
+		member.Recipient = recipientVal.Value
+	}
+
+	if reservationIDsVal, ok := item["reservation_ids"].(*types.AttributeValueMemberSS); ok {
+		member.ReservationIDs = reservationIDsVal.Value
+	}
+
+	if channelsVal, ok := item["channels"].(*types.AttributeValueMemberS); ok {
+		if err := json.Unmarshal([]byte(channelsVal.Value), &member.Channels); err != nil {
+			return nil, fmt.Errorf("error decodificando canales del asistente: %v", err)
+		}
+	}
+
+	if updatedAtVal, ok := item["updated_at"].(*types.AttributeValueMemberS); ok {
+		member.UpdatedAt, _ = time.Parse(time.RFC3339, updatedAtVal.Value)
+	}
+
+	return member, nil
+}
+
+// audienceKey arma la clave de un asistente en la tabla event_audience
+func audienceKey(eventID, recipient string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		"event_id":  &types.AttributeValueMemberS{Value: eventID},
+		"recipient": &types.AttributeValueMemberS{Value: recipient},
+	}
+}
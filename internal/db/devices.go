@@ -0,0 +1,192 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/jhonathanssegura/ticket-notification/internal/model"
+)
+
+// deviceTokenIndex es el índice de dispositivos por token, para encontrar a su usuario
+const deviceTokenIndex = "token-user_id-index"
+
+// SaveDeviceToken registra un dispositivo del usuario o actualiza su plataforma y versión,
+// conservando la fecha del primer registro. Si el token estaba registrado a nombre de
+// otro usuario (cambio de sesión en el mismo dispositivo) se le quita.
+func (d *DynamoClient) SaveDeviceToken(device model.DeviceToken) (*model.DeviceToken, error) {
+	fmt.Printf("Registrando dispositivo: UserID=%s, Platform=%s\n", device.UserID, device.Platform)
+
+	result, err := d.Client.UpdateItem(context.TODO(), &dynamodb.UpdateItemInput{
+		TableName: aws.String("device_tokens"),
+		Key: map[string]types.AttributeValue{
+			"user_id": &types.AttributeValueMemberS{Value: device.UserID},
+			"token":   &types.AttributeValueMemberS{Value: device.Token},
+		},
+		UpdateExpression: aws.String("SET #platform = :platform, #app_version = :app_version, #created_at = if_not_exists(#created_at, :now), #updated_at = :now"),
+		ExpressionAttributeNames: map[string]string{
+			"#platform":    "platform",
+			"#app_version": "app_version",
+			"#created_at":  "created_at",
+			"#updated_at":  "updated_at",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":platform":    &types.AttributeValueMemberS{Value: string(device.Platform)},
+			":app_version": &types.AttributeValueMemberS{Value: device.AppVersion},
+			":now":         &types.AttributeValueMemberS{Value: device.UpdatedAt.Format(time.RFC3339)},
+		},
+		ReturnValues: types.ReturnValueAllNew,
+	})
+	if err != nil {
+		if strings.Contains(err.Error(), "ResourceNotFoundException") {
+			return nil, errors.New("La tabla 'device_tokens' no existe en DynamoDB. Verifique que LocalStack esté ejecutándose y la tabla haya sido creada.")
+		}
+		return nil, fmt.Errorf("error guardando dispositivo en DynamoDB: %v", err)
+	}
+
+	owners, err := d.getDeviceTokenOwners(device.Token)
+	if err != nil {
+		fmt.Printf("Error buscando otros usuarios del dispositivo: %v\n", err)
+	}
+	for _, owner := range owners {
+		if owner == device.UserID {
+			continue
+		}
+		if err := d.DeleteDeviceToken(owner, device.Token); err != nil {
+			fmt.Printf("Error quitando dispositivo al usuario %s: %v\n", owner, err)
+		}
+	}
+
+	return d.unmarshalDeviceToken(result.Attributes)
+}
+
+// GetDeviceTokens lista los dispositivos de un usuario, filtrando opcionalmente por plataforma
+func (d *DynamoClient) GetDeviceTokens(userID string, platform model.DevicePlatform) ([]model.DeviceToken, error) {
+	queryInput := &dynamodb.QueryInput{
+		TableName:              aws.String("device_tokens"),
+		KeyConditionExpression: aws.String("user_id = :user_id"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":user_id": &types.AttributeValueMemberS{Value: userID},
+		},
+	}
+	if platform != "" {
+		queryInput.FilterExpression = aws.String("#platform = :platform")
+		queryInput.ExpressionAttributeNames = map[string]string{"#platform": "platform"}
+		queryInput.ExpressionAttributeValues[":platform"] = &types.AttributeValueMemberS{Value: string(platform)}
+	}
+
+	var devices []model.DeviceToken
+	for {
+		result, err := d.Client.Query(context.TODO(), queryInput)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, item := range result.Items {
+			device, err := d.unmarshalDeviceToken(item)
+			if err != nil {
+				return nil, err
+			}
+			devices = append(devices, *device)
+		}
+
+		if len(result.LastEvaluatedKey) == 0 {
+			break
+		}
+		queryInput.ExclusiveStartKey = result.LastEvaluatedKey
+	}
+
+	return devices, nil
+}
+
+// DeleteDeviceToken elimina un dispositivo del usuario. Devuelve ErrNotFound si no existía.
+func (d *DynamoClient) DeleteDeviceToken(userID, token string) error {
+	_, err := d.Client.DeleteItem(context.TODO(), &dynamodb.DeleteItemInput{
+		TableName: aws.String("device_tokens"),
+		Key: map[string]types.AttributeValue{
+			"user_id": &types.AttributeValueMemberS{Value: userID},
+			"token":   &types.AttributeValueMemberS{Value: token},
+		},
+		ConditionExpression: aws.String("attribute_exists(#token)"),
+		ExpressionAttributeNames: map[string]string{
+			"#token": "token",
+		},
+	})
+	if err != nil {
+		if strings.Contains(err.Error(), "ConditionalCheckFailed") {
+			return fmt.Errorf("device %w", ErrNotFound)
+		}
+		return err
+	}
+	return nil
+}
+
+// getDeviceTokenOwners obtiene los usuarios que tienen registrado un token
+func (d *DynamoClient) getDeviceTokenOwners(token string) ([]string, error) {
+	result, err := d.Client.Query(context.TODO(), &dynamodb.QueryInput{
+		TableName:              aws.String("device_tokens"),
+		IndexName:              aws.String(deviceTokenIndex),
+		KeyConditionExpression: aws.String("#token = :token"),
+		ExpressionAttributeNames: map[string]string{
+			"#token": "token",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":token": &types.AttributeValueMemberS{Value: token},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var owners []string
+	for _, item := range result.Items {
+		if userIDVal, ok := item["user_id"].(*types.AttributeValueMemberS); ok {
+			owners = append(owners, userIDVal.Value)
+		}
+	}
+	return owners, nil
+}
+
+// unmarshalDeviceToken convierte un item de DynamoDB a DeviceToken
+func (d *DynamoClient) unmarshalDeviceToken(item map[string]types.AttributeValue) (*model.DeviceToken, error) {
+	device := &model.DeviceToken{}
+
+	if userIDVal, ok := item["user_id"].(*types.AttributeValueMemberS); ok {
+		device.UserID = userIDVal.Value
+	}
+
+	if tokenVal, ok := item["token"].(*types.AttributeValueMemberS); ok {
+		device.Token = tokenVal.Value
+	}
+
+	if platformVal, ok := item["platform"].(*types.AttributeValueMemberS); ok {
+		device.Platform = model.DevicePlatform(platformVal.Value)
+	}
+
+	if appVersionVal, ok := item["app_version"].(*types.AttributeValueMemberS); ok {
+		device.AppVersion = appVersionVal.Value
+	}
+
+	if createdAtVal, ok := item["created_at"].(*types.AttributeValueMemberS); ok {
+		createdAt, err := time.Parse(time.RFC3339, createdAtVal.Value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid created_at time: %v", err)
+		}
+		device.CreatedAt = createdAt
+	}
+
+	if updatedAtVal, ok := item["updated_at"].(*types.AttributeValueMemberS); ok {
+		updatedAt, err := time.Parse(time.RFC3339, updatedAtVal.Value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid updated_at time: %v", err)
+		}
+		device.UpdatedAt = updatedAt
+	}
+
+	return device, nil
+}
@@ -2,8 +2,10 @@ package db
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
@@ -14,8 +16,17 @@ import (
 	"github.com/jhonathanssegura/ticket-notification/internal/model"
 )
 
+// MaxStoredAttachmentContent limita el base64 de los adjuntos que se guarda con la
+// notificación para poder reenviarla al retomarla; un item admite como máximo 400 KB
+const MaxStoredAttachmentContent = 256 * 1024
+
+// ErrNotFound indica que el elemento buscado no existe en DynamoDB
+var ErrNotFound = errors.New("not found")
+
 type DynamoClient struct {
 	Client *dynamodb.Client
+	// OnNotificationSaved, si está definido, se llama después de guardar cada notificación
+	OnNotificationSaved func(notification model.Notification)
 }
 
 // SaveNotification guarda una notificación en DynamoDB
@@ -23,6 +34,24 @@ func (d *DynamoClient) SaveNotification(notification model.Notification) error {
 	fmt.Printf("Guardando notificación: ID=%s, Type=%s, Recipient=%s\n",
 		notification.ID.String(), notification.Type, notification.Recipient)
 
+	_, err := d.Client.PutItem(context.TODO(), &dynamodb.PutItemInput{
+		TableName: aws.String("notifications"),
+		Item:      notificationItem(notification),
+	})
+
+	if err != nil {
+		return notificationSaveError(err)
+	}
+
+	if d.OnNotificationSaved != nil {
+		d.OnNotificationSaved(notification)
+	}
+
+	return nil
+}
+
+// notificationItem convierte una notificación al item de DynamoDB
+func notificationItem(notification model.Notification) map[string]types.AttributeValue {
 	item := map[string]types.AttributeValue{
 		"id":          &types.AttributeValueMemberS{Value: notification.ID.String()},
 		"type":        &types.AttributeValueMemberS{Value: string(notification.Type)},
@@ -37,39 +66,137 @@ func (d *DynamoClient) SaveNotification(notification model.Notification) error {
 	}
 
 	// Campos opcionales
+	if notification.HTMLContent != "" {
+		item["html_content"] = &types.AttributeValueMemberS{Value: notification.HTMLContent}
+	}
+	if notification.TemplateVersion > 0 {
+		item["template_version"] = &types.AttributeValueMemberN{Value: strconv.Itoa(notification.TemplateVersion)}
+	}
+	if notification.Locale != "" {
+		item["locale"] = &types.AttributeValueMemberS{Value: notification.Locale}
+	}
+	if len(notification.Attachments) > 0 {
+		// Solo se guardan los metadatos de los adjuntos, no su contenido
+		attachments := make([]model.Attachment, len(notification.Attachments))
+		for i, attachment := range notification.Attachments {
+			attachments[i] = model.Attachment{
+				Filename:    attachment.Filename,
+				ContentType: attachment.ContentType,
+				URL:         attachment.URL,
+			}
+		}
+		attachmentsJSON, err := json.Marshal(attachments)
+		if err == nil {
+			item["attachments"] = &types.AttributeValueMemberS{Value: string(attachmentsJSON)}
+		}
+
+		// El contenido se guarda aparte, para reenviarla sin incluirlo en las respuestas
+		if contents := attachmentContents(notification.Attachments); len(contents) > 0 {
+			item["attachment_contents"] = &types.AttributeValueMemberM{Value: contents}
+		}
+	}
+	if len(notification.Deliveries) > 0 {
+		deliveriesJSON, err := json.Marshal(notification.Deliveries)
+		if err == nil {
+			item["deliveries"] = &types.AttributeValueMemberS{Value: string(deliveriesJSON)}
+		}
+	}
+	if notification.SendAt != nil {
+		item["send_at"] = &types.AttributeValueMemberS{Value: indexTime(*notification.SendAt)}
+	}
 	if notification.SentAt != nil {
 		item["sent_at"] = &types.AttributeValueMemberS{Value: notification.SentAt.Format(time.RFC3339)}
 	}
 	if notification.ReadAt != nil {
 		item["read_at"] = &types.AttributeValueMemberS{Value: notification.ReadAt.Format(time.RFC3339)}
 	}
+	// Se guardan en UTC para poder compararlas como texto en las condiciones y el índice
+	if notification.LockedUntil != nil {
+		item["locked_until"] = &types.AttributeValueMemberS{Value: indexTime(*notification.LockedUntil)}
+	}
+	if notification.NextAttemptAt != nil {
+		item["next_attempt_at"] = &types.AttributeValueMemberS{Value: indexTime(*notification.NextAttemptAt)}
+	}
+	if notification.Attempts > 0 {
+		item["attempts"] = &types.AttributeValueMemberN{Value: strconv.Itoa(notification.Attempts)}
+	}
 
-	// Convertir datos adicionales a JSON string (simplificado)
+	// Convertir datos adicionales a JSON string; las notificaciones programadas los necesitan al enviarse
 	if len(notification.Data) > 0 {
-		dataStr := fmt.Sprintf("%v", notification.Data)
-		item["data"] = &types.AttributeValueMemberS{Value: dataStr}
+		dataJSON, err := json.Marshal(notification.Data)
+		if err == nil {
+			item["data"] = &types.AttributeValueMemberS{Value: string(dataJSON)}
+		}
 	}
 
-	_, err := d.Client.PutItem(context.TODO(), &dynamodb.PutItemInput{
+	return item
+}
+
+// notificationSaveError describe el error de DynamoDB al guardar una notificación
+func notificationSaveError(err error) error {
+	var errorMsg string
+	switch {
+	case strings.Contains(err.Error(), "ResourceNotFoundException"):
+		errorMsg = "La tabla 'notifications' no existe en DynamoDB. Verifique que LocalStack esté ejecutándose y la tabla haya sido creada."
+	case strings.Contains(err.Error(), "RequestCanceled"):
+		errorMsg = "Error de conexión con DynamoDB. Verifique que LocalStack esté ejecutándose en http://localhost:4566."
+	case strings.Contains(err.Error(), "ConditionalCheckFailedException"):
+		errorMsg = "La notificación ya existe en la base de datos."
+	default:
+		errorMsg = fmt.Sprintf("Error guardando notificación en DynamoDB: %v", err)
+	}
+	return errors.New(errorMsg)
+}
+
+// AttachmentContentSize devuelve el tamaño del base64 de los adjuntos
+func AttachmentContentSize(attachments []model.Attachment) int {
+	size := 0
+	for _, attachment := range attachments {
+		size += len(attachment.Content)
+	}
+	return size
+}
+
+// attachmentContents arma el contenido de los adjuntos en base64 por posición. Si
+// supera MaxStoredAttachmentContent no se guarda y la notificación no se puede retomar.
+func attachmentContents(attachments []model.Attachment) map[string]types.AttributeValue {
+	size := AttachmentContentSize(attachments)
+	if size == 0 || size > MaxStoredAttachmentContent {
+		return nil
+	}
+
+	contents := make(map[string]types.AttributeValue)
+	for i, attachment := range attachments {
+		if attachment.Content != "" {
+			contents[strconv.Itoa(i)] = &types.AttributeValueMemberS{Value: attachment.Content}
+		}
+	}
+	return contents
+}
+
+// LoadAttachmentContents completa los adjuntos de la notificación con el contenido en
+// base64 que se guardó con ella
+func (d *DynamoClient) LoadAttachmentContents(notification *model.Notification) error {
+	result, err := d.Client.GetItem(context.TODO(), &dynamodb.GetItemInput{
 		TableName: aws.String("notifications"),
-		Item:      item,
+		Key: map[string]types.AttributeValue{
+			"id": &types.AttributeValueMemberS{Value: notification.ID.String()},
+		},
+		ProjectionExpression: aws.String("attachment_contents"),
 	})
-
 	if err != nil {
-		var errorMsg string
-		switch {
-		case strings.Contains(err.Error(), "ResourceNotFoundException"):
-			errorMsg = "La tabla 'notifications' no existe en DynamoDB. Verifique que LocalStack esté ejecutándose y la tabla haya sido creada."
-		case strings.Contains(err.Error(), "RequestCanceled"):
-			errorMsg = "Error de conexión con DynamoDB. Verifique que LocalStack esté ejecutándose en http://localhost:4566."
-		case strings.Contains(err.Error(), "ConditionalCheckFailedException"):
-			errorMsg = "La notificación ya existe en la base de datos."
-		default:
-			errorMsg = fmt.Sprintf("Error guardando notificación en DynamoDB: %v", err)
-		}
-		return fmt.Errorf(errorMsg)
+		return fmt.Errorf("error loading attachment contents: %w", err)
 	}
 
+	contents, _ := result.Item["attachment_contents"].(*types.AttributeValueMemberM)
+	if contents == nil {
+		return nil
+	}
+	for i := range notification.Attachments {
+		if content, ok := contents.Value[strconv.Itoa(i)].(*types.AttributeValueMemberS); ok {
+			notification.Attachments[i].Content = content.Value
+		}
+	}
 	return nil
 }
 
@@ -86,7 +213,7 @@ func (d *DynamoClient) GetNotificationByID(notificationID string) (*model.Notifi
 	}
 
 	if result.Item == nil {
-		return nil, errors.New("notification not found")
+		return nil, fmt.Errorf("notification %w", ErrNotFound)
 	}
 
 	notification, err := d.unmarshalNotification(result.Item)
@@ -99,6 +226,11 @@ func (d *DynamoClient) GetNotificationByID(notificationID string) (*model.Notifi
 
 // GetNotifications obtiene notificaciones con filtros opcionales
 func (d *DynamoClient) GetNotifications(recipient string, notificationType string, limit int) ([]model.Notification, error) {
+	// Con destinatario se consulta el índice en lugar de recorrer toda la tabla
+	if recipient != "" {
+		return d.getRecipientNotifications(recipient, notificationType, limit)
+	}
+
 	scanInput := &dynamodb.ScanInput{
 		TableName: aws.String("notifications"),
 		Limit:     aws.Int32(int32(limit)),
@@ -225,42 +357,83 @@ func (d *DynamoClient) SaveNotificationTemplate(template model.NotificationTempl
 	fmt.Printf("Guardando plantilla: ID=%s, Name=%s, Type=%s\n",
 		template.ID.String(), template.Name, template.Type)
 
+	_, err := d.Client.PutItem(context.TODO(), &dynamodb.PutItemInput{
+		TableName: aws.String("notification_templates"),
+		Item:      templateItem(template),
+	})
+
+	if err != nil {
+		var errorMsg string
+		switch {
+		case strings.Contains(err.Error(), "ResourceNotFoundException"):
+			errorMsg = "La tabla 'notification_templates' no existe en DynamoDB. Verifique que LocalStack esté ejecutándose y la tabla haya sido creada."
+		case strings.Contains(err.Error(), "RequestCanceled"):
+			errorMsg = "Error de conexión con DynamoDB. Verifique que LocalStack esté ejecutándose en http://localhost:4566."
+		default:
+			errorMsg = fmt.Sprintf("Error guardando plantilla en DynamoDB: %v", err)
+		}
+		return errors.New(errorMsg)
+	}
+
+	return nil
+}
+
+// templateItem convierte una plantilla en un item de DynamoDB
+func templateItem(template model.NotificationTemplate) map[string]types.AttributeValue {
 	item := map[string]types.AttributeValue{
 		"id":         &types.AttributeValueMemberS{Value: template.ID.String()},
 		"name":       &types.AttributeValueMemberS{Value: template.Name},
 		"type":       &types.AttributeValueMemberS{Value: string(template.Type)},
 		"subject":    &types.AttributeValueMemberS{Value: template.Subject},
 		"content":    &types.AttributeValueMemberS{Value: template.Content},
+		"version":    &types.AttributeValueMemberN{Value: strconv.Itoa(template.Version)},
 		"is_active":  &types.AttributeValueMemberBOOL{Value: template.IsActive},
 		"created_at": &types.AttributeValueMemberS{Value: template.CreatedAt.Format(time.RFC3339)},
 		"updated_at": &types.AttributeValueMemberS{Value: template.UpdatedAt.Format(time.RFC3339)},
 	}
 
+	if template.HTMLContent != "" {
+		item["html_content"] = &types.AttributeValueMemberS{Value: template.HTMLContent}
+	}
+	addTemplateLocales(item, template.Locale, template.Locales)
+
 	// Convertir variables a string (simplificado)
 	if len(template.Variables) > 0 {
 		variablesStr := strings.Join(template.Variables, ",")
 		item["variables"] = &types.AttributeValueMemberS{Value: variablesStr}
 	}
 
-	_, err := d.Client.PutItem(context.TODO(), &dynamodb.PutItemInput{
-		TableName: aws.String("notification_templates"),
-		Item:      item,
-	})
+	return item
+}
 
-	if err != nil {
-		var errorMsg string
-		switch {
-		case strings.Contains(err.Error(), "ResourceNotFoundException"):
-			errorMsg = "La tabla 'notification_templates' no existe en DynamoDB. Verifique que LocalStack esté ejecutándose y la tabla haya sido creada."
-		case strings.Contains(err.Error(), "RequestCanceled"):
-			errorMsg = "Error de conexión con DynamoDB. Verifique que LocalStack esté ejecutándose en http://localhost:4566."
-		default:
-			errorMsg = fmt.Sprintf("Error guardando plantilla en DynamoDB: %v", err)
+// addTemplateLocales agrega al item el idioma de la plantilla y sus traducciones como JSON
+func addTemplateLocales(item map[string]types.AttributeValue, locale string, locales map[string]model.TemplateLocale) {
+	if locale != "" {
+		item["locale"] = &types.AttributeValueMemberS{Value: locale}
+	}
+	if len(locales) > 0 {
+		localesJSON, err := json.Marshal(locales)
+		if err == nil {
+			item["locales"] = &types.AttributeValueMemberS{Value: string(localesJSON)}
+		}
+	}
+}
+
+// templateLocales lee del item el idioma de la plantilla y sus traducciones
+func templateLocales(item map[string]types.AttributeValue) (string, map[string]model.TemplateLocale, error) {
+	var locale string
+	if localeVal, ok := item["locale"].(*types.AttributeValueMemberS); ok {
+		locale = localeVal.Value
+	}
+
+	var locales map[string]model.TemplateLocale
+	if localesVal, ok := item["locales"].(*types.AttributeValueMemberS); ok {
+		if err := json.Unmarshal([]byte(localesVal.Value), &locales); err != nil {
+			return "", nil, fmt.Errorf("invalid template locales: %v", err)
 		}
-		return fmt.Errorf(errorMsg)
 	}
 
-	return nil
+	return locale, locales, nil
 }
 
 // GetNotificationTemplate obtiene una plantilla por ID
@@ -276,7 +449,7 @@ func (d *DynamoClient) GetNotificationTemplate(templateID string) (*model.Notifi
 	}
 
 	if result.Item == nil {
-		return nil, errors.New("template not found")
+		return nil, fmt.Errorf("template %w", ErrNotFound)
 	}
 
 	template, err := d.unmarshalNotificationTemplate(result.Item)
@@ -287,6 +460,128 @@ func (d *DynamoClient) GetNotificationTemplate(templateID string) (*model.Notifi
 	return template, nil
 }
 
+// templateNameIndex es el índice de plantillas por nombre ordenado por fecha de actualización
+const templateNameIndex = "name-updated_at-index"
+
+// GetNotificationTemplateByName obtiene una plantilla por nombre, priorizando las activas
+// y, entre varias, la actualizada más recientemente
+func (d *DynamoClient) GetNotificationTemplateByName(name string) (*model.NotificationTemplate, error) {
+	queryInput := &dynamodb.QueryInput{
+		TableName:              aws.String("notification_templates"),
+		IndexName:              aws.String(templateNameIndex),
+		KeyConditionExpression: aws.String("#name = :name"),
+		ExpressionAttributeNames: map[string]string{
+			"#name": "name",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":name": &types.AttributeValueMemberS{Value: name},
+		},
+		ScanIndexForward: aws.Bool(false),
+	}
+
+	var found *model.NotificationTemplate
+	for {
+		result, err := d.Client.Query(context.TODO(), queryInput)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, item := range result.Items {
+			template, err := d.unmarshalNotificationTemplate(item)
+			if err != nil {
+				return nil, err
+			}
+			if template.IsActive {
+				return template, nil
+			}
+			if found == nil {
+				found = template
+			}
+		}
+
+		if len(result.LastEvaluatedKey) == 0 {
+			break
+		}
+		queryInput.ExclusiveStartKey = result.LastEvaluatedKey
+	}
+
+	if found == nil {
+		return nil, fmt.Errorf("template %w", ErrNotFound)
+	}
+
+	return found, nil
+}
+
+// GetNotificationTemplates obtiene plantillas con filtros opcionales por tipo y estado
+func (d *DynamoClient) GetNotificationTemplates(templateType string, isActive *bool) ([]model.NotificationTemplate, error) {
+	scanInput := &dynamodb.ScanInput{
+		TableName: aws.String("notification_templates"),
+	}
+
+	var filterExpressions []string
+	expressionAttributeNames := make(map[string]string)
+	expressionAttributeValues := make(map[string]types.AttributeValue)
+
+	if templateType != "" {
+		filterExpressions = append(filterExpressions, "#type = :type")
+		expressionAttributeNames["#type"] = "type"
+		expressionAttributeValues[":type"] = &types.AttributeValueMemberS{Value: templateType}
+	}
+
+	if isActive != nil {
+		filterExpressions = append(filterExpressions, "#is_active = :is_active")
+		expressionAttributeNames["#is_active"] = "is_active"
+		expressionAttributeValues[":is_active"] = &types.AttributeValueMemberBOOL{Value: *isActive}
+	}
+
+	if len(filterExpressions) > 0 {
+		scanInput.FilterExpression = aws.String(strings.Join(filterExpressions, " AND "))
+		scanInput.ExpressionAttributeNames = expressionAttributeNames
+		scanInput.ExpressionAttributeValues = expressionAttributeValues
+	}
+
+	var templates []model.NotificationTemplate
+	for {
+		result, err := d.Client.Scan(context.TODO(), scanInput)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, item := range result.Items {
+			template, err := d.unmarshalNotificationTemplate(item)
+			if err != nil {
+				return nil, err
+			}
+			templates = append(templates, *template)
+		}
+
+		if len(result.LastEvaluatedKey) == 0 {
+			break
+		}
+		scanInput.ExclusiveStartKey = result.LastEvaluatedKey
+	}
+
+	return templates, nil
+}
+
+// DeleteNotificationTemplate elimina una plantilla y libera su nombre
+func (d *DynamoClient) DeleteNotificationTemplate(template model.NotificationTemplate) error {
+	_, err := d.Client.TransactWriteItems(context.TODO(), &dynamodb.TransactWriteItemsInput{
+		TransactItems: []types.TransactWriteItem{
+			{
+				Delete: &types.Delete{
+					TableName: aws.String("notification_templates"),
+					Key: map[string]types.AttributeValue{
+						"id": &types.AttributeValueMemberS{Value: template.ID.String()},
+					},
+				},
+			},
+			templateNameRelease(template.Name, template.ID.String()),
+		},
+	})
+	return err
+}
+
 // unmarshalNotification convierte un item de DynamoDB a Notification
 func (d *DynamoClient) unmarshalNotification(item map[string]types.AttributeValue) (*model.Notification, error) {
 	notification := &model.Notification{}
@@ -323,10 +618,47 @@ func (d *DynamoClient) unmarshalNotification(item map[string]types.AttributeValu
 		notification.Content = contentVal.Value
 	}
 
+	if htmlContentVal, ok := item["html_content"].(*types.AttributeValueMemberS); ok {
+		notification.HTMLContent = htmlContentVal.Value
+	}
+
 	if templateIDVal, ok := item["template_id"].(*types.AttributeValueMemberS); ok {
 		notification.TemplateID = templateIDVal.Value
 	}
 
+	if templateVersionVal, ok := item["template_version"].(*types.AttributeValueMemberN); ok {
+		templateVersion, err := strconv.Atoi(templateVersionVal.Value)
+		if err == nil {
+			notification.TemplateVersion = templateVersion
+		}
+	}
+
+	if localeVal, ok := item["locale"].(*types.AttributeValueMemberS); ok {
+		notification.Locale = localeVal.Value
+	}
+
+	if attachmentsVal, ok := item["attachments"].(*types.AttributeValueMemberS); ok {
+		var attachments []model.Attachment
+		if err := json.Unmarshal([]byte(attachmentsVal.Value), &attachments); err == nil {
+			notification.Attachments = attachments
+		}
+	}
+
+	// Las notificaciones antiguas guardaban los datos con formato de Go y no se recuperan
+	if dataVal, ok := item["data"].(*types.AttributeValueMemberS); ok {
+		var data map[string]interface{}
+		if err := json.Unmarshal([]byte(dataVal.Value), &data); err == nil {
+			notification.Data = data
+		}
+	}
+
+	if deliveriesVal, ok := item["deliveries"].(*types.AttributeValueMemberS); ok {
+		var deliveries []model.Delivery
+		if err := json.Unmarshal([]byte(deliveriesVal.Value), &deliveries); err == nil {
+			notification.Deliveries = deliveries
+		}
+	}
+
 	if createdAtVal, ok := item["created_at"].(*types.AttributeValueMemberS); ok {
 		createdAt, err := time.Parse(time.RFC3339, createdAtVal.Value)
 		if err != nil {
@@ -344,6 +676,13 @@ func (d *DynamoClient) unmarshalNotification(item map[string]types.AttributeValu
 	}
 
 	// Campos opcionales
+	if sendAtVal, ok := item["send_at"].(*types.AttributeValueMemberS); ok {
+		sendAt, err := time.Parse(time.RFC3339, sendAtVal.Value)
+		if err == nil {
+			notification.SendAt = &sendAt
+		}
+	}
+
 	if sentAtVal, ok := item["sent_at"].(*types.AttributeValueMemberS); ok {
 		sentAt, err := time.Parse(time.RFC3339, sentAtVal.Value)
 		if err == nil {
@@ -358,6 +697,27 @@ func (d *DynamoClient) unmarshalNotification(item map[string]types.AttributeValu
 		}
 	}
 
+	if lockedUntilVal, ok := item["locked_until"].(*types.AttributeValueMemberS); ok {
+		lockedUntil, err := time.Parse(time.RFC3339, lockedUntilVal.Value)
+		if err == nil {
+			notification.LockedUntil = &lockedUntil
+		}
+	}
+
+	if nextAttemptAtVal, ok := item["next_attempt_at"].(*types.AttributeValueMemberS); ok {
+		nextAttemptAt, err := time.Parse(time.RFC3339, nextAttemptAtVal.Value)
+		if err == nil {
+			notification.NextAttemptAt = &nextAttemptAt
+		}
+	}
+
+	if attemptsVal, ok := item["attempts"].(*types.AttributeValueMemberN); ok {
+		attempts, err := strconv.Atoi(attemptsVal.Value)
+		if err == nil {
+			notification.Attempts = attempts
+		}
+	}
+
 	return notification, nil
 }
 
@@ -389,6 +749,25 @@ func (d *DynamoClient) unmarshalNotificationTemplate(item map[string]types.Attri
 		template.Content = contentVal.Value
 	}
 
+	if htmlContentVal, ok := item["html_content"].(*types.AttributeValueMemberS); ok {
+		template.HTMLContent = htmlContentVal.Value
+	}
+
+	locale, locales, err := templateLocales(item)
+	if err != nil {
+		return nil, err
+	}
+	template.Locale = locale
+	template.Locales = locales
+
+	if versionVal, ok := item["version"].(*types.AttributeValueMemberN); ok {
+		version, err := strconv.Atoi(versionVal.Value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid template version: %v", err)
+		}
+		template.Version = version
+	}
+
 	if isActiveVal, ok := item["is_active"].(*types.AttributeValueMemberBOOL); ok {
 		template.IsActive = isActiveVal.Value
 	}
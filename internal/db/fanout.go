@@ -0,0 +1,357 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/jhonathanssegura/ticket-notification/internal/model"
+)
+
+// ErrFanoutJobNotFailed indica que el envío masivo no se puede reanudar porque no falló
+var ErrFanoutJobNotFailed = errors.New("fanout job has not failed")
+
+// CreateFanoutJob guarda un envío masivo nuevo
+func (d *DynamoClient) CreateFanoutJob(job model.FanoutJob) error {
+	item := map[string]types.AttributeValue{
+		"id":         &types.AttributeValueMemberS{Value: job.ID},
+		"event_id":   &types.AttributeValueMemberS{Value: job.EventID},
+		"type":       &types.AttributeValueMemberS{Value: string(job.Type)},
+		"status":     &types.AttributeValueMemberS{Value: string(job.Status)},
+		"event_name": &types.AttributeValueMemberS{Value: job.EventName},
+		"event_date": &types.AttributeValueMemberS{Value: job.EventDate.Format(time.RFC3339)},
+		"location":   &types.AttributeValueMemberS{Value: job.Location},
+		"priority":   &types.AttributeValueMemberS{Value: string(job.Priority)},
+		"total":      &types.AttributeValueMemberN{Value: strconv.Itoa(job.Total)},
+		"enqueued":   &types.AttributeValueMemberN{Value: strconv.Itoa(job.Enqueued)},
+		"skipped":    &types.AttributeValueMemberN{Value: strconv.Itoa(job.Skipped)},
+		"batches":    &types.AttributeValueMemberN{Value: strconv.Itoa(job.Batches)},
+		"created_at": &types.AttributeValueMemberS{Value: indexTime(job.CreatedAt)},
+		"updated_at": &types.AttributeValueMemberS{Value: job.UpdatedAt.Format(time.RFC3339)},
+	}
+	if job.PreviousEventDate != nil {
+		item["previous_event_date"] = &types.AttributeValueMemberS{Value: job.PreviousEventDate.Format(time.RFC3339)}
+	}
+	if job.PreviousLocation != "" {
+		item["previous_location"] = &types.AttributeValueMemberS{Value: job.PreviousLocation}
+	}
+	if len(job.Exclude) > 0 {
+		excludeJSON, err := json.Marshal(job.Exclude)
+		if err != nil {
+			return fmt.Errorf("error codificando destinatarios excluidos: %v", err)
+		}
+		item["exclude"] = &types.AttributeValueMemberS{Value: string(excludeJSON)}
+	}
+
+	_, err := d.Client.PutItem(context.TODO(), &dynamodb.PutItemInput{
+		TableName:           aws.String("fanout_jobs"),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(id)"),
+	})
+	if err != nil {
+		if strings.Contains(err.Error(), "ResourceNotFoundException") {
+			return errors.New("La tabla 'fanout_jobs' no existe en DynamoDB. Verifique que LocalStack esté ejecutándose y la tabla haya sido creada.")
+		}
+		return fmt.Errorf("error guardando envío masivo en DynamoDB: %v", err)
+	}
+	return nil
+}
+
+// GetFanoutJob obtiene un envío masivo. Devuelve ErrNotFound si no existe.
+func (d *DynamoClient) GetFanoutJob(jobID string) (*model.FanoutJob, error) {
+	result, err := d.Client.GetItem(context.TODO(), &dynamodb.GetItemInput{
+		TableName: aws.String("fanout_jobs"),
+		Key: map[string]types.AttributeValue{
+			"id": &types.AttributeValueMemberS{Value: jobID},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if result.Item == nil {
+		return nil, fmt.Errorf("fanout job %w", ErrNotFound)
+	}
+
+	return d.unmarshalFanoutJob(result.Item)
+}
+
+// GetFanoutJobsByStatus obtiene, del más antiguo al más nuevo, hasta limit envíos masivos en el estado indicado
+func (d *DynamoClient) GetFanoutJobsByStatus(status model.FanoutJobStatus, limit int) ([]model.FanoutJob, error) {
+	result, err := d.Client.Query(context.TODO(), &dynamodb.QueryInput{
+		TableName:              aws.String("fanout_jobs"),
+		IndexName:              aws.String("status-created_at-index"),
+		KeyConditionExpression: aws.String("#status = :status"),
+		ExpressionAttributeNames: map[string]string{
+			"#status": "status",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":status": &types.AttributeValueMemberS{Value: string(status)},
+		},
+		Limit: aws.Int32(int32(limit)),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	jobs := make([]model.FanoutJob, 0, len(result.Items))
+	for _, item := range result.Items {
+		job, err := d.unmarshalFanoutJob(item)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, *job)
+	}
+	return jobs, nil
+}
+
+// ClaimFanoutJob toma un envío masivo en curso hasta leaseUntil. Devuelve false si otra
+// instancia lo tiene tomado y su plazo no venció, o si ya no está en curso.
+func (d *DynamoClient) ClaimFanoutJob(jobID, leaseID string, leaseUntil, at time.Time) (bool, error) {
+	_, err := d.Client.UpdateItem(context.TODO(), &dynamodb.UpdateItemInput{
+		TableName: aws.String("fanout_jobs"),
+		Key: map[string]types.AttributeValue{
+			"id": &types.AttributeValueMemberS{Value: jobID},
+		},
+		ConditionExpression: aws.String("#status = :running AND (attribute_not_exists(lease_until) OR lease_until < :now)"),
+		UpdateExpression:    aws.String("SET lease_id = :lease_id, lease_until = :lease_until"),
+		ExpressionAttributeNames: map[string]string{
+			"#status": "status",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":running":     &types.AttributeValueMemberS{Value: string(model.FanoutJobStatusRunning)},
+			":now":         &types.AttributeValueMemberS{Value: indexTime(at)},
+			":lease_id":    &types.AttributeValueMemberS{Value: leaseID},
+			":lease_until": &types.AttributeValueMemberS{Value: indexTime(leaseUntil)},
+		},
+	})
+	if err != nil {
+		if strings.Contains(err.Error(), "ConditionalCheckFailed") {
+			return false, nil
+		}
+		return false, fmt.Errorf("error tomando envío masivo en DynamoDB: %v", err)
+	}
+	return true, nil
+}
+
+// UpdateFanoutJobProgress registra un lote procesado: avanza el cursor hasta el último
+// destinatario del lote, suma los encolados y omitidos y extiende el plazo. Devuelve false si el envío ya no está
+// tomado con leaseID.
+func (d *DynamoClient) UpdateFanoutJobProgress(jobID, leaseID, cursor string, enqueued, skipped int, leaseUntil, at time.Time) (bool, error) {
+	_, err := d.Client.UpdateItem(context.TODO(), &dynamodb.UpdateItemInput{
+		TableName: aws.String("fanout_jobs"),
+		Key: map[string]types.AttributeValue{
+			"id": &types.AttributeValueMemberS{Value: jobID},
+		},
+		ConditionExpression: aws.String("#status = :running AND lease_id = :lease_id"),
+		UpdateExpression:    aws.String("SET #cursor = :cursor, lease_until = :lease_until, updated_at = :updated_at ADD enqueued :enqueued, skipped :skipped, batches :one"),
+		ExpressionAttributeNames: map[string]string{
+			"#status": "status",
+			"#cursor": "cursor",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":running":     &types.AttributeValueMemberS{Value: string(model.FanoutJobStatusRunning)},
+			":lease_id":    &types.AttributeValueMemberS{Value: leaseID},
+			":cursor":      &types.AttributeValueMemberS{Value: cursor},
+			":lease_until": &types.AttributeValueMemberS{Value: indexTime(leaseUntil)},
+			":updated_at":  &types.AttributeValueMemberS{Value: at.Format(time.RFC3339)},
+			":enqueued":    &types.AttributeValueMemberN{Value: strconv.Itoa(enqueued)},
+			":skipped":     &types.AttributeValueMemberN{Value: strconv.Itoa(skipped)},
+			":one":         &types.AttributeValueMemberN{Value: "1"},
+		},
+	})
+	if err != nil {
+		if strings.Contains(err.Error(), "ConditionalCheckFailed") {
+			return false, nil
+		}
+		return false, fmt.Errorf("error actualizando avance del envío masivo en DynamoDB: %v", err)
+	}
+	return true, nil
+}
+
+// FinishFanoutJob marca el envío masivo como completado o fallido y lo libera.
+// Devuelve false si el envío ya no está tomado con leaseID.
+func (d *DynamoClient) FinishFanoutJob(jobID, leaseID string, status model.FanoutJobStatus, message string, at time.Time) (bool, error) {
+	updateExpression := "SET #status = :status, updated_at = :updated_at"
+	values := map[string]types.AttributeValue{
+		":running":    &types.AttributeValueMemberS{Value: string(model.FanoutJobStatusRunning)},
+		":lease_id":   &types.AttributeValueMemberS{Value: leaseID},
+		":status":     &types.AttributeValueMemberS{Value: string(status)},
+		":updated_at": &types.AttributeValueMemberS{Value: at.Format(time.RFC3339)},
+	}
+	if status == model.FanoutJobStatusCompleted {
+		updateExpression += ", completed_at = :updated_at"
+	}
+	names := map[string]string{
+		"#status": "status",
+	}
+	if message != "" {
+		updateExpression += ", #error = :error"
+		names["#error"] = "error"
+		values[":error"] = &types.AttributeValueMemberS{Value: message}
+	}
+	updateExpression += " REMOVE lease_id, lease_until"
+
+	_, err := d.Client.UpdateItem(context.TODO(), &dynamodb.UpdateItemInput{
+		TableName: aws.String("fanout_jobs"),
+		Key: map[string]types.AttributeValue{
+			"id": &types.AttributeValueMemberS{Value: jobID},
+		},
+		ConditionExpression:       aws.String("#status = :running AND lease_id = :lease_id"),
+		UpdateExpression:          aws.String(updateExpression),
+		ExpressionAttributeNames:  names,
+		ExpressionAttributeValues: values,
+	})
+	if err != nil {
+		if strings.Contains(err.Error(), "ConditionalCheckFailed") {
+			return false, nil
+		}
+		return false, fmt.Errorf("error finalizando envío masivo en DynamoDB: %v", err)
+	}
+	return true, nil
+}
+
+// ReleaseFanoutJob libera un envío masivo en curso para que otra ejecución lo retome
+// desde su cursor
+func (d *DynamoClient) ReleaseFanoutJob(jobID, leaseID string) error {
+	_, err := d.Client.UpdateItem(context.TODO(), &dynamodb.UpdateItemInput{
+		TableName: aws.String("fanout_jobs"),
+		Key: map[string]types.AttributeValue{
+			"id": &types.AttributeValueMemberS{Value: jobID},
+		},
+		ConditionExpression: aws.String("lease_id = :lease_id"),
+		UpdateExpression:    aws.String("REMOVE lease_id, lease_until"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":lease_id": &types.AttributeValueMemberS{Value: leaseID},
+		},
+	})
+	if err != nil && !strings.Contains(err.Error(), "ConditionalCheckFailed") {
+		return fmt.Errorf("error liberando envío masivo en DynamoDB: %v", err)
+	}
+	return nil
+}
+
+// ResumeFanoutJob vuelve a poner en curso un envío masivo fallido; seguirá desde el
+// último lote encolado. Devuelve ErrFanoutJobNotFailed si no está fallido.
+func (d *DynamoClient) ResumeFanoutJob(jobID string, at time.Time) (*model.FanoutJob, error) {
+	result, err := d.Client.UpdateItem(context.TODO(), &dynamodb.UpdateItemInput{
+		TableName: aws.String("fanout_jobs"),
+		Key: map[string]types.AttributeValue{
+			"id": &types.AttributeValueMemberS{Value: jobID},
+		},
+		ConditionExpression: aws.String("#status = :failed"),
+		UpdateExpression:    aws.String("SET #status = :running, updated_at = :updated_at REMOVE #error"),
+		ExpressionAttributeNames: map[string]string{
+			"#status": "status",
+			"#error":  "error",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":failed":     &types.AttributeValueMemberS{Value: string(model.FanoutJobStatusFailed)},
+			":running":    &types.AttributeValueMemberS{Value: string(model.FanoutJobStatusRunning)},
+			":updated_at": &types.AttributeValueMemberS{Value: at.Format(time.RFC3339)},
+		},
+		ReturnValues: types.ReturnValueAllNew,
+	})
+	if err != nil {
+		if strings.Contains(err.Error(), "ConditionalCheckFailed") {
+			return nil, ErrFanoutJobNotFailed
+		}
+		return nil, fmt.Errorf("error reanudando envío masivo en DynamoDB: %v", err)
+	}
+
+	return d.unmarshalFanoutJob(result.Attributes)
+}
+
+// unmarshalFanoutJob convierte un item de DynamoDB a FanoutJob
+func (d *DynamoClient) unmarshalFanoutJob(item map[string]types.AttributeValue) (*model.FanoutJob, error) {
+	job := &model.FanoutJob{}
+
+	if idVal, ok := item["id"].(*types.AttributeValueMemberS); ok {
+		job.ID = idVal.Value
+	}
+
+	if eventIDVal, ok := item["event_id"].(*types.AttributeValueMemberS); ok {
+		job.EventID = eventIDVal.Value
+	}
+
+	if typeVal, ok := item["type"].(*types.AttributeValueMemberS); ok {
+		job.Type = model.NotificationType(typeVal.Value)
+	}
+
+	if statusVal, ok := item["status"].(*types.AttributeValueMemberS); ok {
+		job.Status = model.FanoutJobStatus(statusVal.Value)
+	}
+
+	if eventNameVal, ok := item["event_name"].(*types.AttributeValueMemberS); ok {
+		job.EventName = eventNameVal.Value
+	}
+
+	if eventDateVal, ok := item["event_date"].(*types.AttributeValueMemberS); ok {
+		job.EventDate, _ = time.Parse(time.RFC3339, eventDateVal.Value)
+	}
+
+	if locationVal, ok := item["location"].(*types.AttributeValueMemberS); ok {
+		job.Location = locationVal.Value
+	}
+
+	if previousDateVal, ok := item["previous_event_date"].(*types.AttributeValueMemberS); ok {
+		if previousDate, err := time.Parse(time.RFC3339, previousDateVal.Value); err == nil {
+			job.PreviousEventDate = &previousDate
+		}
+	}
+
+	if previousLocationVal, ok := item["previous_location"].(*types.AttributeValueMemberS); ok {
+		job.PreviousLocation = previousLocationVal.Value
+	}
+
+	if priorityVal, ok := item["priority"].(*types.AttributeValueMemberS); ok {
+		job.Priority = model.NotificationPriority(priorityVal.Value)
+	}
+
+	if excludeVal, ok := item["exclude"].(*types.AttributeValueMemberS); ok {
+		if err := json.Unmarshal([]byte(excludeVal.Value), &job.Exclude); err != nil {
+			return nil, fmt.Errorf("error decodificando destinatarios excluidos: %v", err)
+		}
+	}
+
+	if cursorVal, ok := item["cursor"].(*types.AttributeValueMemberS); ok {
+		job.Cursor = cursorVal.Value
+	}
+
+	for name, field := range map[string]*int{"total": &job.Total, "enqueued": &job.Enqueued, "skipped": &job.Skipped, "batches": &job.Batches} {
+		if numberVal, ok := item[name].(*types.AttributeValueMemberN); ok {
+			value, err := strconv.Atoi(numberVal.Value)
+			if err != nil {
+				return nil, fmt.Errorf("error convirtiendo %s del envío masivo: %v", name, err)
+			}
+			*field = value
+		}
+	}
+
+	if errorVal, ok := item["error"].(*types.AttributeValueMemberS); ok {
+		job.Error = errorVal.Value
+	}
+
+	if createdAtVal, ok := item["created_at"].(*types.AttributeValueMemberS); ok {
+		job.CreatedAt, _ = time.Parse(time.RFC3339, createdAtVal.Value)
+	}
+
+	if updatedAtVal, ok := item["updated_at"].(*types.AttributeValueMemberS); ok {
+		job.UpdatedAt, _ = time.Parse(time.RFC3339, updatedAtVal.Value)
+	}
+
+	if completedAtVal, ok := item["completed_at"].(*types.AttributeValueMemberS); ok {
+		if completedAt, err := time.Parse(time.RFC3339, completedAtVal.Value); err == nil {
+			job.CompletedAt = &completedAt
+		}
+	}
+
+	return job, nil
+}
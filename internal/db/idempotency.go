@@ -0,0 +1,196 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/jhonathanssegura/ticket-notification/internal/model"
+)
+
+// ClaimIdempotencyKey toma la clave para una operación nueva con una escritura
+// condicional: solo se guarda si la clave no existe, si ya expiró o si la operación
+// que la tomó quedó abandonada (en curso con LockedUntil vencido). Si la clave está
+// tomada devuelve el registro existente y claimed en false.
+func (d *DynamoClient) ClaimIdempotencyKey(record model.IdempotencyRecord, now time.Time) (*model.IdempotencyRecord, bool, error) {
+	item := map[string]types.AttributeValue{
+		"idempotency_key": &types.AttributeValueMemberS{Value: record.Key},
+		"status":          &types.AttributeValueMemberS{Value: string(model.IdempotencyStatusInProgress)},
+		"locked_until":    &types.AttributeValueMemberN{Value: strconv.FormatInt(record.LockedUntil.Unix(), 10)},
+		"created_at":      &types.AttributeValueMemberS{Value: record.CreatedAt.Format(time.RFC3339)},
+		"expires_at":      &types.AttributeValueMemberN{Value: strconv.FormatInt(record.ExpiresAt.Unix(), 10)},
+	}
+	if record.RequestHash != "" {
+		item["request_hash"] = &types.AttributeValueMemberS{Value: record.RequestHash}
+	}
+
+	// Entre la escritura rechazada y la lectura el registro puede expirar o liberarse
+	for attempt := 0; attempt < 2; attempt++ {
+		_, err := d.Client.PutItem(context.TODO(), &dynamodb.PutItemInput{
+			TableName: aws.String("idempotency_keys"),
+			Item:      item,
+			// DynamoDB borra los registros vencidos con retraso, así que se comprueba expires_at
+			ConditionExpression: aws.String("attribute_not_exists(idempotency_key) OR expires_at < :now OR (#status = :in_progress AND locked_until < :now)"),
+			ExpressionAttributeNames: map[string]string{
+				"#status": "status",
+			},
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":now":         &types.AttributeValueMemberN{Value: strconv.FormatInt(now.Unix(), 10)},
+				":in_progress": &types.AttributeValueMemberS{Value: string(model.IdempotencyStatusInProgress)},
+			},
+		})
+		if err == nil {
+			record.Status = model.IdempotencyStatusInProgress
+			return &record, true, nil
+		}
+		if strings.Contains(err.Error(), "ResourceNotFoundException") {
+			return nil, false, errors.New("La tabla 'idempotency_keys' no existe en DynamoDB. Verifique que LocalStack esté ejecutándose y la tabla haya sido creada.")
+		}
+		if !strings.Contains(err.Error(), "ConditionalCheckFailed") {
+			return nil, false, fmt.Errorf("error tomando clave de idempotencia en DynamoDB: %v", err)
+		}
+
+		existing, err := d.GetIdempotencyRecord(record.Key)
+		if err == nil {
+			return existing, false, nil
+		}
+		if !errors.Is(err, ErrNotFound) {
+			return nil, false, err
+		}
+	}
+
+	return nil, false, fmt.Errorf("idempotency key %s changed while being claimed", record.Key)
+}
+
+// GetIdempotencyRecord obtiene el registro de una clave de idempotencia
+func (d *DynamoClient) GetIdempotencyRecord(key string) (*model.IdempotencyRecord, error) {
+	result, err := d.Client.GetItem(context.TODO(), &dynamodb.GetItemInput{
+		TableName: aws.String("idempotency_keys"),
+		Key: map[string]types.AttributeValue{
+			"idempotency_key": &types.AttributeValueMemberS{Value: key},
+		},
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if result.Item == nil {
+		return nil, fmt.Errorf("idempotency key %w", ErrNotFound)
+	}
+
+	return unmarshalIdempotencyRecord(result.Item)
+}
+
+// CompleteIdempotencyKey marca la operación como terminada y guarda su respuesta
+func (d *DynamoClient) CompleteIdempotencyKey(key string, responseStatus int, responseBody string) error {
+	update := "SET #status = :completed, response_status = :response_status"
+	values := map[string]types.AttributeValue{
+		":completed":       &types.AttributeValueMemberS{Value: string(model.IdempotencyStatusCompleted)},
+		":response_status": &types.AttributeValueMemberN{Value: strconv.Itoa(responseStatus)},
+	}
+	if responseBody != "" {
+		update += ", response_body = :response_body"
+		values[":response_body"] = &types.AttributeValueMemberS{Value: responseBody}
+	}
+
+	_, err := d.Client.UpdateItem(context.TODO(), &dynamodb.UpdateItemInput{
+		TableName: aws.String("idempotency_keys"),
+		Key: map[string]types.AttributeValue{
+			"idempotency_key": &types.AttributeValueMemberS{Value: key},
+		},
+		UpdateExpression:    aws.String(update),
+		ConditionExpression: aws.String("attribute_exists(idempotency_key)"),
+		ExpressionAttributeNames: map[string]string{
+			"#status": "status",
+		},
+		ExpressionAttributeValues: values,
+	})
+	if err != nil {
+		return fmt.Errorf("error completando clave de idempotencia en DynamoDB: %v", err)
+	}
+	return nil
+}
+
+// ReleaseIdempotencyKey borra una clave en curso para que la operación se pueda
+// reintentar; no borra las que ya terminaron
+func (d *DynamoClient) ReleaseIdempotencyKey(key string) error {
+	_, err := d.Client.DeleteItem(context.TODO(), &dynamodb.DeleteItemInput{
+		TableName: aws.String("idempotency_keys"),
+		Key: map[string]types.AttributeValue{
+			"idempotency_key": &types.AttributeValueMemberS{Value: key},
+		},
+		ConditionExpression: aws.String("#status = :in_progress"),
+		ExpressionAttributeNames: map[string]string{
+			"#status": "status",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":in_progress": &types.AttributeValueMemberS{Value: string(model.IdempotencyStatusInProgress)},
+		},
+	})
+	if err != nil && !strings.Contains(err.Error(), "ConditionalCheckFailed") {
+		return fmt.Errorf("error liberando clave de idempotencia en DynamoDB: %v", err)
+	}
+	return nil
+}
+
+// unmarshalIdempotencyRecord convierte un item de DynamoDB a IdempotencyRecord
+func unmarshalIdempotencyRecord(item map[string]types.AttributeValue) (*model.IdempotencyRecord, error) {
+	record := &model.IdempotencyRecord{}
+
+	if keyVal, ok := item["idempotency_key"].(*types.AttributeValueMemberS); ok {
+		record.Key = keyVal.Value
+	}
+
+	if statusVal, ok := item["status"].(*types.AttributeValueMemberS); ok {
+		record.Status = model.IdempotencyStatus(statusVal.Value)
+	}
+
+	if requestHashVal, ok := item["request_hash"].(*types.AttributeValueMemberS); ok {
+		record.RequestHash = requestHashVal.Value
+	}
+
+	if responseStatusVal, ok := item["response_status"].(*types.AttributeValueMemberN); ok {
+		responseStatus, err := strconv.Atoi(responseStatusVal.Value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid response_status: %v", err)
+		}
+		record.ResponseStatus = responseStatus
+	}
+
+	if responseBodyVal, ok := item["response_body"].(*types.AttributeValueMemberS); ok {
+		record.ResponseBody = responseBodyVal.Value
+	}
+
+	if lockedUntilVal, ok := item["locked_until"].(*types.AttributeValueMemberN); ok {
+		lockedUntil, err := strconv.ParseInt(lockedUntilVal.Value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid locked_until: %v", err)
+		}
+		record.LockedUntil = time.Unix(lockedUntil, 0)
+	}
+
+	if createdAtVal, ok := item["created_at"].(*types.AttributeValueMemberS); ok {
+		createdAt, err := time.Parse(time.RFC3339, createdAtVal.Value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid created_at time: %v", err)
+		}
+		record.CreatedAt = createdAt
+	}
+
+	if expiresAtVal, ok := item["expires_at"].(*types.AttributeValueMemberN); ok {
+		expiresAt, err := strconv.ParseInt(expiresAtVal.Value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid expires_at: %v", err)
+		}
+		record.ExpiresAt = time.Unix(expiresAt, 0)
+	}
+
+	return record, nil
+}
@@ -0,0 +1,327 @@
+package db
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/jhonathanssegura/ticket-notification/internal/model"
+)
+
+// recipientIndex es el índice de notificaciones por destinatario ordenado por fecha de creación
+const recipientIndex = "recipient-created_at-index"
+
+// ErrInvalidCursor indica que el cursor de paginación no es válido
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// ErrNotDelivered indica que la notificación todavía no se envió (está programada,
+// pendiente o enviándose) o no llegó a enviarse, así que no se puede marcar como leída
+var ErrNotDelivered = errors.New("notification has not been delivered")
+
+// GetInboxNotifications obtiene las notificaciones de un destinatario, de la más reciente a
+// la más antigua. read filtra por leídas (true) o no leídas (false); nil devuelve todas.
+// Devuelve el cursor de la página siguiente, vacío si no hay más resultados.
+func (d *DynamoClient) GetInboxNotifications(recipient string, read *bool, limit int, cursor string) ([]model.Notification, string, error) {
+	queryInput := inboxQuery(recipient, read)
+	if cursor != "" {
+		startKey, err := decodeCursor(cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		if startKey["recipient"].(*types.AttributeValueMemberS).Value != recipient {
+			return nil, "", ErrInvalidCursor
+		}
+		queryInput.ExclusiveStartKey = startKey
+	}
+
+	var notifications []model.Notification
+	var lastItem map[string]types.AttributeValue
+	for {
+		// Con filtro de leídas DynamoDB puede devolver páginas incompletas, así que se sigue leyendo
+		queryInput.Limit = aws.Int32(int32(limit - len(notifications)))
+		result, err := d.Client.Query(context.TODO(), queryInput)
+		if err != nil {
+			return nil, "", err
+		}
+
+		for _, item := range result.Items {
+			notification, err := d.unmarshalNotification(item)
+			if err != nil {
+				return nil, "", err
+			}
+			notifications = append(notifications, *notification)
+			lastItem = item
+		}
+
+		if len(result.LastEvaluatedKey) == 0 {
+			return notifications, "", nil
+		}
+		if len(notifications) >= limit {
+			break
+		}
+		queryInput.ExclusiveStartKey = result.LastEvaluatedKey
+	}
+
+	return notifications, encodeCursor(lastItem), nil
+}
+
+// CountUnreadNotifications cuenta las notificaciones no leídas de un destinatario
+func (d *DynamoClient) CountUnreadNotifications(recipient string) (int, error) {
+	read := false
+	queryInput := inboxQuery(recipient, &read)
+	queryInput.Select = types.SelectCount
+
+	total := 0
+	for {
+		result, err := d.Client.Query(context.TODO(), queryInput)
+		if err != nil {
+			return 0, err
+		}
+		total += int(result.Count)
+
+		if len(result.LastEvaluatedKey) == 0 {
+			return total, nil
+		}
+		queryInput.ExclusiveStartKey = result.LastEvaluatedKey
+	}
+}
+
+// MarkNotificationRead marca como leída una notificación enviada o entregada del
+// destinatario. Si ya estaba leída conserva la fecha original. Devuelve ErrNotFound si
+// la notificación no existe o pertenece a otro destinatario, y ErrNotDelivered si aún
+// no se envió: marcarla cambiaría el estado que esperan el envío programado y el outbox.
+func (d *DynamoClient) MarkNotificationRead(recipient, notificationID string, readAt time.Time) (*model.Notification, error) {
+	result, err := d.Client.UpdateItem(context.TODO(), &dynamodb.UpdateItemInput{
+		TableName: aws.String("notifications"),
+		Key: map[string]types.AttributeValue{
+			"id": &types.AttributeValueMemberS{Value: notificationID},
+		},
+		ConditionExpression: aws.String("attribute_exists(id) AND #recipient = :recipient AND #status IN (:sent, :delivered, :status)"),
+		UpdateExpression:    aws.String("SET #read_at = if_not_exists(#read_at, :read_at), #status = :status, #updated_at = :read_at"),
+		ExpressionAttributeNames: map[string]string{
+			"#recipient":  "recipient",
+			"#read_at":    "read_at",
+			"#status":     "status",
+			"#updated_at": "updated_at",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":recipient": &types.AttributeValueMemberS{Value: recipient},
+			":read_at":   &types.AttributeValueMemberS{Value: readAt.Format(time.RFC3339)},
+			":status":    &types.AttributeValueMemberS{Value: string(model.NotificationStatusRead)},
+			":sent":      &types.AttributeValueMemberS{Value: string(model.NotificationStatusSent)},
+			":delivered": &types.AttributeValueMemberS{Value: string(model.NotificationStatusDelivered)},
+		},
+		ReturnValues:                        types.ReturnValueAllNew,
+		ReturnValuesOnConditionCheckFailure: types.ReturnValuesOnConditionCheckFailureAllOld,
+	})
+	if err != nil {
+		var conditionErr *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionErr) {
+			// Con el elemento actual se distingue una notificación ajena de una sin enviar
+			if owner, ok := conditionErr.Item["recipient"].(*types.AttributeValueMemberS); ok && owner.Value == recipient {
+				return nil, fmt.Errorf("notification %s: %w", notificationID, ErrNotDelivered)
+			}
+			return nil, fmt.Errorf("notification %w", ErrNotFound)
+		}
+		if strings.Contains(err.Error(), "ConditionalCheckFailed") {
+			return nil, fmt.Errorf("notification %w", ErrNotFound)
+		}
+		return nil, err
+	}
+
+	return d.unmarshalNotification(result.Attributes)
+}
+
+// MarkAllNotificationsRead marca como leídas todas las notificaciones no leídas de un
+// destinatario, salvo las que no se enviaron, y devuelve cuántas se actualizaron
+func (d *DynamoClient) MarkAllNotificationsRead(recipient string, readAt time.Time) (int, error) {
+	read := false
+	queryInput := inboxQuery(recipient, &read)
+	queryInput.ProjectionExpression = aws.String("id")
+
+	updated := 0
+	for {
+		result, err := d.Client.Query(context.TODO(), queryInput)
+		if err != nil {
+			return updated, err
+		}
+
+		for _, item := range result.Items {
+			idVal, ok := item["id"].(*types.AttributeValueMemberS)
+			if !ok {
+				continue
+			}
+			if _, err := d.MarkNotificationRead(recipient, idVal.Value, readAt); err != nil {
+				// Las que fallaron siguen en la bandeja pero no se pueden marcar como leídas
+				if errors.Is(err, ErrNotDelivered) {
+					continue
+				}
+				return updated, err
+			}
+			updated++
+		}
+
+		if len(result.LastEvaluatedKey) == 0 {
+			return updated, nil
+		}
+		queryInput.ExclusiveStartKey = result.LastEvaluatedKey
+	}
+}
+
+// GetNotificationsSince obtiene las notificaciones de un destinatario creadas a partir de
+// since, de la más antigua a la más reciente
+func (d *DynamoClient) GetNotificationsSince(recipient string, since time.Time, limit int) ([]model.Notification, error) {
+	queryInput := inboxQuery(recipient, nil)
+	queryInput.KeyConditionExpression = aws.String("#recipient = :recipient AND #created_at >= :since")
+	queryInput.ExpressionAttributeNames["#created_at"] = "created_at"
+	queryInput.ExpressionAttributeValues[":since"] = &types.AttributeValueMemberS{Value: since.Format(time.RFC3339)}
+	queryInput.ScanIndexForward = aws.Bool(true)
+
+	var notifications []model.Notification
+	for len(notifications) < limit {
+		queryInput.Limit = aws.Int32(int32(limit - len(notifications)))
+		result, err := d.Client.Query(context.TODO(), queryInput)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, item := range result.Items {
+			notification, err := d.unmarshalNotification(item)
+			if err != nil {
+				return nil, err
+			}
+			notifications = append(notifications, *notification)
+		}
+
+		if len(result.LastEvaluatedKey) == 0 {
+			break
+		}
+		queryInput.ExclusiveStartKey = result.LastEvaluatedKey
+	}
+
+	return notifications, nil
+}
+
+// getRecipientNotifications obtiene las notificaciones más recientes de un destinatario,
+// filtrando opcionalmente por tipo
+func (d *DynamoClient) getRecipientNotifications(recipient, notificationType string, limit int) ([]model.Notification, error) {
+	queryInput := recipientQuery(recipient, nil)
+	if notificationType != "" {
+		queryInput.FilterExpression = aws.String("#type = :type")
+		queryInput.ExpressionAttributeNames["#type"] = "type"
+		queryInput.ExpressionAttributeValues[":type"] = &types.AttributeValueMemberS{Value: notificationType}
+	}
+
+	var notifications []model.Notification
+	for len(notifications) < limit {
+		queryInput.Limit = aws.Int32(int32(limit - len(notifications)))
+		result, err := d.Client.Query(context.TODO(), queryInput)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, item := range result.Items {
+			notification, err := d.unmarshalNotification(item)
+			if err != nil {
+				return nil, err
+			}
+			notifications = append(notifications, *notification)
+		}
+
+		if len(result.LastEvaluatedKey) == 0 {
+			break
+		}
+		queryInput.ExclusiveStartKey = result.LastEvaluatedKey
+	}
+
+	return notifications, nil
+}
+
+// recipientQuery construye la consulta al índice por destinatario
+func recipientQuery(recipient string, read *bool) *dynamodb.QueryInput {
+	queryInput := &dynamodb.QueryInput{
+		TableName:              aws.String("notifications"),
+		IndexName:              aws.String(recipientIndex),
+		KeyConditionExpression: aws.String("#recipient = :recipient"),
+		ExpressionAttributeNames: map[string]string{
+			"#recipient": "recipient",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":recipient": &types.AttributeValueMemberS{Value: recipient},
+		},
+		ScanIndexForward: aws.Bool(false),
+	}
+
+	if read != nil {
+		queryInput.ExpressionAttributeNames["#read_at"] = "read_at"
+		if *read {
+			queryInput.FilterExpression = aws.String("attribute_exists(#read_at)")
+		} else {
+			queryInput.FilterExpression = aws.String("attribute_not_exists(#read_at)")
+		}
+	}
+
+	return queryInput
+}
+
+// inboxQuery construye la consulta de la bandeja del destinatario, que no incluye
+// las notificaciones suprimidas por sus preferencias ni las que aún no se enviaron
+func inboxQuery(recipient string, read *bool) *dynamodb.QueryInput {
+	queryInput := recipientQuery(recipient, read)
+
+	condition := "NOT #status IN (:suppressed, :scheduled, :cancelled, :pending, :sending)"
+	if queryInput.FilterExpression != nil {
+		condition = *queryInput.FilterExpression + " AND " + condition
+	}
+	queryInput.FilterExpression = aws.String(condition)
+	queryInput.ExpressionAttributeNames["#status"] = "status"
+	queryInput.ExpressionAttributeValues[":suppressed"] = &types.AttributeValueMemberS{Value: string(model.NotificationStatusSuppressed)}
+	queryInput.ExpressionAttributeValues[":scheduled"] = &types.AttributeValueMemberS{Value: string(model.NotificationStatusScheduled)}
+	queryInput.ExpressionAttributeValues[":cancelled"] = &types.AttributeValueMemberS{Value: string(model.NotificationStatusCancelled)}
+	queryInput.ExpressionAttributeValues[":pending"] = &types.AttributeValueMemberS{Value: string(model.NotificationStatusPending)}
+	queryInput.ExpressionAttributeValues[":sending"] = &types.AttributeValueMemberS{Value: string(model.NotificationStatusSending)}
+
+	return queryInput
+}
+
+// encodeCursor codifica la clave del último elemento devuelto como cursor opaco
+func encodeCursor(item map[string]types.AttributeValue) string {
+	key := make(map[string]string)
+	for _, name := range []string{"id", "recipient", "created_at"} {
+		if value, ok := item[name].(*types.AttributeValueMemberS); ok {
+			key[name] = value.Value
+		}
+	}
+
+	data, _ := json.Marshal(key)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor convierte un cursor en la clave de inicio de la consulta
+func decodeCursor(cursor string) (map[string]types.AttributeValue, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var key map[string]string
+	if err := json.Unmarshal(data, &key); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	if key["id"] == "" || key["recipient"] == "" || key["created_at"] == "" {
+		return nil, ErrInvalidCursor
+	}
+
+	startKey := make(map[string]types.AttributeValue, len(key))
+	for name, value := range key {
+		startKey[name] = &types.AttributeValueMemberS{Value: value}
+	}
+	return startKey, nil
+}
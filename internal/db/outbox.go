@@ -0,0 +1,164 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/jhonathanssegura/ticket-notification/internal/model"
+)
+
+// outboxIndex es el índice de notificaciones por estado ordenado por next_attempt_at.
+// Solo las notificaciones que retoma el despachador del outbox tienen ese atributo.
+const outboxIndex = "status-next_attempt_at-index"
+
+// CreateNotification guarda una notificación nueva. Devuelve false si ya existe una con
+// el mismo ID, en cuyo caso no se modifica.
+func (d *DynamoClient) CreateNotification(notification model.Notification) (bool, error) {
+	_, err := d.Client.PutItem(context.TODO(), &dynamodb.PutItemInput{
+		TableName:           aws.String("notifications"),
+		Item:                notificationItem(notification),
+		ConditionExpression: aws.String("attribute_not_exists(id)"),
+	})
+	if err != nil {
+		if strings.Contains(err.Error(), "ConditionalCheckFailed") {
+			return false, nil
+		}
+		return false, notificationSaveError(err)
+	}
+
+	if d.OnNotificationSaved != nil {
+		d.OnNotificationSaved(notification)
+	}
+	return true, nil
+}
+
+// ClaimNotification pasa una notificación pendiente a enviando y la retiene hasta
+// lockedUntil. También toma las que quedaron enviando con la retención vencida, porque
+// quien las enviaba se detuvo. Devuelve false si otra entrega la tomó o ya terminó.
+func (d *DynamoClient) ClaimNotification(notification model.Notification, lockedUntil, at time.Time) (bool, error) {
+	update := "SET #status = :sending, locked_until = :locked_until, updated_at = :updated_at"
+	// Las del outbox vuelven a quedar a la vista del despachador cuando vence la retención
+	if notification.NextAttemptAt != nil {
+		update += ", next_attempt_at = :locked_until"
+	}
+
+	_, err := d.Client.UpdateItem(context.TODO(), &dynamodb.UpdateItemInput{
+		TableName: aws.String("notifications"),
+		Key: map[string]types.AttributeValue{
+			"id": &types.AttributeValueMemberS{Value: notification.ID.String()},
+		},
+		ConditionExpression: aws.String("#status = :pending OR (#status = :sending AND locked_until < :now)"),
+		UpdateExpression:    aws.String(update),
+		ExpressionAttributeNames: map[string]string{
+			"#status": "status",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pending":      &types.AttributeValueMemberS{Value: string(model.NotificationStatusPending)},
+			":sending":      &types.AttributeValueMemberS{Value: string(model.NotificationStatusSending)},
+			":now":          &types.AttributeValueMemberS{Value: indexTime(at)},
+			":locked_until": &types.AttributeValueMemberS{Value: indexTime(lockedUntil)},
+			":updated_at":   &types.AttributeValueMemberS{Value: at.Format(time.RFC3339)},
+		},
+	})
+	if err != nil {
+		if strings.Contains(err.Error(), "ConditionalCheckFailed") {
+			return false, nil
+		}
+		return false, fmt.Errorf("error tomando notificación en DynamoDB: %v", err)
+	}
+	return true, nil
+}
+
+// SaveClaimedNotification guarda el resultado de la entrega de una notificación tomada
+// con ClaimNotification, solo si sigue retenida hasta lockedUntil. Devuelve false si la
+// retención venció y otra entrega la tomó.
+func (d *DynamoClient) SaveClaimedNotification(notification model.Notification, lockedUntil time.Time) (bool, error) {
+	_, err := d.Client.PutItem(context.TODO(), &dynamodb.PutItemInput{
+		TableName:           aws.String("notifications"),
+		Item:                notificationItem(notification),
+		ConditionExpression: aws.String("#status = :sending AND locked_until = :locked_until"),
+		ExpressionAttributeNames: map[string]string{
+			"#status": "status",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":sending":      &types.AttributeValueMemberS{Value: string(model.NotificationStatusSending)},
+			":locked_until": &types.AttributeValueMemberS{Value: indexTime(lockedUntil)},
+		},
+	})
+	if err != nil {
+		if strings.Contains(err.Error(), "ConditionalCheckFailed") {
+			return false, nil
+		}
+		return false, notificationSaveError(err)
+	}
+
+	if d.OnNotificationSaved != nil {
+		d.OnNotificationSaved(notification)
+	}
+	return true, nil
+}
+
+// GetOutboxNotifications obtiene hasta limit notificaciones del outbox en el estado
+// indicado cuyo next_attempt_at ya llegó a until, de la más antigua a la más reciente
+func (d *DynamoClient) GetOutboxNotifications(status model.NotificationStatus, until time.Time, limit int) ([]model.Notification, error) {
+	result, err := d.Client.Query(context.TODO(), &dynamodb.QueryInput{
+		TableName:              aws.String("notifications"),
+		IndexName:              aws.String(outboxIndex),
+		KeyConditionExpression: aws.String("#status = :status AND next_attempt_at <= :until"),
+		ExpressionAttributeNames: map[string]string{
+			"#status": "status",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":status": &types.AttributeValueMemberS{Value: string(status)},
+			":until":  &types.AttributeValueMemberS{Value: indexTime(until)},
+		},
+		Limit: aws.Int32(int32(limit)),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	notifications := make([]model.Notification, 0, len(result.Items))
+	for _, item := range result.Items {
+		notification, err := d.unmarshalNotification(item)
+		if err != nil {
+			return nil, err
+		}
+		notifications = append(notifications, *notification)
+	}
+	return notifications, nil
+}
+
+// ReopenNotification vuelve a dejar pendiente una notificación de la cola que terminó,
+// para que la retome el consumidor cuando se reenvía su mensaje. Devuelve false si no
+// existe o si se está entregando.
+func (d *DynamoClient) ReopenNotification(id string, at time.Time) (bool, error) {
+	_, err := d.Client.UpdateItem(context.TODO(), &dynamodb.UpdateItemInput{
+		TableName: aws.String("notifications"),
+		Key: map[string]types.AttributeValue{
+			"id": &types.AttributeValueMemberS{Value: id},
+		},
+		ConditionExpression: aws.String("attribute_exists(id) AND #status <> :sending"),
+		UpdateExpression:    aws.String("SET #status = :pending, updated_at = :updated_at"),
+		ExpressionAttributeNames: map[string]string{
+			"#status": "status",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pending":    &types.AttributeValueMemberS{Value: string(model.NotificationStatusPending)},
+			":sending":    &types.AttributeValueMemberS{Value: string(model.NotificationStatusSending)},
+			":updated_at": &types.AttributeValueMemberS{Value: at.Format(time.RFC3339)},
+		},
+	})
+	if err != nil {
+		if strings.Contains(err.Error(), "ConditionalCheckFailed") {
+			return false, nil
+		}
+		return false, fmt.Errorf("error reabriendo notificación en DynamoDB: %v", err)
+	}
+	return true, nil
+}
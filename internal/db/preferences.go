@@ -0,0 +1,136 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/jhonathanssegura/ticket-notification/internal/model"
+)
+
+// SaveUserPreferences guarda las preferencias de notificación de un destinatario
+func (d *DynamoClient) SaveUserPreferences(preferences model.UserPreferences) error {
+	item := map[string]types.AttributeValue{
+		"recipient":  &types.AttributeValueMemberS{Value: preferences.Recipient},
+		"updated_at": &types.AttributeValueMemberS{Value: preferences.UpdatedAt.Format(time.RFC3339)},
+	}
+	if preferences.Language != "" {
+		item["language"] = &types.AttributeValueMemberS{Value: preferences.Language}
+	}
+	if preferences.Timezone != "" {
+		item["timezone"] = &types.AttributeValueMemberS{Value: preferences.Timezone}
+	}
+	if preferences.QuietHours != nil {
+		item["quiet_hours_start"] = &types.AttributeValueMemberS{Value: preferences.QuietHours.Start}
+		item["quiet_hours_end"] = &types.AttributeValueMemberS{Value: preferences.QuietHours.End}
+	}
+	if len(preferences.Types) > 0 {
+		typesJSON, err := json.Marshal(preferences.Types)
+		if err != nil {
+			return fmt.Errorf("error codificando preferencias por tipo: %v", err)
+		}
+		item["types"] = &types.AttributeValueMemberS{Value: string(typesJSON)}
+	}
+	if len(preferences.Channels) > 0 {
+		channelsJSON, err := json.Marshal(preferences.Channels)
+		if err != nil {
+			return fmt.Errorf("error codificando preferencias por canal: %v", err)
+		}
+		item["channels"] = &types.AttributeValueMemberS{Value: string(channelsJSON)}
+	}
+
+	_, err := d.Client.PutItem(context.TODO(), &dynamodb.PutItemInput{
+		TableName: aws.String("user_preferences"),
+		Item:      item,
+	})
+	if err != nil {
+		if strings.Contains(err.Error(), "ResourceNotFoundException") {
+			return errors.New("La tabla 'user_preferences' no existe en DynamoDB. Verifique que LocalStack esté ejecutándose y la tabla haya sido creada.")
+		}
+		return fmt.Errorf("error guardando preferencias en DynamoDB: %v", err)
+	}
+
+	return nil
+}
+
+// GetUserPreferences obtiene las preferencias de un destinatario. Devuelve
+// ErrNotFound si nunca las configuró.
+func (d *DynamoClient) GetUserPreferences(recipient string) (*model.UserPreferences, error) {
+	result, err := d.Client.GetItem(context.TODO(), &dynamodb.GetItemInput{
+		TableName: aws.String("user_preferences"),
+		Key: map[string]types.AttributeValue{
+			"recipient": &types.AttributeValueMemberS{Value: recipient},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if result.Item == nil {
+		return nil, fmt.Errorf("preferences %w", ErrNotFound)
+	}
+
+	return d.unmarshalUserPreferences(result.Item)
+}
+
+// DeleteUserPreferences elimina las preferencias de un destinatario, que vuelve a los valores por defecto
+func (d *DynamoClient) DeleteUserPreferences(recipient string) error {
+	_, err := d.Client.DeleteItem(context.TODO(), &dynamodb.DeleteItemInput{
+		TableName: aws.String("user_preferences"),
+		Key: map[string]types.AttributeValue{
+			"recipient": &types.AttributeValueMemberS{Value: recipient},
+		},
+	})
+	return err
+}
+
+// unmarshalUserPreferences convierte un item de DynamoDB a UserPreferences
+func (d *DynamoClient) unmarshalUserPreferences(item map[string]types.AttributeValue) (*model.UserPreferences, error) {
+	preferences := &model.UserPreferences{}
+
+	if recipientVal, ok := item["recipient"].(*types.AttributeValueMemberS); ok {
+		preferences.Recipient = recipientVal.Value
+	}
+
+	if languageVal, ok := item["language"].(*types.AttributeValueMemberS); ok {
+		preferences.Language = languageVal.Value
+	}
+
+	if timezoneVal, ok := item["timezone"].(*types.AttributeValueMemberS); ok {
+		preferences.Timezone = timezoneVal.Value
+	}
+
+	startVal, hasStart := item["quiet_hours_start"].(*types.AttributeValueMemberS)
+	endVal, hasEnd := item["quiet_hours_end"].(*types.AttributeValueMemberS)
+	if hasStart && hasEnd {
+		preferences.QuietHours = &model.QuietHours{Start: startVal.Value, End: endVal.Value}
+	}
+
+	if typesVal, ok := item["types"].(*types.AttributeValueMemberS); ok {
+		if err := json.Unmarshal([]byte(typesVal.Value), &preferences.Types); err != nil {
+			return nil, fmt.Errorf("invalid type preferences: %v", err)
+		}
+	}
+
+	if channelsVal, ok := item["channels"].(*types.AttributeValueMemberS); ok {
+		if err := json.Unmarshal([]byte(channelsVal.Value), &preferences.Channels); err != nil {
+			return nil, fmt.Errorf("invalid channel preferences: %v", err)
+		}
+	}
+
+	if updatedAtVal, ok := item["updated_at"].(*types.AttributeValueMemberS); ok {
+		updatedAt, err := time.Parse(time.RFC3339, updatedAtVal.Value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid updated_at time: %v", err)
+		}
+		preferences.UpdatedAt = updatedAt
+	}
+
+	return preferences, nil
+}
@@ -0,0 +1,309 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/jhonathanssegura/ticket-notification/internal/model"
+)
+
+// SaveScheduledReminder guarda un recordatorio programado. Si el asistente ya tenía
+// ese recordatorio en la cola o enviado no se reemplaza y devuelve false.
+func (d *DynamoClient) SaveScheduledReminder(reminder model.ScheduledReminder) (bool, error) {
+	item := map[string]types.AttributeValue{
+		"event_id":      &types.AttributeValueMemberS{Value: reminder.EventID},
+		"reminder_id":   &types.AttributeValueMemberS{Value: reminder.ID},
+		"event_name":    &types.AttributeValueMemberS{Value: reminder.EventName},
+		"event_date":    &types.AttributeValueMemberS{Value: reminder.EventDate.Format(time.RFC3339)},
+		"location":      &types.AttributeValueMemberS{Value: reminder.Location},
+		"recipient":     &types.AttributeValueMemberS{Value: reminder.Recipient},
+		"reminder_type": &types.AttributeValueMemberS{Value: string(reminder.ReminderType)},
+		"fire_at":       &types.AttributeValueMemberS{Value: indexTime(reminder.FireAt)},
+		"status":        &types.AttributeValueMemberS{Value: string(reminder.Status)},
+		"created_at":    &types.AttributeValueMemberS{Value: reminder.CreatedAt.Format(time.RFC3339)},
+		"updated_at":    &types.AttributeValueMemberS{Value: reminder.UpdatedAt.Format(time.RFC3339)},
+	}
+	if len(reminder.Channels) > 0 {
+		channelsJSON, err := json.Marshal(reminder.Channels)
+		if err != nil {
+			return false, fmt.Errorf("error codificando canales del recordatorio: %v", err)
+		}
+		item["channels"] = &types.AttributeValueMemberS{Value: string(channelsJSON)}
+	}
+
+	_, err := d.Client.PutItem(context.TODO(), &dynamodb.PutItemInput{
+		TableName:           aws.String("scheduled_reminders"),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(reminder_id) OR NOT #status IN (:enqueued, :sent)"),
+		ExpressionAttributeNames: map[string]string{
+			"#status": "status",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":enqueued": &types.AttributeValueMemberS{Value: string(model.ReminderStatusEnqueued)},
+			":sent":     &types.AttributeValueMemberS{Value: string(model.ReminderStatusSent)},
+		},
+	})
+	if err != nil {
+		if strings.Contains(err.Error(), "ConditionalCheckFailed") {
+			return false, nil
+		}
+		if strings.Contains(err.Error(), "ResourceNotFoundException") {
+			return false, errors.New("La tabla 'scheduled_reminders' no existe en DynamoDB. Verifique que LocalStack esté ejecutándose y la tabla haya sido creada.")
+		}
+		return false, fmt.Errorf("error guardando recordatorio en DynamoDB: %v", err)
+	}
+
+	return true, nil
+}
+
+// GetScheduledReminder obtiene un recordatorio programado. Devuelve ErrNotFound si no existe.
+func (d *DynamoClient) GetScheduledReminder(eventID, reminderID string) (*model.ScheduledReminder, error) {
+	result, err := d.Client.GetItem(context.TODO(), &dynamodb.GetItemInput{
+		TableName: aws.String("scheduled_reminders"),
+		Key:       reminderKey(eventID, reminderID),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if result.Item == nil {
+		return nil, fmt.Errorf("reminder %w", ErrNotFound)
+	}
+
+	return d.unmarshalScheduledReminder(result.Item)
+}
+
+// GetEventReminders lista los recordatorios de un evento en cualquier estado
+func (d *DynamoClient) GetEventReminders(eventID string) ([]model.ScheduledReminder, error) {
+	queryInput := &dynamodb.QueryInput{
+		TableName:              aws.String("scheduled_reminders"),
+		KeyConditionExpression: aws.String("event_id = :event_id"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":event_id": &types.AttributeValueMemberS{Value: eventID},
+		},
+	}
+
+	var reminders []model.ScheduledReminder
+	for {
+		result, err := d.Client.Query(context.TODO(), queryInput)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, item := range result.Items {
+			reminder, err := d.unmarshalScheduledReminder(item)
+			if err != nil {
+				return nil, err
+			}
+			reminders = append(reminders, *reminder)
+		}
+
+		if len(result.LastEvaluatedKey) == 0 {
+			return reminders, nil
+		}
+		queryInput.ExclusiveStartKey = result.LastEvaluatedKey
+	}
+}
+
+// GetDueReminders obtiene, de la más próxima a la más lejana, hasta limit recordatorios
+// pendientes cuya hora de envío es anterior a until
+func (d *DynamoClient) GetDueReminders(until time.Time, limit int) ([]model.ScheduledReminder, error) {
+	result, err := d.Client.Query(context.TODO(), &dynamodb.QueryInput{
+		TableName:              aws.String("scheduled_reminders"),
+		IndexName:              aws.String("status-fire_at-index"),
+		KeyConditionExpression: aws.String("#status = :status AND fire_at <= :until"),
+		ExpressionAttributeNames: map[string]string{
+			"#status": "status",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":status": &types.AttributeValueMemberS{Value: string(model.ReminderStatusPending)},
+			":until":  &types.AttributeValueMemberS{Value: indexTime(until)},
+		},
+		Limit: aws.Int32(int32(limit)),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	reminders := make([]model.ScheduledReminder, 0, len(result.Items))
+	for _, item := range result.Items {
+		reminder, err := d.unmarshalScheduledReminder(item)
+		if err != nil {
+			return nil, err
+		}
+		reminders = append(reminders, *reminder)
+	}
+	return reminders, nil
+}
+
+// ClaimDueReminder pasa un recordatorio pendiente a la cola. Falla (devuelve false) si
+// otra instancia ya lo tomó o si se movió después de leerlo.
+func (d *DynamoClient) ClaimDueReminder(reminder model.ScheduledReminder, at time.Time) (bool, error) {
+	_, err := d.Client.UpdateItem(context.TODO(), &dynamodb.UpdateItemInput{
+		TableName:           aws.String("scheduled_reminders"),
+		Key:                 reminderKey(reminder.EventID, reminder.ID),
+		ConditionExpression: aws.String("#status = :pending AND fire_at = :fire_at"),
+		UpdateExpression:    aws.String("SET #status = :enqueued, updated_at = :updated_at"),
+		ExpressionAttributeNames: map[string]string{
+			"#status": "status",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pending":    &types.AttributeValueMemberS{Value: string(model.ReminderStatusPending)},
+			":enqueued":   &types.AttributeValueMemberS{Value: string(model.ReminderStatusEnqueued)},
+			":fire_at":    &types.AttributeValueMemberS{Value: indexTime(reminder.FireAt)},
+			":updated_at": &types.AttributeValueMemberS{Value: at.Format(time.RFC3339)},
+		},
+	})
+	if err != nil {
+		if strings.Contains(err.Error(), "ConditionalCheckFailed") {
+			return false, nil
+		}
+		return false, fmt.Errorf("error tomando recordatorio en DynamoDB: %v", err)
+	}
+	return true, nil
+}
+
+// UpdateReminderStatus cambia el estado de un recordatorio si está en alguno de los
+// estados from. Devuelve false si no existe o estaba en otro estado.
+func (d *DynamoClient) UpdateReminderStatus(eventID, reminderID string, from []model.ReminderStatus, to model.ReminderStatus, at time.Time) (bool, error) {
+	values := map[string]types.AttributeValue{
+		":status":     &types.AttributeValueMemberS{Value: string(to)},
+		":updated_at": &types.AttributeValueMemberS{Value: at.Format(time.RFC3339)},
+	}
+	placeholders := make([]string, len(from))
+	for i, status := range from {
+		placeholders[i] = fmt.Sprintf(":from%d", i)
+		values[placeholders[i]] = &types.AttributeValueMemberS{Value: string(status)}
+	}
+
+	updateExpression := "SET #status = :status, updated_at = :updated_at"
+	if to == model.ReminderStatusSent {
+		updateExpression += ", sent_at = :updated_at"
+	}
+
+	_, err := d.Client.UpdateItem(context.TODO(), &dynamodb.UpdateItemInput{
+		TableName:           aws.String("scheduled_reminders"),
+		Key:                 reminderKey(eventID, reminderID),
+		ConditionExpression: aws.String("#status IN (" + strings.Join(placeholders, ", ") + ")"),
+		UpdateExpression:    aws.String(updateExpression),
+		ExpressionAttributeNames: map[string]string{
+			"#status": "status",
+		},
+		ExpressionAttributeValues: values,
+	})
+	if err != nil {
+		if strings.Contains(err.Error(), "ConditionalCheckFailed") {
+			return false, nil
+		}
+		return false, fmt.Errorf("error actualizando recordatorio en DynamoDB: %v", err)
+	}
+	return true, nil
+}
+
+// MoveReminder cambia la fecha, el lugar, la hora de envío y el estado de un recordatorio
+// no cancelado. Devuelve false si entretanto se canceló.
+func (d *DynamoClient) MoveReminder(reminder model.ScheduledReminder, at time.Time) (bool, error) {
+	_, err := d.Client.UpdateItem(context.TODO(), &dynamodb.UpdateItemInput{
+		TableName:           aws.String("scheduled_reminders"),
+		Key:                 reminderKey(reminder.EventID, reminder.ID),
+		ConditionExpression: aws.String("attribute_exists(reminder_id) AND #status <> :cancelled"),
+		UpdateExpression:    aws.String("SET event_date = :event_date, #location = :location, fire_at = :fire_at, #status = :status, updated_at = :updated_at"),
+		ExpressionAttributeNames: map[string]string{
+			"#status":   "status",
+			"#location": "location",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":event_date": &types.AttributeValueMemberS{Value: reminder.EventDate.Format(time.RFC3339)},
+			":location":   &types.AttributeValueMemberS{Value: reminder.Location},
+			":fire_at":    &types.AttributeValueMemberS{Value: indexTime(reminder.FireAt)},
+			":status":     &types.AttributeValueMemberS{Value: string(reminder.Status)},
+			":cancelled":  &types.AttributeValueMemberS{Value: string(model.ReminderStatusCancelled)},
+			":updated_at": &types.AttributeValueMemberS{Value: at.Format(time.RFC3339)},
+		},
+	})
+	if err != nil {
+		if strings.Contains(err.Error(), "ConditionalCheckFailed") {
+			return false, nil
+		}
+		return false, fmt.Errorf("error moviendo recordatorio en DynamoDB: %v", err)
+	}
+	return true, nil
+}
+
+// unmarshalScheduledReminder convierte un item de DynamoDB a ScheduledReminder
+func (d *DynamoClient) unmarshalScheduledReminder(item map[string]types.AttributeValue) (*model.ScheduledReminder, error) {
+	reminder := &model.ScheduledReminder{}
+
+	if eventIDVal, ok := item["event_id"].(*types.AttributeValueMemberS); ok {
+		reminder.EventID = eventIDVal.Value
+	}
+
+	if reminderIDVal, ok := item["reminder_id"].(*types.AttributeValueMemberS); ok {
+		reminder.ID = reminderIDVal.Value
+	}
+
+	if eventNameVal, ok := item["event_name"].(*types.AttributeValueMemberS); ok {
+		reminder.EventName = eventNameVal.Value
+	}
+
+	if eventDateVal, ok := item["event_date"].(*types.AttributeValueMemberS); ok {
+		reminder.EventDate, _ = time.Parse(time.RFC3339, eventDateVal.Value)
+	}
+
+	if locationVal, ok := item["location"].(*types.AttributeValueMemberS); ok {
+		reminder.Location = locationVal.Value
+	}
+
+	if recipientVal, ok := item["recipient"].(*types.AttributeValueMemberS); ok {
+		reminder.Recipient = recipientVal.Value
+	}
+
+	if channelsVal, ok := item["channels"].(*types.AttributeValueMemberS); ok {
+		if err := json.Unmarshal([]byte(channelsVal.Value), &reminder.Channels); err != nil {
+			return nil, fmt.Errorf("error decodificando canales del recordatorio: %v", err)
+		}
+	}
+
+	if reminderTypeVal, ok := item["reminder_type"].(*types.AttributeValueMemberS); ok {
+		reminder.ReminderType = model.ReminderType(reminderTypeVal.Value)
+	}
+
+	if fireAtVal, ok := item["fire_at"].(*types.AttributeValueMemberS); ok {
+		reminder.FireAt, _ = time.Parse(time.RFC3339, fireAtVal.Value)
+	}
+
+	if statusVal, ok := item["status"].(*types.AttributeValueMemberS); ok {
+		reminder.Status = model.ReminderStatus(statusVal.Value)
+	}
+
+	if createdAtVal, ok := item["created_at"].(*types.AttributeValueMemberS); ok {
+		reminder.CreatedAt, _ = time.Parse(time.RFC3339, createdAtVal.Value)
+	}
+
+	if updatedAtVal, ok := item["updated_at"].(*types.AttributeValueMemberS); ok {
+		reminder.UpdatedAt, _ = time.Parse(time.RFC3339, updatedAtVal.Value)
+	}
+
+	if sentAtVal, ok := item["sent_at"].(*types.AttributeValueMemberS); ok {
+		if sentAt, err := time.Parse(time.RFC3339, sentAtVal.Value); err == nil {
+			reminder.SentAt = &sentAt
+		}
+	}
+
+	return reminder, nil
+}
+
+// reminderKey construye la clave de un recordatorio programado
+func reminderKey(eventID, reminderID string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		"event_id":    &types.AttributeValueMemberS{Value: eventID},
+		"reminder_id": &types.AttributeValueMemberS{Value: reminderID},
+	}
+}
@@ -0,0 +1,153 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/jhonathanssegura/ticket-notification/internal/model"
+)
+
+// ErrNotScheduled indica que la notificación ya no está programada: se envió, se está
+// enviando o se canceló
+var ErrNotScheduled = errors.New("notification is not scheduled")
+
+// GetScheduledNotifications obtiene, de la más próxima a la más lejana, hasta limit
+// notificaciones programadas. Con until solo devuelve las que vencen antes de esa hora.
+func (d *DynamoClient) GetScheduledNotifications(recipient string, until *time.Time, limit int) ([]model.Notification, error) {
+	queryInput := &dynamodb.QueryInput{
+		TableName:              aws.String("notifications"),
+		IndexName:              aws.String("status-send_at-index"),
+		KeyConditionExpression: aws.String("#status = :status"),
+		ExpressionAttributeNames: map[string]string{
+			"#status": "status",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":status": &types.AttributeValueMemberS{Value: string(model.NotificationStatusScheduled)},
+		},
+	}
+	if until != nil {
+		queryInput.KeyConditionExpression = aws.String("#status = :status AND send_at <= :until")
+		queryInput.ExpressionAttributeValues[":until"] = &types.AttributeValueMemberS{Value: indexTime(*until)}
+	}
+	if recipient != "" {
+		queryInput.FilterExpression = aws.String("#recipient = :recipient")
+		queryInput.ExpressionAttributeNames["#recipient"] = "recipient"
+		queryInput.ExpressionAttributeValues[":recipient"] = &types.AttributeValueMemberS{Value: recipient}
+	}
+
+	var notifications []model.Notification
+	for {
+		// Con filtro por destinatario DynamoDB puede devolver páginas incompletas
+		queryInput.Limit = aws.Int32(int32(limit - len(notifications)))
+		result, err := d.Client.Query(context.TODO(), queryInput)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, item := range result.Items {
+			notification, err := d.unmarshalNotification(item)
+			if err != nil {
+				return nil, err
+			}
+			notifications = append(notifications, *notification)
+		}
+
+		if len(result.LastEvaluatedKey) == 0 || len(notifications) >= limit {
+			return notifications, nil
+		}
+		queryInput.ExclusiveStartKey = result.LastEvaluatedKey
+	}
+}
+
+// ClaimScheduledNotification pasa una notificación programada a pendiente para enviarla,
+// con nextAttemptAt como la hora en que la retoma el despachador del outbox. Devuelve
+// false si otra instancia ya la tomó, se canceló o se reprogramó después de leerla.
+func (d *DynamoClient) ClaimScheduledNotification(notification model.Notification, nextAttemptAt, at time.Time) (bool, error) {
+	if notification.SendAt == nil {
+		return false, nil
+	}
+
+	_, err := d.Client.UpdateItem(context.TODO(), &dynamodb.UpdateItemInput{
+		TableName: aws.String("notifications"),
+		Key: map[string]types.AttributeValue{
+			"id": &types.AttributeValueMemberS{Value: notification.ID.String()},
+		},
+		ConditionExpression: aws.String("#status = :scheduled AND send_at = :send_at"),
+		UpdateExpression:    aws.String("SET #status = :pending, next_attempt_at = :next_attempt_at, updated_at = :updated_at"),
+		ExpressionAttributeNames: map[string]string{
+			"#status": "status",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":scheduled":       &types.AttributeValueMemberS{Value: string(model.NotificationStatusScheduled)},
+			":pending":         &types.AttributeValueMemberS{Value: string(model.NotificationStatusPending)},
+			":send_at":         &types.AttributeValueMemberS{Value: indexTime(*notification.SendAt)},
+			":updated_at":      &types.AttributeValueMemberS{Value: at.Format(time.RFC3339)},
+			":next_attempt_at": &types.AttributeValueMemberS{Value: indexTime(nextAttemptAt)},
+		},
+	})
+	if err != nil {
+		if strings.Contains(err.Error(), "ConditionalCheckFailed") {
+			return false, nil
+		}
+		return false, fmt.Errorf("error tomando notificación programada en DynamoDB: %v", err)
+	}
+	return true, nil
+}
+
+// RescheduleNotification cambia la hora de envío de una notificación programada.
+// Devuelve ErrNotScheduled si ya no está programada.
+func (d *DynamoClient) RescheduleNotification(notificationID string, sendAt, at time.Time) (*model.Notification, error) {
+	return d.updateScheduledNotification(notificationID, "SET send_at = :send_at, updated_at = :updated_at",
+		map[string]types.AttributeValue{
+			":send_at":    &types.AttributeValueMemberS{Value: indexTime(sendAt)},
+			":updated_at": &types.AttributeValueMemberS{Value: at.Format(time.RFC3339)},
+		})
+}
+
+// CancelScheduledNotification cancela una notificación programada antes de que se envíe.
+// Devuelve ErrNotScheduled si ya no está programada.
+func (d *DynamoClient) CancelScheduledNotification(notificationID string, at time.Time) (*model.Notification, error) {
+	return d.updateScheduledNotification(notificationID, "SET #status = :cancelled, updated_at = :updated_at",
+		map[string]types.AttributeValue{
+			":cancelled":  &types.AttributeValueMemberS{Value: string(model.NotificationStatusCancelled)},
+			":updated_at": &types.AttributeValueMemberS{Value: at.Format(time.RFC3339)},
+		})
+}
+
+// updateScheduledNotification aplica la actualización solo si la notificación sigue programada
+func (d *DynamoClient) updateScheduledNotification(notificationID, updateExpression string, values map[string]types.AttributeValue) (*model.Notification, error) {
+	values[":scheduled"] = &types.AttributeValueMemberS{Value: string(model.NotificationStatusScheduled)}
+
+	result, err := d.Client.UpdateItem(context.TODO(), &dynamodb.UpdateItemInput{
+		TableName: aws.String("notifications"),
+		Key: map[string]types.AttributeValue{
+			"id": &types.AttributeValueMemberS{Value: notificationID},
+		},
+		ConditionExpression: aws.String("#status = :scheduled"),
+		UpdateExpression:    aws.String(updateExpression),
+		ExpressionAttributeNames: map[string]string{
+			"#status": "status",
+		},
+		ExpressionAttributeValues: values,
+		ReturnValues:              types.ReturnValueAllNew,
+	})
+	if err != nil {
+		if strings.Contains(err.Error(), "ConditionalCheckFailed") {
+			return nil, ErrNotScheduled
+		}
+		return nil, fmt.Errorf("error actualizando notificación programada en DynamoDB: %v", err)
+	}
+
+	return d.unmarshalNotification(result.Attributes)
+}
+
+// indexTime formatea una hora en UTC para que el orden de los índices sea cronológico
+func indexTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,197 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/jhonathanssegura/ticket-notification/internal/model"
+)
+
+// emailMessageRetention es el tiempo durante el que se puede asociar la respuesta
+// de SES (rebote, queja o entrega) con la notificación que envió el email
+const emailMessageRetention = 30 * 24 * time.Hour
+
+// SaveEmailSuppression añade una dirección a la lista de supresión. Si ya estaba
+// se conserva la entrada original.
+func (d *DynamoClient) SaveEmailSuppression(suppression model.EmailSuppression) error {
+	item := map[string]types.AttributeValue{
+		"email":      &types.AttributeValueMemberS{Value: normalizeEmail(suppression.Email)},
+		"reason":     &types.AttributeValueMemberS{Value: string(suppression.Reason)},
+		"created_at": &types.AttributeValueMemberS{Value: suppression.CreatedAt.Format(time.RFC3339)},
+	}
+	if suppression.Details != "" {
+		item["details"] = &types.AttributeValueMemberS{Value: suppression.Details}
+	}
+	if suppression.NotificationID != "" {
+		item["notification_id"] = &types.AttributeValueMemberS{Value: suppression.NotificationID}
+	}
+
+	_, err := d.Client.PutItem(context.TODO(), &dynamodb.PutItemInput{
+		TableName:           aws.String("email_suppressions"),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(email)"),
+	})
+	if err != nil {
+		if strings.Contains(err.Error(), "ConditionalCheckFailed") {
+			return nil
+		}
+		if strings.Contains(err.Error(), "ResourceNotFoundException") {
+			return errors.New("La tabla 'email_suppressions' no existe en DynamoDB. Verifique que LocalStack esté ejecutándose y la tabla haya sido creada.")
+		}
+		return fmt.Errorf("error guardando supresión en DynamoDB: %v", err)
+	}
+
+	return nil
+}
+
+// GetEmailSuppression obtiene la entrada de la lista de supresión de una dirección.
+// Devuelve ErrNotFound si la dirección no está suprimida.
+func (d *DynamoClient) GetEmailSuppression(email string) (*model.EmailSuppression, error) {
+	result, err := d.Client.GetItem(context.TODO(), &dynamodb.GetItemInput{
+		TableName: aws.String("email_suppressions"),
+		Key: map[string]types.AttributeValue{
+			"email": &types.AttributeValueMemberS{Value: normalizeEmail(email)},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if result.Item == nil {
+		return nil, fmt.Errorf("suppression %w", ErrNotFound)
+	}
+
+	return d.unmarshalEmailSuppression(result.Item), nil
+}
+
+// GetEmailSuppressions lista la lista de supresión, filtrando opcionalmente por motivo
+func (d *DynamoClient) GetEmailSuppressions(reason string, limit int) ([]model.EmailSuppression, error) {
+	scanInput := &dynamodb.ScanInput{
+		TableName: aws.String("email_suppressions"),
+	}
+	if reason != "" {
+		scanInput.FilterExpression = aws.String("#reason = :reason")
+		scanInput.ExpressionAttributeNames = map[string]string{"#reason": "reason"}
+		scanInput.ExpressionAttributeValues = map[string]types.AttributeValue{
+			":reason": &types.AttributeValueMemberS{Value: reason},
+		}
+	}
+
+	var suppressions []model.EmailSuppression
+	for {
+		result, err := d.Client.Scan(context.TODO(), scanInput)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, item := range result.Items {
+			suppressions = append(suppressions, *d.unmarshalEmailSuppression(item))
+			if limit > 0 && len(suppressions) >= limit {
+				return suppressions, nil
+			}
+		}
+
+		if len(result.LastEvaluatedKey) == 0 {
+			break
+		}
+		scanInput.ExclusiveStartKey = result.LastEvaluatedKey
+	}
+
+	return suppressions, nil
+}
+
+// DeleteEmailSuppression quita una dirección de la lista de supresión. Devuelve
+// ErrNotFound si no estaba.
+func (d *DynamoClient) DeleteEmailSuppression(email string) error {
+	_, err := d.Client.DeleteItem(context.TODO(), &dynamodb.DeleteItemInput{
+		TableName: aws.String("email_suppressions"),
+		Key: map[string]types.AttributeValue{
+			"email": &types.AttributeValueMemberS{Value: normalizeEmail(email)},
+		},
+		ConditionExpression: aws.String("attribute_exists(email)"),
+	})
+	if err != nil {
+		if strings.Contains(err.Error(), "ConditionalCheckFailed") {
+			return fmt.Errorf("suppression %w", ErrNotFound)
+		}
+		return err
+	}
+	return nil
+}
+
+// SaveEmailMessage asocia el ID de mensaje de SES con la notificación que lo envió
+func (d *DynamoClient) SaveEmailMessage(messageID, notificationID, recipient string, sentAt time.Time) error {
+	_, err := d.Client.PutItem(context.TODO(), &dynamodb.PutItemInput{
+		TableName: aws.String("email_messages"),
+		Item: map[string]types.AttributeValue{
+			"message_id":      &types.AttributeValueMemberS{Value: messageID},
+			"notification_id": &types.AttributeValueMemberS{Value: notificationID},
+			"recipient":       &types.AttributeValueMemberS{Value: recipient},
+			"created_at":      &types.AttributeValueMemberS{Value: sentAt.Format(time.RFC3339)},
+			"expires_at":      &types.AttributeValueMemberN{Value: strconv.FormatInt(sentAt.Add(emailMessageRetention).Unix(), 10)},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("error guardando mensaje de email en DynamoDB: %v", err)
+	}
+	return nil
+}
+
+// GetEmailMessageNotification obtiene el ID de la notificación que envió un mensaje de SES.
+// Devuelve ErrNotFound si el mensaje no es de este servicio o ya expiró.
+func (d *DynamoClient) GetEmailMessageNotification(messageID string) (string, error) {
+	result, err := d.Client.GetItem(context.TODO(), &dynamodb.GetItemInput{
+		TableName: aws.String("email_messages"),
+		Key: map[string]types.AttributeValue{
+			"message_id": &types.AttributeValueMemberS{Value: messageID},
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	notificationIDVal, ok := result.Item["notification_id"].(*types.AttributeValueMemberS)
+	if !ok {
+		return "", fmt.Errorf("email message %w", ErrNotFound)
+	}
+	return notificationIDVal.Value, nil
+}
+
+// unmarshalEmailSuppression convierte un item de DynamoDB a EmailSuppression
+func (d *DynamoClient) unmarshalEmailSuppression(item map[string]types.AttributeValue) *model.EmailSuppression {
+	suppression := &model.EmailSuppression{}
+
+	if emailVal, ok := item["email"].(*types.AttributeValueMemberS); ok {
+		suppression.Email = emailVal.Value
+	}
+
+	if reasonVal, ok := item["reason"].(*types.AttributeValueMemberS); ok {
+		suppression.Reason = model.SuppressionReason(reasonVal.Value)
+	}
+
+	if detailsVal, ok := item["details"].(*types.AttributeValueMemberS); ok {
+		suppression.Details = detailsVal.Value
+	}
+
+	if notificationIDVal, ok := item["notification_id"].(*types.AttributeValueMemberS); ok {
+		suppression.NotificationID = notificationIDVal.Value
+	}
+
+	if createdAtVal, ok := item["created_at"].(*types.AttributeValueMemberS); ok {
+		suppression.CreatedAt, _ = time.Parse(time.RFC3339, createdAtVal.Value)
+	}
+
+	return suppression
+}
+
+// normalizeEmail normaliza una dirección para usarla como clave
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
@@ -0,0 +1,254 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/google/uuid"
+	"github.com/jhonathanssegura/ticket-notification/internal/model"
+)
+
+// ErrTemplateNameTaken indica que otra plantilla ya usa el nombre
+var ErrTemplateNameTaken = errors.New("template name already in use")
+
+// templateNamesTable reserva cada nombre de plantilla para una sola plantilla
+const templateNamesTable = "notification_template_names"
+
+// SaveNotificationTemplateVersion guarda la plantilla junto con una nueva versión inmutable.
+// previousVersion es la versión que se está reemplazando; si otro proceso la modificó
+// entretanto, la escritura falla. En la misma transacción se reserva el nombre de la
+// plantilla y, si cambió, se libera previousName; si otra plantilla ya tiene el nombre
+// devuelve ErrTemplateNameTaken.
+func (d *DynamoClient) SaveNotificationTemplateVersion(template model.NotificationTemplate, previousVersion int, previousName string) error {
+	fmt.Printf("Guardando versión de plantilla: ID=%s, Name=%s, Version=%d\n",
+		template.ID.String(), template.Name, template.Version)
+
+	versionItem := map[string]types.AttributeValue{
+		"template_id": &types.AttributeValueMemberS{Value: template.ID.String()},
+		"version":     &types.AttributeValueMemberN{Value: strconv.Itoa(template.Version)},
+		"name":        &types.AttributeValueMemberS{Value: template.Name},
+		"type":        &types.AttributeValueMemberS{Value: string(template.Type)},
+		"subject":     &types.AttributeValueMemberS{Value: template.Subject},
+		"content":     &types.AttributeValueMemberS{Value: template.Content},
+		"created_at":  &types.AttributeValueMemberS{Value: template.UpdatedAt.Format(time.RFC3339)},
+	}
+	if template.HTMLContent != "" {
+		versionItem["html_content"] = &types.AttributeValueMemberS{Value: template.HTMLContent}
+	}
+	addTemplateLocales(versionItem, template.Locale, template.Locales)
+	if len(template.Variables) > 0 {
+		versionItem["variables"] = &types.AttributeValueMemberS{Value: strings.Join(template.Variables, ",")}
+	}
+
+	transactItems := []types.TransactWriteItem{
+		{
+			Put: &types.Put{
+				TableName:           aws.String("notification_templates"),
+				Item:                templateItem(template),
+				ConditionExpression: aws.String("attribute_not_exists(#version) OR #version = :previous"),
+				ExpressionAttributeNames: map[string]string{
+					"#version": "version",
+				},
+				ExpressionAttributeValues: map[string]types.AttributeValue{
+					":previous": &types.AttributeValueMemberN{Value: strconv.Itoa(previousVersion)},
+				},
+			},
+		},
+		{
+			Put: &types.Put{
+				TableName:           aws.String("notification_template_versions"),
+				Item:                versionItem,
+				ConditionExpression: aws.String("attribute_not_exists(template_id)"),
+			},
+		},
+		{
+			Put: &types.Put{
+				TableName: aws.String(templateNamesTable),
+				Item: map[string]types.AttributeValue{
+					"name":        &types.AttributeValueMemberS{Value: template.Name},
+					"template_id": &types.AttributeValueMemberS{Value: template.ID.String()},
+				},
+				ConditionExpression:       aws.String("attribute_not_exists(#name) OR template_id = :template_id"),
+				ExpressionAttributeNames:  map[string]string{"#name": "name"},
+				ExpressionAttributeValues: templateIDValue(template.ID.String()),
+			},
+		},
+	}
+	if previousName != "" && previousName != template.Name {
+		transactItems = append(transactItems, templateNameRelease(previousName, template.ID.String()))
+	}
+
+	_, err := d.Client.TransactWriteItems(context.TODO(), &dynamodb.TransactWriteItemsInput{
+		TransactItems: transactItems,
+	})
+
+	if err != nil {
+		// La tercera operación es la reserva del nombre
+		var canceled *types.TransactionCanceledException
+		if errors.As(err, &canceled) && len(canceled.CancellationReasons) > 2 &&
+			aws.ToString(canceled.CancellationReasons[2].Code) == "ConditionalCheckFailed" {
+			return fmt.Errorf("%w: %s", ErrTemplateNameTaken, template.Name)
+		}
+
+		var errorMsg string
+		switch {
+		case strings.Contains(err.Error(), "ResourceNotFoundException"):
+			errorMsg = "La tabla 'notification_template_versions' no existe en DynamoDB. Verifique que LocalStack esté ejecutándose y la tabla haya sido creada."
+		case strings.Contains(err.Error(), "RequestCanceled"):
+			errorMsg = "Error de conexión con DynamoDB. Verifique que LocalStack esté ejecutándose en http://localhost:4566."
+		case strings.Contains(err.Error(), "ConditionalCheckFailed"), strings.Contains(err.Error(), "TransactionCanceledException"):
+			errorMsg = "version conflict: la plantilla fue modificada por otra operación"
+		default:
+			errorMsg = fmt.Sprintf("Error guardando versión de plantilla en DynamoDB: %v", err)
+		}
+		return errors.New(errorMsg)
+	}
+
+	return nil
+}
+
+// templateNameRelease libera el nombre reservado por la plantilla, si lo tenía reservado
+func templateNameRelease(name, templateID string) types.TransactWriteItem {
+	return types.TransactWriteItem{
+		Delete: &types.Delete{
+			TableName: aws.String(templateNamesTable),
+			Key: map[string]types.AttributeValue{
+				"name": &types.AttributeValueMemberS{Value: name},
+			},
+			ConditionExpression:       aws.String("attribute_not_exists(#name) OR template_id = :template_id"),
+			ExpressionAttributeNames:  map[string]string{"#name": "name"},
+			ExpressionAttributeValues: templateIDValue(templateID),
+		},
+	}
+}
+
+// templateIDValue arma el valor :template_id de las condiciones sobre nombres reservados
+func templateIDValue(templateID string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		":template_id": &types.AttributeValueMemberS{Value: templateID},
+	}
+}
+
+// GetNotificationTemplateVersions obtiene todas las versiones de una plantilla, de la más antigua a la más reciente
+func (d *DynamoClient) GetNotificationTemplateVersions(templateID string) ([]model.TemplateVersion, error) {
+	queryInput := &dynamodb.QueryInput{
+		TableName:              aws.String("notification_template_versions"),
+		KeyConditionExpression: aws.String("template_id = :template_id"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":template_id": &types.AttributeValueMemberS{Value: templateID},
+		},
+		ScanIndexForward: aws.Bool(true),
+	}
+
+	var versions []model.TemplateVersion
+	for {
+		result, err := d.Client.Query(context.TODO(), queryInput)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, item := range result.Items {
+			version, err := d.unmarshalTemplateVersion(item)
+			if err != nil {
+				return nil, err
+			}
+			versions = append(versions, *version)
+		}
+
+		if len(result.LastEvaluatedKey) == 0 {
+			break
+		}
+		queryInput.ExclusiveStartKey = result.LastEvaluatedKey
+	}
+
+	return versions, nil
+}
+
+// GetNotificationTemplateVersion obtiene una versión concreta de una plantilla
+func (d *DynamoClient) GetNotificationTemplateVersion(templateID string, version int) (*model.TemplateVersion, error) {
+	result, err := d.Client.GetItem(context.TODO(), &dynamodb.GetItemInput{
+		TableName: aws.String("notification_template_versions"),
+		Key: map[string]types.AttributeValue{
+			"template_id": &types.AttributeValueMemberS{Value: templateID},
+			"version":     &types.AttributeValueMemberN{Value: strconv.Itoa(version)},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if result.Item == nil {
+		return nil, fmt.Errorf("template version %w", ErrNotFound)
+	}
+
+	return d.unmarshalTemplateVersion(result.Item)
+}
+
+// unmarshalTemplateVersion convierte un item de DynamoDB a TemplateVersion
+func (d *DynamoClient) unmarshalTemplateVersion(item map[string]types.AttributeValue) (*model.TemplateVersion, error) {
+	version := &model.TemplateVersion{}
+
+	if idVal, ok := item["template_id"].(*types.AttributeValueMemberS); ok {
+		id, err := uuid.Parse(idVal.Value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid template ID: %v", err)
+		}
+		version.TemplateID = id
+	}
+
+	if versionVal, ok := item["version"].(*types.AttributeValueMemberN); ok {
+		number, err := strconv.Atoi(versionVal.Value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid template version: %v", err)
+		}
+		version.Version = number
+	}
+
+	if nameVal, ok := item["name"].(*types.AttributeValueMemberS); ok {
+		version.Name = nameVal.Value
+	}
+
+	if typeVal, ok := item["type"].(*types.AttributeValueMemberS); ok {
+		version.Type = model.NotificationType(typeVal.Value)
+	}
+
+	if subjectVal, ok := item["subject"].(*types.AttributeValueMemberS); ok {
+		version.Subject = subjectVal.Value
+	}
+
+	if contentVal, ok := item["content"].(*types.AttributeValueMemberS); ok {
+		version.Content = contentVal.Value
+	}
+
+	if htmlContentVal, ok := item["html_content"].(*types.AttributeValueMemberS); ok {
+		version.HTMLContent = htmlContentVal.Value
+	}
+
+	locale, locales, err := templateLocales(item)
+	if err != nil {
+		return nil, err
+	}
+	version.Locale = locale
+	version.Locales = locales
+
+	if createdAtVal, ok := item["created_at"].(*types.AttributeValueMemberS); ok {
+		createdAt, err := time.Parse(time.RFC3339, createdAtVal.Value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid created_at time: %v", err)
+		}
+		version.CreatedAt = createdAt
+	}
+
+	if variablesVal, ok := item["variables"].(*types.AttributeValueMemberS); ok {
+		version.Variables = strings.Split(variablesVal.Value, ",")
+	}
+
+	return version, nil
+}
@@ -0,0 +1,325 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/google/uuid"
+	"github.com/jhonathanssegura/ticket-notification/internal/model"
+)
+
+// SaveWebhookSubscription guarda una suscripción de webhook
+func (d *DynamoClient) SaveWebhookSubscription(subscription model.WebhookSubscription) error {
+	fmt.Printf("Guardando suscripción de webhook: ID=%s, Name=%s, URL=%s\n",
+		subscription.ID.String(), subscription.Name, subscription.URL)
+
+	eventTypes := make([]string, len(subscription.EventTypes))
+	for i, eventType := range subscription.EventTypes {
+		eventTypes[i] = string(eventType)
+	}
+
+	item := map[string]types.AttributeValue{
+		"id":          &types.AttributeValueMemberS{Value: subscription.ID.String()},
+		"name":        &types.AttributeValueMemberS{Value: subscription.Name},
+		"url":         &types.AttributeValueMemberS{Value: subscription.URL},
+		"secret":      &types.AttributeValueMemberS{Value: subscription.Secret},
+		"event_types": &types.AttributeValueMemberS{Value: strings.Join(eventTypes, ",")},
+		"is_active":   &types.AttributeValueMemberBOOL{Value: subscription.IsActive},
+		"created_at":  &types.AttributeValueMemberS{Value: subscription.CreatedAt.Format(time.RFC3339)},
+		"updated_at":  &types.AttributeValueMemberS{Value: subscription.UpdatedAt.Format(time.RFC3339)},
+	}
+
+	_, err := d.Client.PutItem(context.TODO(), &dynamodb.PutItemInput{
+		TableName: aws.String("webhook_subscriptions"),
+		Item:      item,
+	})
+
+	if err != nil {
+		var errorMsg string
+		switch {
+		case strings.Contains(err.Error(), "ResourceNotFoundException"):
+			errorMsg = "La tabla 'webhook_subscriptions' no existe en DynamoDB. Verifique que LocalStack esté ejecutándose y la tabla haya sido creada."
+		case strings.Contains(err.Error(), "RequestCanceled"):
+			errorMsg = "Error de conexión con DynamoDB. Verifique que LocalStack esté ejecutándose en http://localhost:4566."
+		default:
+			errorMsg = fmt.Sprintf("Error guardando suscripción de webhook en DynamoDB: %v", err)
+		}
+		return errors.New(errorMsg)
+	}
+
+	return nil
+}
+
+// GetWebhookSubscription obtiene una suscripción de webhook por ID
+func (d *DynamoClient) GetWebhookSubscription(subscriptionID string) (*model.WebhookSubscription, error) {
+	result, err := d.Client.GetItem(context.TODO(), &dynamodb.GetItemInput{
+		TableName: aws.String("webhook_subscriptions"),
+		Key: map[string]types.AttributeValue{
+			"id": &types.AttributeValueMemberS{Value: subscriptionID},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if result.Item == nil {
+		return nil, fmt.Errorf("webhook subscription %w", ErrNotFound)
+	}
+
+	return d.unmarshalWebhookSubscription(result.Item)
+}
+
+// GetWebhookSubscriptions lista las suscripciones, filtrando opcionalmente por tipo de notificación y estado
+func (d *DynamoClient) GetWebhookSubscriptions(eventType string, isActive *bool) ([]model.WebhookSubscription, error) {
+	scanInput := &dynamodb.ScanInput{
+		TableName: aws.String("webhook_subscriptions"),
+	}
+
+	if isActive != nil {
+		scanInput.FilterExpression = aws.String("#is_active = :is_active")
+		scanInput.ExpressionAttributeNames = map[string]string{"#is_active": "is_active"}
+		scanInput.ExpressionAttributeValues = map[string]types.AttributeValue{
+			":is_active": &types.AttributeValueMemberBOOL{Value: *isActive},
+		}
+	}
+
+	var subscriptions []model.WebhookSubscription
+	for {
+		result, err := d.Client.Scan(context.TODO(), scanInput)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, item := range result.Items {
+			subscription, err := d.unmarshalWebhookSubscription(item)
+			if err != nil {
+				return nil, err
+			}
+			// Los tipos se guardan como lista separada por comas, así que se filtran aquí
+			if eventType != "" && !subscription.Subscribes(model.NotificationType(eventType)) {
+				continue
+			}
+			subscriptions = append(subscriptions, *subscription)
+		}
+
+		if len(result.LastEvaluatedKey) == 0 {
+			break
+		}
+		scanInput.ExclusiveStartKey = result.LastEvaluatedKey
+	}
+
+	return subscriptions, nil
+}
+
+// DeleteWebhookSubscription elimina una suscripción de webhook
+func (d *DynamoClient) DeleteWebhookSubscription(subscriptionID string) error {
+	_, err := d.Client.DeleteItem(context.TODO(), &dynamodb.DeleteItemInput{
+		TableName: aws.String("webhook_subscriptions"),
+		Key: map[string]types.AttributeValue{
+			"id": &types.AttributeValueMemberS{Value: subscriptionID},
+		},
+	})
+	return err
+}
+
+// SaveWebhookDeliveryAttempt registra un intento de entrega de un webhook
+func (d *DynamoClient) SaveWebhookDeliveryAttempt(attempt model.WebhookDeliveryAttempt) error {
+	item := map[string]types.AttributeValue{
+		"subscription_id": &types.AttributeValueMemberS{Value: attempt.SubscriptionID.String()},
+		"created_at":      &types.AttributeValueMemberS{Value: attempt.CreatedAt.Format(time.RFC3339Nano)},
+		"id":              &types.AttributeValueMemberS{Value: attempt.ID.String()},
+		"notification_id": &types.AttributeValueMemberS{Value: attempt.NotificationID},
+		"event_type":      &types.AttributeValueMemberS{Value: string(attempt.EventType)},
+		"url":             &types.AttributeValueMemberS{Value: attempt.URL},
+		"attempt":         &types.AttributeValueMemberN{Value: strconv.Itoa(attempt.Attempt)},
+		"status_code":     &types.AttributeValueMemberN{Value: strconv.Itoa(attempt.StatusCode)},
+		"success":         &types.AttributeValueMemberBOOL{Value: attempt.Success},
+		"duration_ms":     &types.AttributeValueMemberN{Value: strconv.FormatInt(attempt.DurationMs, 10)},
+	}
+	if attempt.Error != "" {
+		item["error"] = &types.AttributeValueMemberS{Value: attempt.Error}
+	}
+	if attempt.ResponseBody != "" {
+		item["response_body"] = &types.AttributeValueMemberS{Value: attempt.ResponseBody}
+	}
+
+	_, err := d.Client.PutItem(context.TODO(), &dynamodb.PutItemInput{
+		TableName: aws.String("webhook_deliveries"),
+		Item:      item,
+	})
+	if err != nil {
+		return fmt.Errorf("error guardando intento de webhook en DynamoDB: %v", err)
+	}
+
+	return nil
+}
+
+// GetWebhookDeliveryAttempts obtiene los intentos de entrega de una suscripción, del más
+// reciente al más antiguo, filtrando opcionalmente por notificación
+func (d *DynamoClient) GetWebhookDeliveryAttempts(subscriptionID, notificationID string, limit int) ([]model.WebhookDeliveryAttempt, error) {
+	queryInput := &dynamodb.QueryInput{
+		TableName:              aws.String("webhook_deliveries"),
+		KeyConditionExpression: aws.String("subscription_id = :subscription_id"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":subscription_id": &types.AttributeValueMemberS{Value: subscriptionID},
+		},
+		ScanIndexForward: aws.Bool(false),
+	}
+	if notificationID != "" {
+		queryInput.FilterExpression = aws.String("notification_id = :notification_id")
+		queryInput.ExpressionAttributeValues[":notification_id"] = &types.AttributeValueMemberS{Value: notificationID}
+	}
+
+	var attempts []model.WebhookDeliveryAttempt
+	for {
+		result, err := d.Client.Query(context.TODO(), queryInput)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, item := range result.Items {
+			attempt, err := d.unmarshalWebhookDeliveryAttempt(item)
+			if err != nil {
+				return nil, err
+			}
+			attempts = append(attempts, *attempt)
+			if limit > 0 && len(attempts) >= limit {
+				return attempts, nil
+			}
+		}
+
+		if len(result.LastEvaluatedKey) == 0 {
+			break
+		}
+		queryInput.ExclusiveStartKey = result.LastEvaluatedKey
+	}
+
+	return attempts, nil
+}
+
+// unmarshalWebhookSubscription convierte un item de DynamoDB a WebhookSubscription
+func (d *DynamoClient) unmarshalWebhookSubscription(item map[string]types.AttributeValue) (*model.WebhookSubscription, error) {
+	subscription := &model.WebhookSubscription{}
+
+	if idVal, ok := item["id"].(*types.AttributeValueMemberS); ok {
+		id, err := uuid.Parse(idVal.Value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid webhook subscription ID: %v", err)
+		}
+		subscription.ID = id
+	}
+
+	if nameVal, ok := item["name"].(*types.AttributeValueMemberS); ok {
+		subscription.Name = nameVal.Value
+	}
+
+	if urlVal, ok := item["url"].(*types.AttributeValueMemberS); ok {
+		subscription.URL = urlVal.Value
+	}
+
+	if secretVal, ok := item["secret"].(*types.AttributeValueMemberS); ok {
+		subscription.Secret = secretVal.Value
+	}
+
+	if eventTypesVal, ok := item["event_types"].(*types.AttributeValueMemberS); ok && eventTypesVal.Value != "" {
+		for _, eventType := range strings.Split(eventTypesVal.Value, ",") {
+			subscription.EventTypes = append(subscription.EventTypes, model.NotificationType(eventType))
+		}
+	}
+
+	if isActiveVal, ok := item["is_active"].(*types.AttributeValueMemberBOOL); ok {
+		subscription.IsActive = isActiveVal.Value
+	}
+
+	if createdAtVal, ok := item["created_at"].(*types.AttributeValueMemberS); ok {
+		createdAt, err := time.Parse(time.RFC3339, createdAtVal.Value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid created_at time: %v", err)
+		}
+		subscription.CreatedAt = createdAt
+	}
+
+	if updatedAtVal, ok := item["updated_at"].(*types.AttributeValueMemberS); ok {
+		updatedAt, err := time.Parse(time.RFC3339, updatedAtVal.Value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid updated_at time: %v", err)
+		}
+		subscription.UpdatedAt = updatedAt
+	}
+
+	return subscription, nil
+}
+
+// unmarshalWebhookDeliveryAttempt convierte un item de DynamoDB a WebhookDeliveryAttempt
+func (d *DynamoClient) unmarshalWebhookDeliveryAttempt(item map[string]types.AttributeValue) (*model.WebhookDeliveryAttempt, error) {
+	attempt := &model.WebhookDeliveryAttempt{}
+
+	if idVal, ok := item["id"].(*types.AttributeValueMemberS); ok {
+		id, err := uuid.Parse(idVal.Value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid webhook delivery ID: %v", err)
+		}
+		attempt.ID = id
+	}
+
+	if subscriptionIDVal, ok := item["subscription_id"].(*types.AttributeValueMemberS); ok {
+		subscriptionID, err := uuid.Parse(subscriptionIDVal.Value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid webhook subscription ID: %v", err)
+		}
+		attempt.SubscriptionID = subscriptionID
+	}
+
+	if notificationIDVal, ok := item["notification_id"].(*types.AttributeValueMemberS); ok {
+		attempt.NotificationID = notificationIDVal.Value
+	}
+
+	if eventTypeVal, ok := item["event_type"].(*types.AttributeValueMemberS); ok {
+		attempt.EventType = model.NotificationType(eventTypeVal.Value)
+	}
+
+	if urlVal, ok := item["url"].(*types.AttributeValueMemberS); ok {
+		attempt.URL = urlVal.Value
+	}
+
+	if attemptVal, ok := item["attempt"].(*types.AttributeValueMemberN); ok {
+		attempt.Attempt, _ = strconv.Atoi(attemptVal.Value)
+	}
+
+	if statusCodeVal, ok := item["status_code"].(*types.AttributeValueMemberN); ok {
+		attempt.StatusCode, _ = strconv.Atoi(statusCodeVal.Value)
+	}
+
+	if successVal, ok := item["success"].(*types.AttributeValueMemberBOOL); ok {
+		attempt.Success = successVal.Value
+	}
+
+	if errorVal, ok := item["error"].(*types.AttributeValueMemberS); ok {
+		attempt.Error = errorVal.Value
+	}
+
+	if responseBodyVal, ok := item["response_body"].(*types.AttributeValueMemberS); ok {
+		attempt.ResponseBody = responseBodyVal.Value
+	}
+
+	if durationVal, ok := item["duration_ms"].(*types.AttributeValueMemberN); ok {
+		attempt.DurationMs, _ = strconv.ParseInt(durationVal.Value, 10, 64)
+	}
+
+	if createdAtVal, ok := item["created_at"].(*types.AttributeValueMemberS); ok {
+		createdAt, err := time.Parse(time.RFC3339Nano, createdAtVal.Value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid created_at time: %v", err)
+		}
+		attempt.CreatedAt = createdAt
+	}
+
+	return attempt, nil
+}
@@ -0,0 +1,234 @@
+package email
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var (
+	styleBlockPattern   = regexp.MustCompile(`(?is)<style[^>]*>(.*?)</style>`)
+	cssCommentPattern   = regexp.MustCompile(`(?s)/\*.*?\*/`)
+	openTagPattern      = regexp.MustCompile(`<([a-zA-Z][a-zA-Z0-9]*)(\s[^<>]*?)?(/?)>`)
+	attributePattern    = regexp.MustCompile(`(?i)\s([a-zA-Z-]+)\s*=\s*("[^"]*"|'[^']*'|[^\s"'>]+)`)
+	simpleSelector      = regexp.MustCompile(`^([a-zA-Z][a-zA-Z0-9]*)?((?:[.#][a-zA-Z0-9_-]+)*)$`)
+	selectorPartPattern = regexp.MustCompile(`[.#][a-zA-Z0-9_-]+`)
+
+	invisibleBlockPattern = regexp.MustCompile(`(?is)<(head|style|script)[^>]*>.*?</(head|style|script)>`)
+	linkPattern           = regexp.MustCompile(`(?is)<a\s[^>]*href\s*=\s*["']([^"']+)["'][^>]*>(.*?)</a>`)
+	lineBreakPattern      = regexp.MustCompile(`(?i)<br\s*/?>`)
+	blockEndPattern       = regexp.MustCompile(`(?i)</(p|div|h[1-6]|tr|table|ul|ol|blockquote)>`)
+	listItemPattern       = regexp.MustCompile(`(?i)<li[^>]*>`)
+	tagPattern            = regexp.MustCompile(`(?s)<[^>]+>`)
+	spacesPattern         = regexp.MustCompile(`[ \t\r\f\v]+`)
+	blankLinesPattern     = regexp.MustCompile(`\n{3,}`)
+)
+
+// cssRule representa una regla CSS con un selector simple
+type cssRule struct {
+	tag          string
+	ids          []string
+	classes      []string
+	declarations string
+	specificity  int
+	order        int
+}
+
+// InlineCSS aplica las reglas de los bloques <style> como atributos style de cada
+// elemento, que es lo que respetan la mayoría de los clientes de correo. Solo se
+// soportan selectores simples (etiqueta, .clase, #id y sus combinaciones); los
+// bloques @media y selectores compuestos se descartan.
+func InlineCSS(document string) string {
+	var rules []cssRule
+	for _, match := range styleBlockPattern.FindAllStringSubmatch(document, -1) {
+		rules = append(rules, parseCSS(match[1], len(rules))...)
+	}
+	if len(rules) == 0 {
+		return document
+	}
+
+	document = styleBlockPattern.ReplaceAllString(document, "")
+
+	return openTagPattern.ReplaceAllStringFunc(document, func(tag string) string {
+		parts := openTagPattern.FindStringSubmatch(tag)
+		name, attributes, selfClosing := strings.ToLower(parts[1]), parts[2], parts[3]
+
+		attrs := parseAttributes(attributes)
+		var matched []cssRule
+		for _, rule := range rules {
+			if rule.matches(name, attrs["id"], strings.Fields(attrs["class"])) {
+				matched = append(matched, rule)
+			}
+		}
+		if len(matched) == 0 {
+			return tag
+		}
+
+		sort.SliceStable(matched, func(i, j int) bool {
+			if matched[i].specificity != matched[j].specificity {
+				return matched[i].specificity < matched[j].specificity
+			}
+			return matched[i].order < matched[j].order
+		})
+
+		var declarations []string
+		for _, rule := range matched {
+			declarations = append(declarations, rule.declarations)
+		}
+		// El estilo inline existente tiene prioridad sobre las reglas de la hoja
+		if existing := strings.TrimSpace(attrs["style"]); existing != "" {
+			declarations = append(declarations, strings.TrimSuffix(existing, ";"))
+		}
+		style := html.EscapeString(strings.Join(declarations, "; "))
+
+		attributes = removeAttribute(attributes, "style")
+		return fmt.Sprintf("<%s%s style=\"%s\"%s>", parts[1], attributes, style, selfClosing)
+	})
+}
+
+// HTMLToText genera una versión de texto plano a partir de un documento HTML
+func HTMLToText(document string) string {
+	text := invisibleBlockPattern.ReplaceAllString(document, "")
+	text = linkPattern.ReplaceAllStringFunc(text, func(link string) string {
+		parts := linkPattern.FindStringSubmatch(link)
+		label := strings.TrimSpace(tagPattern.ReplaceAllString(parts[2], ""))
+		if label == "" || label == parts[1] {
+			return parts[1]
+		}
+		return fmt.Sprintf("%s (%s)", label, parts[1])
+	})
+	text = lineBreakPattern.ReplaceAllString(text, "\n")
+	text = listItemPattern.ReplaceAllString(text, "\n- ")
+	text = blockEndPattern.ReplaceAllString(text, "\n\n")
+	text = tagPattern.ReplaceAllString(text, "")
+	text = html.UnescapeString(text)
+
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(spacesPattern.ReplaceAllString(line, " "))
+	}
+	text = blankLinesPattern.ReplaceAllString(strings.Join(lines, "\n"), "\n\n")
+
+	return strings.TrimSpace(text)
+}
+
+// parseCSS extrae las reglas con selectores simples de una hoja de estilos
+func parseCSS(css string, offset int) []cssRule {
+	css = cssCommentPattern.ReplaceAllString(css, "")
+
+	var rules []cssRule
+	for len(css) > 0 {
+		open := strings.Index(css, "{")
+		if open < 0 {
+			break
+		}
+		selectors := strings.TrimSpace(css[:open])
+
+		// Buscar el cierre del bloque respetando llaves anidadas (@media)
+		depth, end := 0, -1
+		for i := open; i < len(css); i++ {
+			if css[i] == '{' {
+				depth++
+			} else if css[i] == '}' {
+				depth--
+				if depth == 0 {
+					end = i
+					break
+				}
+			}
+		}
+		if end < 0 {
+			break
+		}
+		body := strings.TrimSpace(css[open+1 : end])
+		css = css[end+1:]
+
+		if strings.HasPrefix(selectors, "@") {
+			continue
+		}
+
+		declarations := strings.TrimSuffix(body, ";")
+		for _, selector := range strings.Split(selectors, ",") {
+			rule, ok := parseSelector(strings.TrimSpace(selector))
+			if !ok {
+				continue
+			}
+			rule.declarations = declarations
+			rule.order = offset + len(rules)
+			rules = append(rules, rule)
+		}
+	}
+
+	return rules
+}
+
+// parseSelector interpreta un selector simple como "p", ".btn", "td.total" o "#header"
+func parseSelector(selector string) (cssRule, bool) {
+	match := simpleSelector.FindStringSubmatch(selector)
+	if match == nil || selector == "" {
+		return cssRule{}, false
+	}
+
+	rule := cssRule{tag: strings.ToLower(match[1])}
+	if rule.tag != "" {
+		rule.specificity = 1
+	}
+	for _, part := range selectorPartPattern.FindAllString(match[2], -1) {
+		if part[0] == '#' {
+			rule.ids = append(rule.ids, part[1:])
+			rule.specificity += 100
+		} else {
+			rule.classes = append(rule.classes, part[1:])
+			rule.specificity += 10
+		}
+	}
+
+	return rule, true
+}
+
+// matches indica si la regla aplica a un elemento
+func (r cssRule) matches(tag, id string, classes []string) bool {
+	if r.tag != "" && r.tag != tag {
+		return false
+	}
+	for _, ruleID := range r.ids {
+		if ruleID != id {
+			return false
+		}
+	}
+	for _, ruleClass := range r.classes {
+		found := false
+		for _, class := range classes {
+			if class == ruleClass {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// parseAttributes obtiene los atributos de una etiqueta de apertura
+func parseAttributes(attributes string) map[string]string {
+	attrs := make(map[string]string)
+	for _, match := range attributePattern.FindAllStringSubmatch(attributes, -1) {
+		attrs[strings.ToLower(match[1])] = html.UnescapeString(strings.Trim(match[2], `"'`))
+	}
+	return attrs
+}
+
+// removeAttribute elimina un atributo de la lista de atributos de una etiqueta
+func removeAttribute(attributes, name string) string {
+	return attributePattern.ReplaceAllStringFunc(attributes, func(attribute string) string {
+		match := attributePattern.FindStringSubmatch(attribute)
+		if strings.EqualFold(match[1], name) {
+			return ""
+		}
+		return attribute
+	})
+}
@@ -0,0 +1,109 @@
+package email
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// CalendarEvent representa los datos de un evento para una invitación iCalendar
+type CalendarEvent struct {
+	UID         string
+	Summary     string
+	Location    string
+	Description string
+	Start       time.Time
+	End         time.Time
+}
+
+// BuildICS genera un archivo iCalendar (RFC 5545) con un único evento
+func BuildICS(event CalendarEvent) []byte {
+	if event.End.IsZero() || !event.End.After(event.Start) {
+		event.End = event.Start.Add(2 * time.Hour)
+	}
+
+	lines := []string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//Ticket System//Notification Service//ES",
+		"CALSCALE:GREGORIAN",
+		"METHOD:PUBLISH",
+		"BEGIN:VEVENT",
+		"UID:" + escapeICSText(event.UID),
+		"DTSTAMP:" + formatICSTime(time.Now()),
+		"DTSTART:" + formatICSTime(event.Start),
+		"DTEND:" + formatICSTime(event.End),
+		"SUMMARY:" + escapeICSText(event.Summary),
+	}
+	if event.Location != "" {
+		lines = append(lines, "LOCATION:"+escapeICSText(event.Location))
+	}
+	if event.Description != "" {
+		lines = append(lines, "DESCRIPTION:"+escapeICSText(event.Description))
+	}
+	lines = append(lines, "END:VEVENT", "END:VCALENDAR")
+
+	var builder strings.Builder
+	for _, line := range lines {
+		builder.WriteString(foldICSLine(line))
+		builder.WriteString("\r\n")
+	}
+
+	return []byte(builder.String())
+}
+
+// formatICSTime formatea una fecha en UTC como exige iCalendar
+func formatICSTime(t time.Time) string {
+	return t.UTC().Format("20060102T150405Z")
+}
+
+// escapeICSText escapa los caracteres especiales de un valor de texto
+func escapeICSText(text string) string {
+	replacer := strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+	)
+	return replacer.Replace(text)
+}
+
+// foldICSLine divide las líneas de más de 75 octetos sin cortar caracteres UTF-8
+func foldICSLine(line string) string {
+	if len(line) <= 75 {
+		return line
+	}
+
+	var builder strings.Builder
+	width := 0
+	for _, r := range line {
+		size := len(string(r))
+		if width+size > 75 {
+			builder.WriteString("\r\n ")
+			width = 1
+		}
+		builder.WriteRune(r)
+		width += size
+	}
+
+	return builder.String()
+}
+
+// ICSFilename devuelve un nombre de archivo seguro para la invitación de un evento
+func ICSFilename(name string) string {
+	safe := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+			return r
+		case r == ' ':
+			return '-'
+		default:
+			return -1
+		}
+	}, name)
+	if safe == "" {
+		safe = "evento"
+	}
+	return fmt.Sprintf("%s.ics", strings.ToLower(safe))
+}
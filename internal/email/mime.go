@@ -0,0 +1,139 @@
+package email
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+// Attachment representa un archivo adjunto ya resuelto
+type Attachment struct {
+	Filename    string
+	ContentType string
+	Data        []byte
+}
+
+// Message representa un email completo a codificar en MIME
+type Message struct {
+	From        string
+	To          []string
+	Subject     string
+	Text        string
+	HTML        string
+	Attachments []Attachment
+}
+
+// BuildRawMessage codifica el mensaje como multipart/mixed, con el cuerpo como
+// multipart/alternative cuando hay HTML, listo para SES SendRawEmail
+func BuildRawMessage(msg Message) ([]byte, error) {
+	var buf bytes.Buffer
+	mixed := multipart.NewWriter(&buf)
+
+	fmt.Fprintf(&buf, "From: %s\r\n", msg.From)
+	fmt.Fprintf(&buf, "To: %s\r\n", strings.Join(msg.To, ", "))
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("UTF-8", msg.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&buf, "Content-Type: multipart/mixed; boundary=%q\r\n\r\n", mixed.Boundary())
+
+	if err := writeBody(mixed, msg); err != nil {
+		return nil, err
+	}
+
+	for _, attachment := range msg.Attachments {
+		if err := writeAttachment(mixed, attachment); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := mixed.Close(); err != nil {
+		return nil, fmt.Errorf("error closing MIME message: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// writeBody escribe el cuerpo de texto y, si existe, su alternativa HTML
+func writeBody(mixed *multipart.Writer, msg Message) error {
+	if msg.HTML == "" {
+		return writeTextPart(mixed, "text/plain; charset=UTF-8", msg.Text)
+	}
+
+	// El boundary se genera antes de crear la parte porque va en su cabecera
+	boundary := multipart.NewWriter(nil).Boundary()
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Type", fmt.Sprintf("multipart/alternative; boundary=%q", boundary))
+	part, err := mixed.CreatePart(header)
+	if err != nil {
+		return fmt.Errorf("error creating MIME body part: %w", err)
+	}
+
+	alternative := multipart.NewWriter(part)
+	if err := alternative.SetBoundary(boundary); err != nil {
+		return fmt.Errorf("error setting MIME boundary: %w", err)
+	}
+	if err := writeTextPart(alternative, "text/plain; charset=UTF-8", msg.Text); err != nil {
+		return err
+	}
+	if err := writeTextPart(alternative, "text/html; charset=UTF-8", msg.HTML); err != nil {
+		return err
+	}
+
+	return alternative.Close()
+}
+
+// writeTextPart escribe una parte de texto codificada en quoted-printable
+func writeTextPart(writer *multipart.Writer, contentType, text string) error {
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Type", contentType)
+	header.Set("Content-Transfer-Encoding", "quoted-printable")
+
+	part, err := writer.CreatePart(header)
+	if err != nil {
+		return fmt.Errorf("error creating MIME text part: %w", err)
+	}
+
+	encoder := quotedprintable.NewWriter(part)
+	if _, err := encoder.Write([]byte(text)); err != nil {
+		return fmt.Errorf("error encoding MIME text part: %w", err)
+	}
+	return encoder.Close()
+}
+
+// writeAttachment escribe un adjunto codificado en base64 con líneas de 76 caracteres
+func writeAttachment(writer *multipart.Writer, attachment Attachment) error {
+	contentType := attachment.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	filename := mime.QEncoding.Encode("UTF-8", attachment.Filename)
+
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Type", fmt.Sprintf("%s; name=%q", contentType, filename))
+	header.Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	header.Set("Content-Transfer-Encoding", "base64")
+
+	part, err := writer.CreatePart(header)
+	if err != nil {
+		return fmt.Errorf("error creating MIME attachment part: %w", err)
+	}
+
+	encoded := base64.StdEncoding.EncodeToString(attachment.Data)
+	for len(encoded) > 76 {
+		if _, err := fmt.Fprintf(part, "%s\r\n", encoded[:76]); err != nil {
+			return fmt.Errorf("error writing MIME attachment: %w", err)
+		}
+		encoded = encoded[76:]
+	}
+	if _, err := fmt.Fprintf(part, "%s\r\n", encoded); err != nil {
+		return fmt.Errorf("error writing MIME attachment: %w", err)
+	}
+
+	return nil
+}
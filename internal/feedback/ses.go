@@ -0,0 +1,119 @@
+package feedback
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Tipos de evento de SES que se procesan
+const (
+	SESEventBounce    = "Bounce"
+	SESEventComplaint = "Complaint"
+	SESEventDelivery  = "Delivery"
+)
+
+// SESEvent es una notificación de SES sobre un email enviado. Las notificaciones
+// de identidad usan notificationType y las de configuration sets, eventType.
+type SESEvent struct {
+	NotificationType string        `json:"notificationType"`
+	EventType        string        `json:"eventType"`
+	Mail             SESMail       `json:"mail"`
+	Bounce           *SESBounce    `json:"bounce,omitempty"`
+	Complaint        *SESComplaint `json:"complaint,omitempty"`
+	Delivery         *SESDelivery  `json:"delivery,omitempty"`
+}
+
+// SESMail identifica el mensaje original
+type SESMail struct {
+	MessageID   string   `json:"messageId"`
+	Timestamp   string   `json:"timestamp"`
+	Source      string   `json:"source"`
+	Destination []string `json:"destination"`
+}
+
+// SESBounce describe un rebote
+type SESBounce struct {
+	BounceType        string                `json:"bounceType"`
+	BounceSubType     string                `json:"bounceSubType"`
+	BouncedRecipients []SESBouncedRecipient `json:"bouncedRecipients"`
+	Timestamp         string                `json:"timestamp"`
+	FeedbackID        string                `json:"feedbackId"`
+}
+
+// SESBouncedRecipient es una dirección que rebotó
+type SESBouncedRecipient struct {
+	EmailAddress   string `json:"emailAddress"`
+	Action         string `json:"action"`
+	Status         string `json:"status"`
+	DiagnosticCode string `json:"diagnosticCode"`
+}
+
+// SESComplaint describe una queja del destinatario
+type SESComplaint struct {
+	ComplainedRecipients []struct {
+		EmailAddress string `json:"emailAddress"`
+	} `json:"complainedRecipients"`
+	ComplaintFeedbackType string `json:"complaintFeedbackType"`
+	Timestamp             string `json:"timestamp"`
+	FeedbackID            string `json:"feedbackId"`
+}
+
+// SESDelivery describe una entrega confirmada por el servidor del destinatario
+type SESDelivery struct {
+	Timestamp            string   `json:"timestamp"`
+	Recipients           []string `json:"recipients"`
+	SMTPResponse         string   `json:"smtpResponse"`
+	ProcessingTimeMillis int64    `json:"processingTimeMillis"`
+}
+
+// ParseSESEvent decodifica el mensaje de SES contenido en una notificación de SNS
+func ParseSESEvent(message string) (*SESEvent, error) {
+	var event SESEvent
+	if err := json.Unmarshal([]byte(message), &event); err != nil {
+		return nil, fmt.Errorf("invalid SES event: %w", err)
+	}
+	if event.Type() == "" || event.Mail.MessageID == "" {
+		return nil, fmt.Errorf("invalid SES event: missing type or message ID")
+	}
+	return &event, nil
+}
+
+// Type devuelve el tipo de evento (Bounce, Complaint, Delivery...)
+func (e *SESEvent) Type() string {
+	if e.NotificationType != "" {
+		return e.NotificationType
+	}
+	return e.EventType
+}
+
+// IsPermanentBounce indica si el rebote es definitivo y la dirección debe suprimirse
+func (e *SESEvent) IsPermanentBounce() bool {
+	return e.Bounce != nil && e.Bounce.BounceType == "Permanent"
+}
+
+// Reason resume el evento en un texto para el registro de la entrega
+func (e *SESEvent) Reason() string {
+	switch {
+	case e.Bounce != nil:
+		reason := fmt.Sprintf("bounce %s/%s", e.Bounce.BounceType, e.Bounce.BounceSubType)
+		var diagnostics []string
+		for _, recipient := range e.Bounce.BouncedRecipients {
+			if recipient.DiagnosticCode != "" {
+				diagnostics = append(diagnostics, recipient.DiagnosticCode)
+			}
+		}
+		if len(diagnostics) > 0 {
+			reason += ": " + strings.Join(diagnostics, "; ")
+		}
+		return reason
+	case e.Complaint != nil:
+		if e.Complaint.ComplaintFeedbackType != "" {
+			return "complaint: " + e.Complaint.ComplaintFeedbackType
+		}
+		return "complaint"
+	case e.Delivery != nil:
+		return e.Delivery.SMTPResponse
+	}
+	return e.Type()
+}
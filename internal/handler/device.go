@@ -0,0 +1,221 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jhonathanssegura/ticket-notification/internal/db"
+	"github.com/jhonathanssegura/ticket-notification/internal/model"
+)
+
+// DeviceHandler maneja el registro de dispositivos móviles para notificaciones push
+type DeviceHandler struct {
+	dbClient *db.DynamoClient
+}
+
+// NewDeviceHandler crea una nueva instancia del handler de dispositivos
+func NewDeviceHandler(dbClient *db.DynamoClient) *DeviceHandler {
+	return &DeviceHandler{
+		dbClient: dbClient,
+	}
+}
+
+// RegisterDevice registra un dispositivo del usuario. Registrar de nuevo el mismo
+// token actualiza su plataforma y versión de la aplicación.
+func (h *DeviceHandler) RegisterDevice(c *gin.Context) {
+	var req model.RegisterDeviceRequest
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Datos de dispositivo inválidos",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	device, err := h.dbClient.SaveDeviceToken(model.DeviceToken{
+		UserID:     c.Param("user_id"),
+		Token:      strings.TrimSpace(req.Token),
+		Platform:   req.Platform,
+		AppVersion: req.AppVersion,
+		UpdatedAt:  time.Now(),
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Error registrando dispositivo",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    device,
+		"message": "Dispositivo registrado exitosamente",
+	})
+}
+
+// RefreshDevice reemplaza el token de un dispositivo cuando el proveedor lo rota,
+// conservando su plataforma y versión
+func (h *DeviceHandler) RefreshDevice(c *gin.Context) {
+	userID := c.Param("user_id")
+
+	var req model.RefreshDeviceRequest
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Datos de dispositivo inválidos",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	devices, err := h.dbClient.GetDeviceTokens(userID, "")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Error obteniendo dispositivos",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	var previous *model.DeviceToken
+	for i := range devices {
+		if devices[i].Token == req.OldToken {
+			previous = &devices[i]
+			break
+		}
+	}
+	if previous == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Dispositivo no encontrado"})
+		return
+	}
+
+	device, err := h.dbClient.SaveDeviceToken(model.DeviceToken{
+		UserID:     userID,
+		Token:      strings.TrimSpace(req.Token),
+		Platform:   previous.Platform,
+		AppVersion: previous.AppVersion,
+		UpdatedAt:  time.Now(),
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Error actualizando dispositivo",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if device.Token != previous.Token {
+		if err := h.dbClient.DeleteDeviceToken(userID, previous.Token); err != nil && !errors.Is(err, db.ErrNotFound) {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "Error eliminando el token anterior",
+				"details": err.Error(),
+			})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    device,
+		"message": "Token de dispositivo actualizado exitosamente",
+	})
+}
+
+// ListDevices lista los dispositivos del usuario, filtrando opcionalmente por plataforma
+func (h *DeviceHandler) ListDevices(c *gin.Context) {
+	platform, ok := devicePlatformParam(c)
+	if !ok {
+		return
+	}
+
+	devices, err := h.dbClient.GetDeviceTokens(c.Param("user_id"), platform)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Error obteniendo dispositivos",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"devices": devices,
+			"total":   len(devices),
+		},
+	})
+}
+
+// UnregisterDevice elimina un dispositivo del usuario
+func (h *DeviceHandler) UnregisterDevice(c *gin.Context) {
+	if err := h.dbClient.DeleteDeviceToken(c.Param("user_id"), c.Param("token")); err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Dispositivo no encontrado"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Error eliminando dispositivo",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Dispositivo eliminado exitosamente",
+	})
+}
+
+// UnregisterDevices elimina todos los dispositivos del usuario o solo los de una plataforma
+func (h *DeviceHandler) UnregisterDevices(c *gin.Context) {
+	userID := c.Param("user_id")
+	platform, ok := devicePlatformParam(c)
+	if !ok {
+		return
+	}
+
+	devices, err := h.dbClient.GetDeviceTokens(userID, platform)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Error obteniendo dispositivos",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	removed := 0
+	for _, device := range devices {
+		if err := h.dbClient.DeleteDeviceToken(userID, device.Token); err != nil && !errors.Is(err, db.ErrNotFound) {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "Error eliminando dispositivos",
+				"details": err.Error(),
+				"removed": removed,
+			})
+			return
+		}
+		removed++
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"removed": removed,
+		},
+		"message": "Dispositivos eliminados exitosamente",
+	})
+}
+
+// devicePlatformParam valida el filtro opcional de plataforma
+func devicePlatformParam(c *gin.Context) (model.DevicePlatform, bool) {
+	platform := model.DevicePlatform(c.Query("platform"))
+	switch platform {
+	case "", model.DevicePlatformIOS, model.DevicePlatformAndroid:
+		return platform, true
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "El parámetro 'platform' debe ser ios o android"})
+		return "", false
+	}
+}
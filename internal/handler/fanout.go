@@ -0,0 +1,130 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jhonathanssegura/ticket-notification/internal/db"
+	"github.com/jhonathanssegura/ticket-notification/internal/model"
+)
+
+// notifyEventCancelledToAudience crea el envío masivo del aviso de cancelación a todos
+// los que tienen reserva para el evento
+func (h *NotificationHandler) notifyEventCancelledToAudience(c *gin.Context, req model.EventNotification) {
+	job, err := h.notificationService.NotifyEventCancelledToAudience(c.Request.Context(), req)
+	if err != nil {
+		respondNotifyError(c, err, "Error creando envío de cancelación de evento")
+		return
+	}
+
+	if job == nil {
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"message": "El evento no tiene asistentes con reserva",
+			"data": gin.H{
+				"event_id":   req.EventID,
+				"event_name": req.EventName,
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"success": true,
+		"message": "Envío de cancelación de evento iniciado",
+		"data":    fanoutJobResponse(job),
+	})
+}
+
+// ListEventAudience lista los destinatarios con reserva para el evento, paginando con
+// el parámetro 'after' (último destinatario de la página anterior)
+func (h *NotificationHandler) ListEventAudience(c *gin.Context) {
+	limit := 100
+	if limitStr := c.Query("limit"); limitStr != "" {
+		value, err := strconv.Atoi(limitStr)
+		if err != nil || value < 1 || value > 1000 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "El parámetro 'limit' debe ser un número entre 1 y 1000"})
+			return
+		}
+		limit = value
+	}
+
+	members, err := h.dbClient.GetEventAudience(c.Param("id"), c.Query("after"), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Error obteniendo asistentes del evento",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	data := gin.H{
+		"event_id": c.Param("id"),
+		"members":  members,
+		"total":    len(members),
+	}
+	if len(members) == limit {
+		data["next"] = members[len(members)-1].Recipient
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    data,
+	})
+}
+
+// GetFanoutJob muestra el avance de un envío masivo
+func (h *NotificationHandler) GetFanoutJob(c *gin.Context) {
+	job, err := h.dbClient.GetFanoutJob(c.Param("id"))
+	if err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Envío masivo no encontrado"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Error obteniendo envío masivo",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    fanoutJobResponse(job),
+	})
+}
+
+// ResumeFanoutJob reanuda un envío masivo fallido desde el último lote encolado
+func (h *NotificationHandler) ResumeFanoutJob(c *gin.Context) {
+	job, err := h.notificationService.ResumeFanoutJob(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		switch {
+		case errors.Is(err, db.ErrNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Envío masivo no encontrado"})
+		case errors.Is(err, db.ErrFanoutJobNotFailed):
+			c.JSON(http.StatusConflict, gin.H{"error": "Solo se pueden reanudar envíos masivos fallidos"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "Error reanudando envío masivo",
+				"details": err.Error(),
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"success": true,
+		"data":    fanoutJobResponse(job),
+		"message": "Envío masivo reanudado",
+	})
+}
+
+// fanoutJobResponse agrega al envío masivo su porcentaje de avance
+func fanoutJobResponse(job *model.FanoutJob) gin.H {
+	return gin.H{
+		"job":      job,
+		"progress": job.Progress(),
+	}
+}
@@ -0,0 +1,134 @@
+package handler
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jhonathanssegura/ticket-notification/internal/db"
+	"github.com/jhonathanssegura/ticket-notification/internal/model"
+	"github.com/jhonathanssegura/ticket-notification/internal/service"
+)
+
+// IdempotencyHeader es el header con el que el cliente identifica un envío para poder
+// reintentarlo sin duplicarlo
+const IdempotencyHeader = "Idempotency-Key"
+
+// maxIdempotencyKeyLength es el largo máximo aceptado para la clave
+const maxIdempotencyKeyLength = 255
+
+// idempotencyRequestLock es el tiempo que una solicitud retiene su clave; si la
+// instancia se cae antes de responder, pasado ese tiempo la clave se puede reutilizar
+const idempotencyRequestLock = time.Minute
+
+// Idempotency devuelve un middleware que hace idempotentes los envíos con el header
+// Idempotency-Key: la primera solicitud con una clave se procesa y su respuesta se
+// guarda durante ttl; las repeticiones reciben esa misma respuesta sin volver a enviar.
+// La clave se pasa al servicio para que los mensajes encolados la lleven y el
+// consumidor descarte los duplicados.
+func Idempotency(dbClient *db.DynamoClient, ttl time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+
+		if len(key) > maxIdempotencyKeyLength {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+				"error":   "Clave de idempotencia inválida",
+				"details": "Idempotency-Key admite hasta 255 caracteres",
+			})
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+				"error":   "Error leyendo la solicitud",
+				"details": err.Error(),
+			})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		hash := sha256.Sum256(body)
+		requestHash := hex.EncodeToString(hash[:])
+
+		// La misma clave en otra ruta es otro envío
+		scopedKey := c.Request.Method + " " + c.Request.URL.Path + " " + key
+
+		now := time.Now()
+		existing, claimed, err := dbClient.ClaimIdempotencyKey(model.IdempotencyRecord{
+			Key:         scopedKey,
+			RequestHash: requestHash,
+			LockedUntil: now.Add(idempotencyRequestLock),
+			CreatedAt:   now,
+			ExpiresAt:   now.Add(ttl),
+		}, now)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+				"error":   "Error verificando la clave de idempotencia",
+				"details": err.Error(),
+			})
+			return
+		}
+
+		if !claimed {
+			switch {
+			case existing.RequestHash != requestHash:
+				c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{
+					"error":   "La clave de idempotencia ya se usó con otra solicitud",
+					"details": "Use una clave nueva para cada envío distinto",
+				})
+			case existing.Status == model.IdempotencyStatusCompleted:
+				c.Header("Idempotent-Replayed", "true")
+				c.Data(existing.ResponseStatus, "application/json; charset=utf-8", []byte(existing.ResponseBody))
+				c.Abort()
+			default:
+				c.AbortWithStatusJSON(http.StatusConflict, gin.H{
+					"error":   "Hay una solicitud con la misma clave de idempotencia en curso",
+					"details": "Reintente cuando termine",
+				})
+			}
+			return
+		}
+
+		c.Request = c.Request.WithContext(service.WithIdempotencyKey(c.Request.Context(), scopedKey))
+		writer := &capturingResponseWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+
+		c.Next()
+
+		// Los errores del servidor no se guardan para que el cliente pueda reintentar
+		if c.Writer.Status() >= http.StatusInternalServerError {
+			if err := dbClient.ReleaseIdempotencyKey(scopedKey); err != nil {
+				log.Printf("Error liberando clave de idempotencia %s: %v", key, err)
+			}
+			return
+		}
+		if err := dbClient.CompleteIdempotencyKey(scopedKey, c.Writer.Status(), writer.body.String()); err != nil {
+			log.Printf("Error guardando respuesta de la clave de idempotencia %s: %v", key, err)
+		}
+	}
+}
+
+// capturingResponseWriter copia el cuerpo de la respuesta mientras se escribe
+type capturingResponseWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *capturingResponseWriter) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *capturingResponseWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,201 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jhonathanssegura/ticket-notification/internal/db"
+	"github.com/jhonathanssegura/ticket-notification/internal/model"
+)
+
+// maxMarkReadBatch limita cuántas notificaciones se marcan como leídas en una petición
+const maxMarkReadBatch = 100
+
+// InboxHandler maneja la bandeja de notificaciones in-app de cada usuario
+type InboxHandler struct {
+	dbClient *db.DynamoClient
+}
+
+// NewInboxHandler crea una nueva instancia del handler de la bandeja de entrada
+func NewInboxHandler(dbClient *db.DynamoClient) *InboxHandler {
+	return &InboxHandler{
+		dbClient: dbClient,
+	}
+}
+
+// ListInbox lista las notificaciones del usuario con paginación por cursor
+func (h *InboxHandler) ListInbox(c *gin.Context) {
+	userID := c.Param("user_id")
+
+	var read *bool
+	switch status := c.DefaultQuery("status", "all"); status {
+	case "all":
+	case "read", "unread":
+		value := status == "read"
+		read = &value
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "El parámetro 'status' debe ser all, read o unread"})
+		return
+	}
+
+	limit := 20
+	if limitStr := c.Query("limit"); limitStr != "" {
+		value, err := strconv.Atoi(limitStr)
+		if err != nil || value < 1 || value > 100 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "El parámetro 'limit' debe ser un número entre 1 y 100"})
+			return
+		}
+		limit = value
+	}
+
+	notifications, nextCursor, err := h.dbClient.GetInboxNotifications(userID, read, limit, c.Query("cursor"))
+	if err != nil {
+		if errors.Is(err, db.ErrInvalidCursor) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Cursor de paginación inválido"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Error obteniendo bandeja de entrada",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"notifications": notifications,
+			"count":         len(notifications),
+			"next_cursor":   nextCursor,
+			"has_more":      nextCursor != "",
+		},
+	})
+}
+
+// GetUnreadCount devuelve cuántas notificaciones no leídas tiene el usuario
+func (h *InboxHandler) GetUnreadCount(c *gin.Context) {
+	userID := c.Param("user_id")
+
+	count, err := h.dbClient.CountUnreadNotifications(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Error contando notificaciones no leídas",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"user_id": userID,
+			"unread":  count,
+		},
+	})
+}
+
+// MarkRead marca como leída una notificación del usuario
+func (h *InboxHandler) MarkRead(c *gin.Context) {
+	userID := c.Param("user_id")
+
+	notification, err := h.dbClient.MarkNotificationRead(userID, c.Param("id"), time.Now())
+	if err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Notificación no encontrada"})
+			return
+		}
+		if errors.Is(err, db.ErrNotDelivered) {
+			c.JSON(http.StatusConflict, gin.H{"error": "La notificación todavía no se envió y no se puede marcar como leída"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Error marcando notificación como leída",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    notification,
+		"message": "Notificación marcada como leída",
+	})
+}
+
+// MarkManyRead marca como leídas varias notificaciones del usuario
+func (h *InboxHandler) MarkManyRead(c *gin.Context) {
+	userID := c.Param("user_id")
+
+	var req model.MarkReadRequest
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Datos inválidos",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if len(req.NotificationIDs) == 0 || len(req.NotificationIDs) > maxMarkReadBatch {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Debe especificar entre 1 y " + strconv.Itoa(maxMarkReadBatch) + " notificaciones",
+		})
+		return
+	}
+
+	now := time.Now()
+	var updated, notFound, notDelivered []string
+	for _, notificationID := range req.NotificationIDs {
+		if _, err := h.dbClient.MarkNotificationRead(userID, notificationID, now); err != nil {
+			if errors.Is(err, db.ErrNotFound) {
+				notFound = append(notFound, notificationID)
+				continue
+			}
+			if errors.Is(err, db.ErrNotDelivered) {
+				notDelivered = append(notDelivered, notificationID)
+				continue
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "Error marcando notificaciones como leídas",
+				"details": err.Error(),
+			})
+			return
+		}
+		updated = append(updated, notificationID)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"updated":       updated,
+			"not_found":     notFound,
+			"not_delivered": notDelivered,
+		},
+		"message": "Notificaciones marcadas como leídas",
+	})
+}
+
+// MarkAllRead marca como leídas todas las notificaciones del usuario
+func (h *InboxHandler) MarkAllRead(c *gin.Context) {
+	userID := c.Param("user_id")
+
+	updated, err := h.dbClient.MarkAllNotificationsRead(userID, time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Error marcando notificaciones como leídas",
+			"details": err.Error(),
+			"updated": updated,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"updated": updated,
+		},
+		"message": "Todas las notificaciones marcadas como leídas",
+	})
+}
@@ -1,12 +1,12 @@
 package handler
 
 import (
-	"log"
+	"errors"
 	"net/http"
 	"strconv"
-	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/jhonathanssegura/ticket-notification/internal/channel"
 	"github.com/jhonathanssegura/ticket-notification/internal/db"
 	"github.com/jhonathanssegura/ticket-notification/internal/model"
 	"github.com/jhonathanssegura/ticket-notification/internal/service"
@@ -38,10 +38,10 @@ func (h *NotificationHandler) SendNotification(c *gin.Context) {
 		return
 	}
 
-	// Validar campos requeridos
-	if req.Recipient == "" || req.Subject == "" || req.Content == "" {
+	// Validar campos requeridos; subject y content pueden venir de la plantilla
+	if (req.Recipient == "" && len(req.Channels) == 0) || (req.TemplateID == "" && (req.Subject == "" || (req.Content == "" && req.HTMLContent == ""))) {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Recipient, subject y content son campos requeridos",
+			"error": "Recipient o channels son requeridos, junto con template_id o subject y content/html_content",
 		})
 		return
 	}
@@ -49,23 +49,20 @@ func (h *NotificationHandler) SendNotification(c *gin.Context) {
 	// Enviar notificación
 	notification, err := h.notificationService.SendNotification(c.Request.Context(), req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Error enviando notificación",
-			"details": err.Error(),
-		})
+		respondNotifyError(c, err, "Error enviando notificación")
 		return
 	}
 
-	// Guardar en base de datos
-	if err := h.dbClient.SaveNotification(*notification); err != nil {
-		log.Printf("Error guardando notificación en DB: %v", err)
-		// No fallar la request si solo falla el guardado en DB
+	// La notificación ya quedó guardada; se entrega en segundo plano
+	message := "Notificación aceptada, se enviará en segundo plano"
+	if notification.Status == model.NotificationStatusScheduled {
+		message = "Notificación programada exitosamente"
 	}
 
-	c.JSON(http.StatusCreated, gin.H{
+	c.JSON(http.StatusAccepted, gin.H{
 		"success": true,
 		"data":    notification,
-		"message": "Notificación enviada exitosamente",
+		"message": message,
 	})
 }
 
@@ -91,28 +88,27 @@ func (h *NotificationHandler) SendBulkNotifications(c *gin.Context) {
 	// Enviar notificaciones en lote
 	notifications, err := h.notificationService.SendBulkNotifications(c.Request.Context(), req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Error enviando notificaciones en lote",
-			"details": err.Error(),
-		})
+		respondNotifyError(c, err, "Error enviando notificaciones en lote")
 		return
 	}
 
-	// Guardar en base de datos
+	// Las notificaciones ya quedaron guardadas; se entregan en segundo plano
+	scheduled := 0
 	for _, notification := range notifications {
-		if err := h.dbClient.SaveNotification(*notification); err != nil {
-			log.Printf("Error guardando notificación %s en DB: %v", notification.ID, err)
+		if notification.Status == model.NotificationStatusScheduled {
+			scheduled++
 		}
 	}
 
-	c.JSON(http.StatusCreated, gin.H{
+	c.JSON(http.StatusAccepted, gin.H{
 		"success": true,
 		"data": gin.H{
 			"notifications":   notifications,
-			"total_sent":      len(notifications),
+			"total_accepted":  len(notifications) - scheduled,
+			"total_scheduled": scheduled,
 			"total_requested": len(req.Notifications),
 		},
-		"message": "Notificaciones en lote enviadas exitosamente",
+		"message": "Notificaciones en lote aceptadas, se enviarán en segundo plano",
 	})
 }
 
@@ -126,7 +122,7 @@ func (h *NotificationHandler) GetNotification(c *gin.Context) {
 
 	notification, err := h.dbClient.GetNotificationByID(notificationID)
 	if err != nil {
-		if strings.Contains(err.Error(), "not found") {
+		if errors.Is(err, db.ErrNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Notificación no encontrada"})
 			return
 		}
@@ -277,10 +273,7 @@ func (h *NotificationHandler) NotifyEventCreated(c *gin.Context) {
 
 	// Enviar notificación
 	if err := h.notificationService.NotifyEventCreated(c.Request.Context(), req); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Error enviando notificación de evento",
-			"details": err.Error(),
-		})
+		respondNotifyError(c, err, "Error enviando notificación de evento")
 		return
 	}
 
@@ -318,10 +311,7 @@ func (h *NotificationHandler) SendEventReminder(c *gin.Context) {
 
 	// Enviar recordatorio
 	if err := h.notificationService.SendEventReminder(c.Request.Context(), req); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Error enviando recordatorio de evento",
-			"details": err.Error(),
-		})
+		respondNotifyError(c, err, "Error enviando recordatorio de evento")
 		return
 	}
 
@@ -336,7 +326,8 @@ func (h *NotificationHandler) SendEventReminder(c *gin.Context) {
 	})
 }
 
-// NotifyEventCancelled notifica cuando se cancela un evento
+// NotifyEventCancelled notifica cuando se cancela un evento. Sin recipient se avisa a
+// toda la audiencia del evento con un envío masivo.
 func (h *NotificationHandler) NotifyEventCancelled(c *gin.Context) {
 	var req model.EventNotification
 
@@ -349,19 +340,21 @@ func (h *NotificationHandler) NotifyEventCancelled(c *gin.Context) {
 	}
 
 	// Validar campos requeridos
-	if req.EventID == "" || req.EventName == "" || req.Recipient == "" {
+	if req.EventID == "" || req.EventName == "" {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "EventID, EventName y Recipient son campos requeridos",
+			"error": "EventID y EventName son campos requeridos",
 		})
 		return
 	}
 
+	if req.Recipient == "" {
+		h.notifyEventCancelledToAudience(c, req)
+		return
+	}
+
 	// Enviar notificación de cancelación
 	if err := h.notificationService.NotifyEventCancelled(c.Request.Context(), req); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Error enviando notificación de cancelación de evento",
-			"details": err.Error(),
-		})
+		respondNotifyError(c, err, "Error enviando notificación de cancelación de evento")
 		return
 	}
 
@@ -376,6 +369,41 @@ func (h *NotificationHandler) NotifyEventCancelled(c *gin.Context) {
 	})
 }
 
+// NotifyEventUpdated notifica a los asistentes un cambio de fecha o lugar del evento
+// y mueve sus recordatorios programados
+func (h *NotificationHandler) NotifyEventUpdated(c *gin.Context) {
+	var req model.EventUpdateNotification
+
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Datos de actualización de evento inválidos",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	result, err := h.notificationService.NotifyEventUpdated(c.Request.Context(), c.Param("id"), req)
+	if err != nil {
+		status, message := notifyError(err, "Error notificando actualización de evento")
+		response := gin.H{
+			"error":   message,
+			"details": err.Error(),
+		}
+		// Si falló a mitad, se informa lo que llegó a hacerse
+		if status == http.StatusInternalServerError {
+			response["data"] = result
+		}
+		c.JSON(status, response)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Actualización de evento notificada exitosamente",
+		"data":    result,
+	})
+}
+
 // NotifyReservationCreated notifica cuando se crea una reserva
 func (h *NotificationHandler) NotifyReservationCreated(c *gin.Context) {
 	var req model.ReservationNotification
@@ -398,10 +426,7 @@ func (h *NotificationHandler) NotifyReservationCreated(c *gin.Context) {
 
 	// Enviar notificación
 	if err := h.notificationService.NotifyReservationCreated(c.Request.Context(), req); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Error enviando notificación de reserva",
-			"details": err.Error(),
-		})
+		respondNotifyError(c, err, "Error enviando notificación de reserva")
 		return
 	}
 
@@ -439,10 +464,7 @@ func (h *NotificationHandler) NotifyReservationConfirmed(c *gin.Context) {
 
 	// Enviar notificación
 	if err := h.notificationService.NotifyReservationConfirmed(c.Request.Context(), req); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Error enviando notificación de confirmación de reserva",
-			"details": err.Error(),
-		})
+		respondNotifyError(c, err, "Error enviando notificación de confirmación de reserva")
 		return
 	}
 
@@ -480,10 +502,7 @@ func (h *NotificationHandler) NotifyReservationCancelled(c *gin.Context) {
 
 	// Enviar notificación
 	if err := h.notificationService.NotifyReservationCancelled(c.Request.Context(), req); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Error enviando notificación de cancelación de reserva",
-			"details": err.Error(),
-		})
+		respondNotifyError(c, err, "Error enviando notificación de cancelación de reserva")
 		return
 	}
 
@@ -499,3 +518,42 @@ func (h *NotificationHandler) NotifyReservationCancelled(c *gin.Context) {
 	})
 }
 
+// notifyError devuelve el estado HTTP y el mensaje con que se responde el error de un
+// envío: 400 si la solicitud no se puede enviar tal como viene y 500 con message si no
+func notifyError(err error, message string) (int, string) {
+	switch {
+	case errors.Is(err, service.ErrInvalidSendAt):
+		return http.StatusBadRequest, "Hora de envío inválida"
+	case errors.Is(err, service.ErrMissingTemplateVariable):
+		return http.StatusBadRequest, "Faltan datos para renderizar la plantilla"
+	case errors.Is(err, service.ErrTemplateInactive):
+		return http.StatusBadRequest, "La plantilla está desactivada"
+	case errors.Is(err, service.ErrInvalidLocale):
+		return http.StatusBadRequest, "Idioma o zona horaria inválidos"
+	case errors.Is(err, service.ErrResetLinkExpired):
+		return http.StatusBadRequest, "El enlace ya venció"
+	case errors.Is(err, service.ErrAttachmentTooLarge):
+		return http.StatusBadRequest, "Los adjuntos en base64 son demasiado grandes; indíquelos por url"
+	case errors.Is(err, service.ErrNoEventChanges):
+		return http.StatusBadRequest, "La fecha y el lugar del evento no cambiaron"
+	case isChannelError(err):
+		return http.StatusBadRequest, "Canal de entrega inválido"
+	default:
+		return http.StatusInternalServerError, message
+	}
+}
+
+// respondNotifyError responde el error de un envío con el estado que le corresponde
+func respondNotifyError(c *gin.Context, err error, message string) {
+	status, text := notifyError(err, message)
+	c.JSON(status, gin.H{
+		"error":   text,
+		"details": err.Error(),
+	})
+}
+
+// isChannelError indica si el error se debe a un canal desconocido o a una dirección inválida
+func isChannelError(err error) bool {
+	return errors.Is(err, channel.ErrUnknownChannel) || errors.Is(err, channel.ErrInvalidRecipient)
+}
+
@@ -0,0 +1,179 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jhonathanssegura/ticket-notification/internal/db"
+	"github.com/jhonathanssegura/ticket-notification/internal/i18n"
+	"github.com/jhonathanssegura/ticket-notification/internal/model"
+)
+
+// PreferencesHandler maneja las preferencias de notificación de cada usuario
+type PreferencesHandler struct {
+	dbClient *db.DynamoClient
+}
+
+// NewPreferencesHandler crea una nueva instancia del handler de preferencias
+func NewPreferencesHandler(dbClient *db.DynamoClient) *PreferencesHandler {
+	return &PreferencesHandler{
+		dbClient: dbClient,
+	}
+}
+
+// GetPreferences obtiene las preferencias del usuario; si nunca las configuró
+// devuelve las preferencias por defecto (todo habilitado)
+func (h *PreferencesHandler) GetPreferences(c *gin.Context) {
+	preferences, ok := h.loadPreferences(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    preferences,
+	})
+}
+
+// UpdatePreferences actualiza las preferencias del usuario. Los tipos y canales
+// indicados se combinan con los ya guardados.
+func (h *PreferencesHandler) UpdatePreferences(c *gin.Context) {
+	var req model.UpdatePreferencesRequest
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Datos de preferencias inválidos",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if message := validatePreferences(req); message != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": message})
+		return
+	}
+
+	preferences, ok := h.loadPreferences(c)
+	if !ok {
+		return
+	}
+
+	if req.Language != nil {
+		// Se guarda en forma canónica ("pt_br" → "pt-BR") para elegir la traducción de las plantillas
+		preferences.Language = i18n.Normalize(*req.Language)
+	}
+	if req.Timezone != nil {
+		preferences.Timezone = *req.Timezone
+	}
+	if req.ClearQuietHours {
+		preferences.QuietHours = nil
+	} else if req.QuietHours != nil {
+		preferences.QuietHours = req.QuietHours
+	}
+	for notificationType, enabled := range req.Types {
+		if preferences.Types == nil {
+			preferences.Types = make(map[model.NotificationType]bool)
+		}
+		preferences.Types[notificationType] = enabled
+	}
+	for channelType, enabled := range req.Channels {
+		if preferences.Channels == nil {
+			preferences.Channels = make(map[model.ChannelType]bool)
+		}
+		preferences.Channels[channelType] = enabled
+	}
+	preferences.UpdatedAt = time.Now()
+
+	if err := h.dbClient.SaveUserPreferences(*preferences); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Error guardando preferencias",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    preferences,
+		"message": "Preferencias actualizadas exitosamente",
+	})
+}
+
+// ResetPreferences elimina las preferencias del usuario, que vuelve a recibir todas las notificaciones
+func (h *PreferencesHandler) ResetPreferences(c *gin.Context) {
+	if err := h.dbClient.DeleteUserPreferences(c.Param("user_id")); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Error eliminando preferencias",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Preferencias restablecidas a los valores por defecto",
+	})
+}
+
+// loadPreferences obtiene las preferencias del usuario de la URL, o las de por defecto si no tiene
+func (h *PreferencesHandler) loadPreferences(c *gin.Context) (*model.UserPreferences, bool) {
+	userID := c.Param("user_id")
+
+	preferences, err := h.dbClient.GetUserPreferences(userID)
+	if err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			return &model.UserPreferences{Recipient: userID}, true
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Error obteniendo preferencias",
+			"details": err.Error(),
+		})
+		return nil, false
+	}
+
+	return preferences, true
+}
+
+// validatePreferences valida la solicitud y devuelve el mensaje de error, vacío si es válida
+func validatePreferences(req model.UpdatePreferencesRequest) string {
+	if req.Language != nil && *req.Language != "" && i18n.Normalize(*req.Language) == "" {
+		return "El idioma debe ser una etiqueta como 'es' o 'pt-BR'"
+	}
+
+	if req.Timezone != nil && *req.Timezone != "" {
+		if _, err := time.LoadLocation(*req.Timezone); err != nil {
+			return "Zona horaria desconocida: " + *req.Timezone
+		}
+	}
+
+	if req.QuietHours != nil {
+		start, err := model.ParseClock(req.QuietHours.Start)
+		if err != nil {
+			return "Las horas de silencio deben tener el formato HH:MM"
+		}
+		end, err := model.ParseClock(req.QuietHours.End)
+		if err != nil {
+			return "Las horas de silencio deben tener el formato HH:MM"
+		}
+		if start == end {
+			return "El inicio y el fin de las horas de silencio deben ser distintos"
+		}
+	}
+
+	for notificationType, enabled := range req.Types {
+		if !enabled && notificationType.IsTransactional() {
+			return "Las notificaciones de tipo " + string(notificationType) + " son transaccionales y no se pueden desactivar"
+		}
+	}
+
+	for channelType := range req.Channels {
+		switch channelType {
+		case model.ChannelEmail, model.ChannelSMS, model.ChannelPush:
+		default:
+			return "Solo se pueden configurar los canales email, sms y push"
+		}
+	}
+
+	return ""
+}
@@ -1,8 +1,10 @@
 package handler
 
 import (
+	"errors"
 	"log"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -70,24 +72,30 @@ func (h *QueueHandler) ProcessNotificationQueue(c *gin.Context) {
 // GetQueueStatus obtiene el estado de todas las colas
 func (h *QueueHandler) GetQueueStatus(c *gin.Context) {
 	// Obtener estado de la cola de eventos
-	eventQueueStatus, err := h.notificationService.GetEventQueueStatus(c.Request.Context())
-	if err != nil {
+	var eventQueueStatus interface{}
+	if result, err := h.notificationService.GetEventQueueStatus(c.Request.Context()); err != nil {
 		log.Printf("Error obteniendo estado de cola de eventos: %v", err)
 		eventQueueStatus = gin.H{"error": err.Error()}
+	} else {
+		eventQueueStatus = result
 	}
 
 	// Obtener estado de la cola de reservas
-	reservationQueueStatus, err := h.notificationService.GetReservationQueueStatus(c.Request.Context())
-	if err != nil {
+	var reservationQueueStatus interface{}
+	if result, err := h.notificationService.GetReservationQueueStatus(c.Request.Context()); err != nil {
 		log.Printf("Error obteniendo estado de cola de reservas: %v", err)
 		reservationQueueStatus = gin.H{"error": err.Error()}
+	} else {
+		reservationQueueStatus = result
 	}
 
 	// Obtener estado de la cola de recordatorios
-	reminderQueueStatus, err := h.notificationService.GetReminderQueueStatus(c.Request.Context())
-	if err != nil {
+	var reminderQueueStatus interface{}
+	if result, err := h.notificationService.GetReminderQueueStatus(c.Request.Context()); err != nil {
 		log.Printf("Error obteniendo estado de cola de recordatorios: %v", err)
 		reminderQueueStatus = gin.H{"error": err.Error()}
+	} else {
+		reminderQueueStatus = result
 	}
 
 	c.JSON(http.StatusOK, gin.H{
@@ -137,6 +145,13 @@ func (h *QueueHandler) PurgeQueue(c *gin.Context) {
 
 	// Purgar la cola
 	if err := h.notificationService.PurgeQueue(c.Request.Context(), queueType); err != nil {
+		if errors.Is(err, service.ErrPurgeInProgress) {
+			c.JSON(http.StatusConflict, gin.H{
+				"error":   "La cola ya se purgó hace menos de 60 segundos",
+				"details": err.Error(),
+			})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "Error purgando cola",
 			"details": err.Error(),
@@ -157,24 +172,30 @@ func (h *QueueHandler) PurgeQueue(c *gin.Context) {
 // GetQueueMetrics obtiene métricas detalladas de las colas
 func (h *QueueHandler) GetQueueMetrics(c *gin.Context) {
 	// Obtener métricas de la cola de eventos
-	eventMetrics, err := h.notificationService.GetEventQueueMetrics(c.Request.Context())
-	if err != nil {
+	var eventMetrics interface{}
+	if result, err := h.notificationService.GetEventQueueMetrics(c.Request.Context()); err != nil {
 		log.Printf("Error obteniendo métricas de cola de eventos: %v", err)
 		eventMetrics = gin.H{"error": err.Error()}
+	} else {
+		eventMetrics = result
 	}
 
 	// Obtener métricas de la cola de reservas
-	reservationMetrics, err := h.notificationService.GetReservationQueueMetrics(c.Request.Context())
-	if err != nil {
+	var reservationMetrics interface{}
+	if result, err := h.notificationService.GetReservationQueueMetrics(c.Request.Context()); err != nil {
 		log.Printf("Error obteniendo métricas de cola de reservas: %v", err)
 		reservationMetrics = gin.H{"error": err.Error()}
+	} else {
+		reservationMetrics = result
 	}
 
 	// Obtener métricas de la cola de recordatorios
-	reminderMetrics, err := h.notificationService.GetReminderQueueMetrics(c.Request.Context())
-	if err != nil {
+	var reminderMetrics interface{}
+	if result, err := h.notificationService.GetReminderQueueMetrics(c.Request.Context()); err != nil {
 		log.Printf("Error obteniendo métricas de cola de recordatorios: %v", err)
 		reminderMetrics = gin.H{"error": err.Error()}
+	} else {
+		reminderMetrics = result
 	}
 
 	c.JSON(http.StatusOK, gin.H{
@@ -188,7 +209,8 @@ func (h *QueueHandler) GetQueueMetrics(c *gin.Context) {
 	})
 }
 
-// RetryFailedNotifications reintenta notificaciones fallidas
+// RetryFailedNotifications reintenta notificaciones fallidas devolviendo a su cola
+// todos los mensajes de la cola de mensajes fallidos
 func (h *QueueHandler) RetryFailedNotifications(c *gin.Context) {
 	queueType := c.Query("type")
 	if queueType == "" {
@@ -215,10 +237,7 @@ func (h *QueueHandler) RetryFailedNotifications(c *gin.Context) {
 	// Reintentar notificaciones fallidas
 	retryCount, err := h.notificationService.RetryFailedNotifications(c.Request.Context(), queueType)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Error reintentando notificaciones fallidas",
-			"details": err.Error(),
-		})
+		h.respondDeadLetterError(c, err, "Error reintentando notificaciones fallidas")
 		return
 	}
 
@@ -233,3 +252,123 @@ func (h *QueueHandler) RetryFailedNotifications(c *gin.Context) {
 	})
 }
 
+
+// ListDeadLetters muestra los mensajes de la cola de mensajes fallidos de una cola
+func (h *QueueHandler) ListDeadLetters(c *gin.Context) {
+	queueType, ok := h.deadLetterQueueType(c)
+	if !ok {
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil || limit < 1 || limit > 10 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "El parámetro 'limit' debe ser un número entre 1 y 10",
+		})
+		return
+	}
+
+	messages, err := h.notificationService.ListDeadLetters(c.Request.Context(), queueType, limit)
+	if err != nil {
+		h.respondDeadLetterError(c, err, "Error obteniendo mensajes fallidos")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"queue_type": queueType,
+			"messages":   messages,
+			"count":      len(messages),
+		},
+	})
+}
+
+// RedriveDeadLetters devuelve a su cola los mensajes fallidos indicados con
+// ?message_id=, o todos si no se indica ninguno
+func (h *QueueHandler) RedriveDeadLetters(c *gin.Context) {
+	queueType, ok := h.deadLetterQueueType(c)
+	if !ok {
+		return
+	}
+
+	messageIDs := c.QueryArray("message_id")
+	redriven, err := h.notificationService.RedriveDeadLetters(c.Request.Context(), queueType, messageIDs)
+	if err != nil {
+		h.respondDeadLetterError(c, err, "Error reenviando mensajes fallidos")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Mensajes fallidos reenviados a su cola",
+		"data": gin.H{
+			"queue_type":     queueType,
+			"redriven_count": redriven,
+			"redriven_at":    time.Now().Format(time.RFC3339),
+		},
+	})
+}
+
+// DiscardDeadLetters elimina los mensajes fallidos indicados con ?message_id=; para
+// eliminarlos todos hay que confirmar con ?confirm=true
+func (h *QueueHandler) DiscardDeadLetters(c *gin.Context) {
+	queueType, ok := h.deadLetterQueueType(c)
+	if !ok {
+		return
+	}
+
+	messageIDs := c.QueryArray("message_id")
+	if len(messageIDs) == 0 && c.Query("confirm") != "true" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Indique los mensajes con ?message_id= o confirme la acción agregando ?confirm=true",
+			"warning": "Sin message_id se eliminarán TODOS los mensajes fallidos de la cola de forma permanente",
+		})
+		return
+	}
+
+	discarded, err := h.notificationService.DiscardDeadLetters(c.Request.Context(), queueType, messageIDs)
+	if err != nil {
+		h.respondDeadLetterError(c, err, "Error descartando mensajes fallidos")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Mensajes fallidos descartados",
+		"data": gin.H{
+			"queue_type":      queueType,
+			"discarded_count": discarded,
+			"discarded_at":    time.Now().Format(time.RFC3339),
+		},
+	})
+}
+
+// deadLetterQueueType valida el tipo de cola de la ruta
+func (h *QueueHandler) deadLetterQueueType(c *gin.Context) (string, bool) {
+	queueType := c.Param("type")
+	switch queueType {
+	case "events", "reservations", "reminders":
+		return queueType, true
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Tipo de cola inválido. Debe ser: events, reservations, o reminders",
+		})
+		return "", false
+	}
+}
+
+// respondDeadLetterError responde el error de una operación sobre la cola de mensajes fallidos
+func (h *QueueHandler) respondDeadLetterError(c *gin.Context, err error, message string) {
+	if errors.Is(err, service.ErrNoDeadLetterQueue) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "La cola no tiene cola de mensajes fallidos configurada",
+			"details": err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{
+		"error":   message,
+		"details": err.Error(),
+	})
+}
@@ -0,0 +1,146 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jhonathanssegura/ticket-notification/internal/db"
+	"github.com/jhonathanssegura/ticket-notification/internal/model"
+	"github.com/jhonathanssegura/ticket-notification/internal/service"
+)
+
+// ReminderHandler maneja los recordatorios programados de los eventos
+type ReminderHandler struct {
+	notificationService *service.NotificationService
+	dbClient            *db.DynamoClient
+}
+
+// NewReminderHandler crea una nueva instancia del handler de recordatorios
+func NewReminderHandler(notificationService *service.NotificationService, dbClient *db.DynamoClient) *ReminderHandler {
+	return &ReminderHandler{
+		notificationService: notificationService,
+		dbClient:            dbClient,
+	}
+}
+
+// ScheduleReminders programa los recordatorios del evento para los asistentes indicados
+func (h *ReminderHandler) ScheduleReminders(c *gin.Context) {
+	var req model.ScheduleRemindersRequest
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Datos de recordatorios inválidos",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	eventID := c.Param("id")
+	reminders, err := h.notificationService.ScheduleEventReminders(c.Request.Context(), eventID, req)
+	if err != nil {
+		if isChannelError(err) || errors.Is(err, service.ErrInvalidReminderType) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Datos de recordatorios inválidos",
+				"details": err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Error programando recordatorios",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data": gin.H{
+			"event_id":  eventID,
+			"reminders": reminders,
+			"total":     len(reminders),
+		},
+		"message": "Recordatorios programados exitosamente",
+	})
+}
+
+// ListReminders lista los recordatorios del evento, filtrando opcionalmente por estado y destinatario
+func (h *ReminderHandler) ListReminders(c *gin.Context) {
+	reminders, err := h.dbClient.GetEventReminders(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Error obteniendo recordatorios",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	status := model.ReminderStatus(c.Query("status"))
+	recipient := c.Query("recipient")
+	filtered := make([]model.ScheduledReminder, 0, len(reminders))
+	for _, reminder := range reminders {
+		if (status == "" || reminder.Status == status) && (recipient == "" || reminder.Recipient == recipient) {
+			filtered = append(filtered, reminder)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"reminders": filtered,
+			"total":     len(filtered),
+		},
+	})
+}
+
+// RescheduleReminders mueve los recordatorios del evento a una nueva fecha
+func (h *ReminderHandler) RescheduleReminders(c *gin.Context) {
+	var req model.RescheduleRemindersRequest
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Datos de reprogramación inválidos",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	moved, err := h.notificationService.RescheduleEventReminders(c.Request.Context(), c.Param("id"), req.EventDate, req.Location)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Error reprogramando recordatorios",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"event_id":   c.Param("id"),
+			"event_date": req.EventDate,
+			"moved":      moved,
+		},
+		"message": "Recordatorios reprogramados exitosamente",
+	})
+}
+
+// CancelReminders cancela los recordatorios pendientes del evento, o solo los del
+// destinatario indicado en ?recipient=
+func (h *ReminderHandler) CancelReminders(c *gin.Context) {
+	cancelled, err := h.notificationService.CancelEventReminders(c.Request.Context(), c.Param("id"), c.Query("recipient"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Error cancelando recordatorios",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"event_id":  c.Param("id"),
+			"cancelled": cancelled,
+		},
+		"message": "Recordatorios cancelados exitosamente",
+	})
+}
@@ -0,0 +1,102 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jhonathanssegura/ticket-notification/internal/db"
+	"github.com/jhonathanssegura/ticket-notification/internal/model"
+	"github.com/jhonathanssegura/ticket-notification/internal/service"
+)
+
+// ListScheduledNotifications lista las notificaciones programadas pendientes de envío,
+// de la más próxima a la más lejana, filtrando opcionalmente por destinatario
+func (h *NotificationHandler) ListScheduledNotifications(c *gin.Context) {
+	limit := 50
+	if limitStr := c.Query("limit"); limitStr != "" {
+		value, err := strconv.Atoi(limitStr)
+		if err != nil || value < 1 || value > 500 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "El parámetro 'limit' debe ser un número entre 1 y 500"})
+			return
+		}
+		limit = value
+	}
+
+	notifications, err := h.dbClient.GetScheduledNotifications(c.Query("recipient"), nil, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Error obteniendo notificaciones programadas",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"notifications": notifications,
+			"total":         len(notifications),
+		},
+	})
+}
+
+// RescheduleNotification cambia la hora de envío de una notificación programada
+func (h *NotificationHandler) RescheduleNotification(c *gin.Context) {
+	var req model.RescheduleNotificationRequest
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Datos de reprogramación inválidos",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	notification, err := h.notificationService.RescheduleNotification(c.Request.Context(), c.Param("id"), req)
+	if err != nil {
+		h.scheduleError(c, err, "Error reprogramando notificación")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    notification,
+		"message": "Notificación reprogramada exitosamente",
+	})
+}
+
+// CancelScheduledNotification cancela una notificación programada antes de que se envíe
+func (h *NotificationHandler) CancelScheduledNotification(c *gin.Context) {
+	notification, err := h.notificationService.CancelScheduledNotification(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.scheduleError(c, err, "Error cancelando notificación programada")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    notification,
+		"message": "Notificación programada cancelada exitosamente",
+	})
+}
+
+// scheduleError responde al error de una operación sobre una notificación programada
+func (h *NotificationHandler) scheduleError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, db.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Notificación no encontrada"})
+	case errors.Is(err, db.ErrNotScheduled):
+		c.JSON(http.StatusConflict, gin.H{"error": "La notificación ya no está programada: se envió o se canceló"})
+	case errors.Is(err, service.ErrInvalidSendAt):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Hora de envío inválida",
+			"details": err.Error(),
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   message,
+			"details": err.Error(),
+		})
+	}
+}
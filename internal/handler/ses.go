@@ -0,0 +1,123 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	"github.com/gin-gonic/gin"
+	"github.com/jhonathanssegura/ticket-notification/internal/feedback"
+	"github.com/jhonathanssegura/ticket-notification/internal/service"
+)
+
+// maxSNSMessageSize limita el tamaño del cuerpo aceptado desde SNS (256 KB es el máximo de SNS)
+const maxSNSMessageSize = 256 * 1024
+
+// SESFeedbackHandler recibe por SNS los rebotes, quejas y entregas que informa SES
+type SESFeedbackHandler struct {
+	notificationService *service.NotificationService
+	snsClient           *sns.Client
+	verifier            *feedback.Verifier
+	topicARNs           map[string]bool
+}
+
+// NewSESFeedbackHandler crea una nueva instancia del handler de SES. Con verifier nil
+// no se comprueban las firmas (LocalStack no firma los mensajes). Solo se confirman
+// suscripciones y se procesan mensajes de los tópicos de topicARNs.
+func NewSESFeedbackHandler(notificationService *service.NotificationService, snsClient *sns.Client, verifier *feedback.Verifier, topicARNs []string) *SESFeedbackHandler {
+	allowed := make(map[string]bool)
+	for _, arn := range topicARNs {
+		allowed[arn] = true
+	}
+	return &SESFeedbackHandler{
+		notificationService: notificationService,
+		snsClient:           snsClient,
+		verifier:            verifier,
+		topicARNs:           allowed,
+	}
+}
+
+// HandleSNSNotification procesa un mensaje de SNS: confirma la suscripción del
+// endpoint o aplica el evento de SES que contiene
+func (h *SESFeedbackHandler) HandleSNSNotification(c *gin.Context) {
+	// SNS envía el JSON con Content-Type text/plain, por eso no se usa BindJSON
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxSNSMessageSize))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Error leyendo mensaje de SNS",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	var message feedback.SNSMessage
+	if err := json.Unmarshal(body, &message); err != nil || message.Type == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Mensaje de SNS inválido"})
+		return
+	}
+
+	// Cualquiera puede crear un tópico firmado por SNS y suscribir este endpoint
+	if !h.topicARNs[message.TopicArn] {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Tópico de SNS no permitido: " + message.TopicArn})
+		return
+	}
+
+	if h.verifier != nil {
+		if err := h.verifier.Verify(&message); err != nil {
+			status := http.StatusForbidden
+			if !errors.Is(err, feedback.ErrInvalidSignature) {
+				// No se pudo descargar el certificado: SNS reintentará
+				status = http.StatusServiceUnavailable
+			}
+			c.JSON(status, gin.H{
+				"error":   "No se pudo verificar la firma del mensaje de SNS",
+				"details": err.Error(),
+			})
+			return
+		}
+	}
+
+	switch message.Type {
+	case feedback.SNSTypeSubscriptionConfirmation:
+		_, err := h.snsClient.ConfirmSubscription(c.Request.Context(), &sns.ConfirmSubscriptionInput{
+			TopicArn: aws.String(message.TopicArn),
+			Token:    aws.String(message.Token),
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "Error confirmando suscripción de SNS",
+				"details": err.Error(),
+			})
+			return
+		}
+		log.Printf("✅ Suscripción al tópico %s confirmada", message.TopicArn)
+
+	case feedback.SNSTypeNotification:
+		event, err := feedback.ParseSESEvent(message.Message)
+		if err != nil {
+			// Un mensaje que no es de SES no se va a poder procesar en un reintento
+			log.Printf("Mensaje %s de SNS ignorado: %v", message.MessageID, err)
+			break
+		}
+		if err := h.notificationService.HandleEmailFeedback(event); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "Error procesando evento de SES",
+				"details": err.Error(),
+			})
+			return
+		}
+
+	case feedback.SNSTypeUnsubscribeConfirmation:
+		log.Printf("Suscripción al tópico %s cancelada", message.TopicArn)
+
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Tipo de mensaje de SNS desconocido: " + message.Type})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
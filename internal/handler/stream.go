@@ -0,0 +1,276 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/jhonathanssegura/ticket-notification/internal/db"
+	"github.com/jhonathanssegura/ticket-notification/internal/model"
+	"github.com/jhonathanssegura/ticket-notification/internal/realtime"
+)
+
+const (
+	// maxResumeNotifications limita las notificaciones que se reenvían al reanudar con Last-Event-ID
+	maxResumeNotifications = 100
+	// streamWriteTimeout limita cuánto puede tardar una escritura en el WebSocket
+	streamWriteTimeout = 10 * time.Second
+)
+
+// streamMessage es el mensaje que se envía por WebSocket
+type streamMessage struct {
+	Type string              `json:"type"`
+	ID   string              `json:"id,omitempty"`
+	Data *model.Notification `json:"data,omitempty"`
+}
+
+// StreamHandler envía en tiempo real las notificaciones nuevas de un destinatario
+type StreamHandler struct {
+	hub            *realtime.Hub
+	dbClient       *db.DynamoClient
+	tokenSecret    string
+	allowedOrigins map[string]bool
+	upgrader       websocket.Upgrader
+	heartbeat      time.Duration
+}
+
+// NewStreamHandler crea el handler de streaming. Las conexiones se autentican con
+// tokens firmados con tokenSecret, que no puede estar vacío. Los WebSocket de
+// navegador solo se aceptan desde el mismo origen o desde allowedOrigins.
+func NewStreamHandler(hub *realtime.Hub, dbClient *db.DynamoClient, tokenSecret string, allowedOrigins []string, heartbeat time.Duration) *StreamHandler {
+	h := &StreamHandler{
+		hub:            hub,
+		dbClient:       dbClient,
+		tokenSecret:    tokenSecret,
+		allowedOrigins: make(map[string]bool, len(allowedOrigins)),
+		heartbeat:      heartbeat,
+	}
+	for _, origin := range allowedOrigins {
+		h.allowedOrigins[strings.TrimSuffix(origin, "/")] = true
+	}
+	h.upgrader = websocket.Upgrader{
+		ReadBufferSize:  1024,
+		WriteBufferSize: 1024,
+		CheckOrigin:     h.checkOrigin,
+	}
+	return h
+}
+
+// checkOrigin evita que otro sitio abra un WebSocket con el token de un usuario: sin
+// cabecera Origin la conexión no viene de un navegador, y si la trae debe ser el mismo
+// host o uno de los orígenes permitidos
+func (h *StreamHandler) checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" || h.allowedOrigins[origin] {
+		return true
+	}
+	parsed, err := url.Parse(origin)
+	return err == nil && strings.EqualFold(parsed.Host, r.Host)
+}
+
+// StreamSSE envía las notificaciones como Server-Sent Events
+func (h *StreamHandler) StreamSSE(c *gin.Context) {
+	recipient, ok := h.authenticate(c)
+	if !ok {
+		return
+	}
+
+	// Suscribirse antes de buscar las perdidas para no dejar huecos entre ambas
+	subscriber := h.hub.Subscribe(recipient)
+	defer h.hub.Unsubscribe(subscriber)
+
+	lastEventID := c.GetHeader("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = c.Query("last_event_id")
+	}
+	missed, err := h.missedNotifications(recipient, lastEventID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Error recuperando notificaciones pendientes",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	c.Render(-1, sse.Event{Event: "ready", Retry: 3000, Data: gin.H{"recipient": recipient}})
+
+	sent := make(map[string]bool, len(missed))
+	for i := range missed {
+		c.Render(-1, sse.Event{Id: missed[i].ID.String(), Event: "notification", Data: missed[i]})
+		sent[missed[i].ID.String()] = true
+	}
+	c.Writer.Flush()
+
+	ticker := time.NewTicker(h.heartbeat)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case <-subscriber.Dropped():
+			return
+		case notification := <-subscriber.Events():
+			if sent[notification.ID.String()] {
+				continue
+			}
+			c.Render(-1, sse.Event{Id: notification.ID.String(), Event: "notification", Data: notification})
+			c.Writer.Flush()
+		case <-ticker.C:
+			// Los comentarios SSE mantienen viva la conexión sin generar eventos en el cliente
+			if _, err := c.Writer.WriteString(": heartbeat\n\n"); err != nil {
+				return
+			}
+			c.Writer.Flush()
+		}
+	}
+}
+
+// StreamWebSocket envía las notificaciones por WebSocket como mensajes JSON
+func (h *StreamHandler) StreamWebSocket(c *gin.Context) {
+	recipient, ok := h.authenticate(c)
+	if !ok {
+		return
+	}
+
+	subscriber := h.hub.Subscribe(recipient)
+	defer h.hub.Unsubscribe(subscriber)
+
+	missed, err := h.missedNotifications(recipient, c.Query("last_event_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Error recuperando notificaciones pendientes",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	conn, err := h.upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// Upgrade ya respondió al cliente con el error
+		log.Printf("Error abriendo WebSocket para %s: %v", recipient, err)
+		return
+	}
+	defer conn.Close()
+
+	// El cliente debe responder a los ping; si deja de hacerlo se cierra la conexión
+	readTimeout := 2 * h.heartbeat
+	conn.SetReadDeadline(time.Now().Add(readTimeout))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(readTimeout))
+	})
+
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	send := func(message streamMessage) bool {
+		conn.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+		return conn.WriteJSON(message) == nil
+	}
+
+	if !send(streamMessage{Type: "ready"}) {
+		return
+	}
+	sent := make(map[string]bool, len(missed))
+	for i := range missed {
+		if !send(streamMessage{Type: "notification", ID: missed[i].ID.String(), Data: &missed[i]}) {
+			return
+		}
+		sent[missed[i].ID.String()] = true
+	}
+
+	ticker := time.NewTicker(h.heartbeat)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-closed:
+			return
+		case <-subscriber.Dropped():
+			conn.WriteControl(websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.CloseGoingAway, "reconnect with last_event_id"),
+				time.Now().Add(streamWriteTimeout))
+			return
+		case notification := <-subscriber.Events():
+			if sent[notification.ID.String()] {
+				continue
+			}
+			if !send(streamMessage{Type: "notification", ID: notification.ID.String(), Data: &notification}) {
+				return
+			}
+		case <-ticker.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(streamWriteTimeout)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// authenticate obtiene el destinatario del token de la petición (cabecera
+// Authorization o parámetro token, ya que EventSource no permite cabeceras)
+func (h *StreamHandler) authenticate(c *gin.Context) (string, bool) {
+	token := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if token == "" {
+		token = c.Query("token")
+	}
+
+	recipient, err := realtime.VerifyToken(h.tokenSecret, token)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Token de conexión inválido o expirado"})
+		return "", false
+	}
+	return recipient, true
+}
+
+// missedNotifications obtiene las notificaciones creadas después de lastEventID.
+// Puede repetir alguna creada en el mismo segundo; los clientes deben descartar
+// duplicados por ID.
+func (h *StreamHandler) missedNotifications(recipient, lastEventID string) ([]model.Notification, error) {
+	if lastEventID == "" {
+		return nil, nil
+	}
+
+	last, err := h.dbClient.GetNotificationByID(lastEventID)
+	if err != nil || last.Recipient != recipient {
+		// Un ID desconocido no impide conectarse; simplemente no hay nada que reanudar
+		return nil, nil
+	}
+
+	notifications, err := h.dbClient.GetNotificationsSince(recipient, last.CreatedAt, maxResumeNotifications)
+	if err != nil {
+		return nil, err
+	}
+
+	var missed []model.Notification
+	passed := false
+	for _, notification := range notifications {
+		// Las del mismo segundo que la última recibida se omiten hasta llegar a ella
+		if !passed && notification.CreatedAt.Equal(last.CreatedAt) {
+			if notification.ID == last.ID {
+				passed = true
+			}
+			continue
+		}
+		missed = append(missed, notification)
+	}
+
+	return missed, nil
+}
@@ -0,0 +1,136 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jhonathanssegura/ticket-notification/internal/db"
+	"github.com/jhonathanssegura/ticket-notification/internal/model"
+)
+
+// SuppressionHandler administra la lista de supresión de emails
+type SuppressionHandler struct {
+	dbClient *db.DynamoClient
+}
+
+// NewSuppressionHandler crea una nueva instancia del handler de supresiones
+func NewSuppressionHandler(dbClient *db.DynamoClient) *SuppressionHandler {
+	return &SuppressionHandler{
+		dbClient: dbClient,
+	}
+}
+
+// ListSuppressions lista las direcciones suprimidas, filtrando opcionalmente por motivo
+func (h *SuppressionHandler) ListSuppressions(c *gin.Context) {
+	reason := c.Query("reason")
+	switch model.SuppressionReason(reason) {
+	case "", model.SuppressionBounce, model.SuppressionComplaint, model.SuppressionManual:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "El parámetro 'reason' debe ser address_bounced, address_complained o manual"})
+		return
+	}
+
+	limit := 100
+	if limitStr := c.Query("limit"); limitStr != "" {
+		value, err := strconv.Atoi(limitStr)
+		if err != nil || value < 1 || value > 1000 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "El parámetro 'limit' debe ser un número entre 1 y 1000"})
+			return
+		}
+		limit = value
+	}
+
+	suppressions, err := h.dbClient.GetEmailSuppressions(reason, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Error obteniendo lista de supresión",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"suppressions": suppressions,
+			"total":        len(suppressions),
+		},
+	})
+}
+
+// GetSuppression indica si una dirección está suprimida y por qué
+func (h *SuppressionHandler) GetSuppression(c *gin.Context) {
+	suppression, err := h.dbClient.GetEmailSuppression(c.Param("email"))
+	if err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "La dirección no está en la lista de supresión"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Error obteniendo supresión",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    suppression,
+	})
+}
+
+// CreateSuppression añade manualmente una dirección a la lista de supresión
+func (h *SuppressionHandler) CreateSuppression(c *gin.Context) {
+	var req model.CreateSuppressionRequest
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Datos de supresión inválidos",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	suppression := model.EmailSuppression{
+		Email:     strings.ToLower(strings.TrimSpace(req.Email)),
+		Reason:    model.SuppressionManual,
+		Details:   req.Details,
+		CreatedAt: time.Now(),
+	}
+	if err := h.dbClient.SaveEmailSuppression(suppression); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Error guardando supresión",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    suppression,
+		"message": "Dirección añadida a la lista de supresión",
+	})
+}
+
+// DeleteSuppression quita una dirección de la lista de supresión para volver a enviarle emails
+func (h *SuppressionHandler) DeleteSuppression(c *gin.Context) {
+	if err := h.dbClient.DeleteEmailSuppression(c.Param("email")); err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "La dirección no está en la lista de supresión"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Error eliminando supresión",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Dirección eliminada de la lista de supresión",
+	})
+}
@@ -0,0 +1,526 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jhonathanssegura/ticket-notification/internal/db"
+	"github.com/jhonathanssegura/ticket-notification/internal/model"
+	"github.com/jhonathanssegura/ticket-notification/internal/service"
+)
+
+// TemplateHandler maneja las peticiones HTTP relacionadas con plantillas de notificación
+type TemplateHandler struct {
+	dbClient *db.DynamoClient
+}
+
+// NewTemplateHandler crea una nueva instancia del handler de plantillas
+func NewTemplateHandler(dbClient *db.DynamoClient) *TemplateHandler {
+	return &TemplateHandler{
+		dbClient: dbClient,
+	}
+}
+
+// CreateTemplate crea una nueva plantilla, validándola con un renderizado de prueba
+func (h *TemplateHandler) CreateTemplate(c *gin.Context) {
+	var req model.CreateTemplateRequest
+
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Datos de plantilla inválidos",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if req.Content == "" && req.HTMLContent == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Debe especificar content, html_content o ambos"})
+		return
+	}
+
+	// El nombre identifica la plantilla en los envíos, por lo que debe ser único
+	if !h.checkTemplateName(c, req.Name, uuid.Nil) {
+		return
+	}
+
+	now := time.Now()
+	template := model.NotificationTemplate{
+		ID:          uuid.New(),
+		Name:        req.Name,
+		Type:        req.Type,
+		Subject:     req.Subject,
+		Content:     req.Content,
+		HTMLContent: req.HTMLContent,
+		Locale:      req.Locale,
+		Locales:     req.Locales,
+		Variables:   req.Variables,
+		Version:     1,
+		IsActive:    true,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if req.IsActive != nil {
+		template.IsActive = *req.IsActive
+	}
+
+	if err := service.NormalizeTemplateLocales(&template); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Idiomas de plantilla inválidos",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	preview, err := testRenderTemplate(&template)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "La plantilla no se pudo renderizar con datos de prueba",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if err := h.dbClient.SaveNotificationTemplateVersion(template, 0, ""); err != nil {
+		if errors.Is(err, db.ErrTemplateNameTaken) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Ya existe una plantilla con ese nombre"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Error guardando plantilla",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data": gin.H{
+			"template": template,
+			"preview":  preview,
+		},
+		"message": "Plantilla creada exitosamente",
+	})
+}
+
+// GetTemplate obtiene una plantilla por ID
+func (h *TemplateHandler) GetTemplate(c *gin.Context) {
+	template, ok := h.loadTemplate(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    template,
+	})
+}
+
+// ListTemplates lista plantillas filtrando opcionalmente por tipo y estado
+func (h *TemplateHandler) ListTemplates(c *gin.Context) {
+	templateType := c.Query("type")
+
+	var isActive *bool
+	if isActiveStr := c.Query("is_active"); isActiveStr != "" {
+		value, err := strconv.ParseBool(isActiveStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "El parámetro 'is_active' debe ser true o false"})
+			return
+		}
+		isActive = &value
+	}
+
+	templates, err := h.dbClient.GetNotificationTemplates(templateType, isActive)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Error obteniendo plantillas",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"templates": templates,
+			"count":     len(templates),
+			"filters": gin.H{
+				"type":      templateType,
+				"is_active": isActive,
+			},
+		},
+	})
+}
+
+// UpdateTemplate actualiza una plantilla existente
+func (h *TemplateHandler) UpdateTemplate(c *gin.Context) {
+	var req model.UpdateTemplateRequest
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Datos de actualización inválidos",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	template, ok := h.loadTemplate(c)
+	if !ok {
+		return
+	}
+	previousName := template.Name
+
+	if req.Name != nil && *req.Name != template.Name {
+		if !h.checkTemplateName(c, *req.Name, template.ID) {
+			return
+		}
+		template.Name = *req.Name
+	}
+	if req.Type != nil {
+		template.Type = *req.Type
+	}
+	if req.Subject != nil {
+		template.Subject = *req.Subject
+	}
+	if req.Content != nil {
+		template.Content = *req.Content
+	}
+	if req.HTMLContent != nil {
+		template.HTMLContent = *req.HTMLContent
+	}
+	if req.Locale != nil {
+		template.Locale = *req.Locale
+	}
+	// locales reemplaza todas las traducciones; {} las elimina
+	if req.Locales != nil {
+		template.Locales = req.Locales
+	}
+	if req.Variables != nil {
+		template.Variables = req.Variables
+	}
+	if template.Content == "" && template.HTMLContent == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "La plantilla debe tener content, html_content o ambos"})
+		return
+	}
+	if err := service.NormalizeTemplateLocales(template); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Idiomas de plantilla inválidos",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	preview, err := testRenderTemplate(template)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "La plantilla no se pudo renderizar con datos de prueba",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if !h.saveNewVersion(c, template, previousName) {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"template": template,
+			"preview":  preview,
+		},
+		"message": "Plantilla actualizada exitosamente",
+	})
+}
+
+// ListTemplateVersions lista todas las versiones de una plantilla
+func (h *TemplateHandler) ListTemplateVersions(c *gin.Context) {
+	template, ok := h.loadTemplate(c)
+	if !ok {
+		return
+	}
+
+	versions, err := h.dbClient.GetNotificationTemplateVersions(template.ID.String())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Error obteniendo versiones de plantilla",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"template_id":     template.ID,
+			"current_version": template.Version,
+			"versions":        versions,
+			"count":           len(versions),
+		},
+	})
+}
+
+// GetTemplateVersion obtiene una versión concreta de una plantilla
+func (h *TemplateHandler) GetTemplateVersion(c *gin.Context) {
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil || version <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Versión inválida"})
+		return
+	}
+
+	templateVersion, ok := h.loadTemplateVersion(c, c.Param("id"), version)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    templateVersion,
+	})
+}
+
+// DiffTemplateVersions compara dos versiones de una plantilla
+func (h *TemplateHandler) DiffTemplateVersions(c *gin.Context) {
+	from, errFrom := strconv.Atoi(c.Query("from"))
+	to, errTo := strconv.Atoi(c.Query("to"))
+	if errFrom != nil || errTo != nil || from <= 0 || to <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Los parámetros 'from' y 'to' son requeridos y deben ser versiones válidas",
+		})
+		return
+	}
+
+	template, ok := h.loadTemplate(c)
+	if !ok {
+		return
+	}
+
+	fromVersion, ok := h.loadTemplateVersion(c, template.ID.String(), from)
+	if !ok {
+		return
+	}
+	toVersion, ok := h.loadTemplateVersion(c, template.ID.String(), to)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    service.DiffTemplateVersions(fromVersion, toVersion),
+	})
+}
+
+// RollbackTemplate vuelve a una versión anterior creando una nueva versión con su contenido
+func (h *TemplateHandler) RollbackTemplate(c *gin.Context) {
+	var req model.RollbackTemplateRequest
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Datos de rollback inválidos",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	template, ok := h.loadTemplate(c)
+	if !ok {
+		return
+	}
+
+	target, ok := h.loadTemplateVersion(c, template.ID.String(), req.Version)
+	if !ok {
+		return
+	}
+
+	// La versión restaurada conserva el estado activo actual de la plantilla
+	restored := service.TemplateFromVersion(template, target)
+	restored.Version = template.Version
+	if restored.Name != template.Name && !h.checkTemplateName(c, restored.Name, template.ID) {
+		return
+	}
+
+	if !h.saveNewVersion(c, restored, template.Name) {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"template":      restored,
+			"restored_from": req.Version,
+		},
+		"message": "Plantilla restaurada exitosamente",
+	})
+}
+
+// ActivateTemplate activa una plantilla
+func (h *TemplateHandler) ActivateTemplate(c *gin.Context) {
+	h.setTemplateActive(c, true)
+}
+
+// DeactivateTemplate desactiva una plantilla
+func (h *TemplateHandler) DeactivateTemplate(c *gin.Context) {
+	h.setTemplateActive(c, false)
+}
+
+// DeleteTemplate elimina una plantilla
+func (h *TemplateHandler) DeleteTemplate(c *gin.Context) {
+	template, ok := h.loadTemplate(c)
+	if !ok {
+		return
+	}
+
+	if err := h.dbClient.DeleteNotificationTemplate(*template); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Error eliminando plantilla",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Plantilla eliminada exitosamente",
+	})
+}
+
+// setTemplateActive cambia el estado activo de una plantilla
+func (h *TemplateHandler) setTemplateActive(c *gin.Context, active bool) {
+	template, ok := h.loadTemplate(c)
+	if !ok {
+		return
+	}
+
+	template.IsActive = active
+	template.UpdatedAt = time.Now()
+
+	if err := h.dbClient.SaveNotificationTemplate(*template); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Error actualizando estado de plantilla",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	message := "Plantilla desactivada exitosamente"
+	if active {
+		message = "Plantilla activada exitosamente"
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    template,
+		"message": message,
+	})
+}
+
+// loadTemplate obtiene la plantilla del parámetro :id, respondiendo el error si no existe
+func (h *TemplateHandler) loadTemplate(c *gin.Context) (*model.NotificationTemplate, bool) {
+	templateID := c.Param("id")
+	if _, err := uuid.Parse(templateID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de plantilla inválido"})
+		return nil, false
+	}
+
+	template, err := h.dbClient.GetNotificationTemplate(templateID)
+	if err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Plantilla no encontrada"})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Error obteniendo plantilla",
+			"details": err.Error(),
+		})
+		return nil, false
+	}
+
+	return template, true
+}
+
+// checkTemplateName comprueba que ninguna plantilla distinta de templateID use el nombre,
+// respondiendo el error si no es así. La reserva del nombre al guardar lo garantiza;
+// esta comprobación lo informa antes y cubre las plantillas guardadas sin reserva.
+func (h *TemplateHandler) checkTemplateName(c *gin.Context, name string, templateID uuid.UUID) bool {
+	existing, err := h.dbClient.GetNotificationTemplateByName(name)
+	if err == nil && existing.ID != templateID {
+		c.JSON(http.StatusConflict, gin.H{"error": "Ya existe una plantilla con ese nombre"})
+		return false
+	}
+	if err != nil && !errors.Is(err, db.ErrNotFound) {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Error verificando plantilla",
+			"details": err.Error(),
+		})
+		return false
+	}
+	return true
+}
+
+// saveNewVersion guarda la plantilla como una nueva versión inmutable, respondiendo el
+// error si falla. previousName es el nombre que tenía, que se libera si cambió.
+func (h *TemplateHandler) saveNewVersion(c *gin.Context, template *model.NotificationTemplate, previousName string) bool {
+	previousVersion := template.Version
+	template.Version = previousVersion + 1
+	template.UpdatedAt = time.Now()
+
+	if err := h.dbClient.SaveNotificationTemplateVersion(*template, previousVersion, previousName); err != nil {
+		if errors.Is(err, db.ErrTemplateNameTaken) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Ya existe una plantilla con ese nombre"})
+			return false
+		}
+		if strings.Contains(err.Error(), "version conflict") {
+			c.JSON(http.StatusConflict, gin.H{
+				"error":   "La plantilla fue modificada por otra operación, vuelva a intentarlo",
+				"details": err.Error(),
+			})
+			return false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Error actualizando plantilla",
+			"details": err.Error(),
+		})
+		return false
+	}
+
+	return true
+}
+
+// loadTemplateVersion obtiene una versión de plantilla, respondiendo el error si no existe
+func (h *TemplateHandler) loadTemplateVersion(c *gin.Context, templateID string, version int) (*model.TemplateVersion, bool) {
+	templateVersion, err := h.dbClient.GetNotificationTemplateVersion(templateID, version)
+	if err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Versión de plantilla no encontrada"})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Error obteniendo versión de plantilla",
+			"details": err.Error(),
+		})
+		return nil, false
+	}
+
+	return templateVersion, true
+}
+
+// testRenderTemplate renderiza la plantilla y cada una de sus traducciones con datos de
+// ejemplo para cada variable declarada. Falla si alguna usa variables que no declara.
+// Devuelve el renderizado en el idioma propio de la plantilla.
+func testRenderTemplate(template *model.NotificationTemplate) (*service.RenderedTemplate, error) {
+	sampleData := make(map[string]interface{}, len(template.Variables))
+	for _, variable := range template.Variables {
+		sampleData[variable] = "<" + variable + ">"
+	}
+
+	for tag := range template.Locales {
+		if _, err := service.RenderTemplate(service.LocalizeTemplate(template, tag), sampleData); err != nil {
+			return nil, fmt.Errorf("locale %s: %w", tag, err)
+		}
+	}
+
+	return service.RenderTemplate(template, sampleData)
+}
@@ -0,0 +1,139 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jhonathanssegura/ticket-notification/internal/model"
+)
+
+// NotifyTicketGenerated notifica la entrada emitida para una reserva
+func (h *NotificationHandler) NotifyTicketGenerated(c *gin.Context) {
+	var req model.TicketNotification
+
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Datos de notificación de entrada inválidos",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if err := h.notificationService.NotifyTicketGenerated(c.Request.Context(), req); err != nil {
+		respondNotifyError(c, err, "Error enviando notificación de entrada")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Notificación de entrada enviada exitosamente",
+		"data": gin.H{
+			"reservation_id": req.ReservationID,
+			"event_id":       req.EventID,
+			"ticket_code":    req.TicketCode,
+			"recipient":      req.Recipient,
+		},
+	})
+}
+
+// NotifyPaymentReceived envía el comprobante de un pago recibido
+func (h *NotificationHandler) NotifyPaymentReceived(c *gin.Context) {
+	var req model.PaymentNotification
+
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Datos de notificación de pago inválidos",
+			"details": err.Error(),
+		})
+		return
+	}
+	if !h.requireRecipient(c, req.Recipient, req.Channels) {
+		return
+	}
+
+	notification, err := h.notificationService.NotifyPaymentReceived(c.Request.Context(), req)
+	h.respondTransactional(c, notification, err, "Notificación de pago recibido aceptada")
+}
+
+// NotifyPaymentFailed avisa que un pago fue rechazado
+func (h *NotificationHandler) NotifyPaymentFailed(c *gin.Context) {
+	var req model.PaymentNotification
+
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Datos de notificación de pago inválidos",
+			"details": err.Error(),
+		})
+		return
+	}
+	if !h.requireRecipient(c, req.Recipient, req.Channels) {
+		return
+	}
+
+	notification, err := h.notificationService.NotifyPaymentFailed(c.Request.Context(), req)
+	h.respondTransactional(c, notification, err, "Notificación de pago rechazado aceptada")
+}
+
+// NotifyWelcome da la bienvenida a un usuario recién registrado
+func (h *NotificationHandler) NotifyWelcome(c *gin.Context) {
+	var req model.WelcomeNotification
+
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Datos de bienvenida inválidos",
+			"details": err.Error(),
+		})
+		return
+	}
+	if !h.requireRecipient(c, req.Recipient, req.Channels) {
+		return
+	}
+
+	notification, err := h.notificationService.NotifyWelcome(c.Request.Context(), req)
+	h.respondTransactional(c, notification, err, "Bienvenida aceptada")
+}
+
+// NotifyPasswordReset envía el enlace para restablecer la contraseña
+func (h *NotificationHandler) NotifyPasswordReset(c *gin.Context) {
+	var req model.PasswordResetNotification
+
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Datos de restablecimiento de contraseña inválidos",
+			"details": err.Error(),
+		})
+		return
+	}
+	if !h.requireRecipient(c, req.Recipient, req.Channels) {
+		return
+	}
+
+	notification, err := h.notificationService.NotifyPasswordReset(c.Request.Context(), req)
+	h.respondTransactional(c, notification, err, "Enlace de restablecimiento de contraseña aceptado")
+}
+
+// requireRecipient comprueba que se indique recipient o channels
+func (h *NotificationHandler) requireRecipient(c *gin.Context, recipient string, channels []model.ChannelRecipient) bool {
+	if recipient == "" && len(channels) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Recipient o channels son requeridos",
+		})
+		return false
+	}
+	return true
+}
+
+// respondTransactional responde la notificación aceptada, o el error del envío
+func (h *NotificationHandler) respondTransactional(c *gin.Context, notification *model.Notification, err error, message string) {
+	if err != nil {
+		respondNotifyError(c, err, "Error enviando notificación")
+		return
+	}
+
+	// La notificación ya quedó guardada; se entrega en segundo plano
+	c.JSON(http.StatusAccepted, gin.H{
+		"success": true,
+		"data":    notification,
+		"message": message,
+	})
+}
@@ -0,0 +1,281 @@
+package handler
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jhonathanssegura/ticket-notification/internal/db"
+	"github.com/jhonathanssegura/ticket-notification/internal/model"
+)
+
+// WebhookHandler maneja las peticiones HTTP relacionadas con suscripciones de webhook
+type WebhookHandler struct {
+	dbClient *db.DynamoClient
+}
+
+// NewWebhookHandler crea una nueva instancia del handler de webhooks
+func NewWebhookHandler(dbClient *db.DynamoClient) *WebhookHandler {
+	return &WebhookHandler{
+		dbClient: dbClient,
+	}
+}
+
+// CreateWebhook crea una suscripción de webhook. El secreto de firma solo se
+// devuelve en esta respuesta.
+func (h *WebhookHandler) CreateWebhook(c *gin.Context) {
+	var req model.CreateWebhookRequest
+
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Datos de webhook inválidos",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if len(req.EventTypes) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Debe especificar al menos un tipo de notificación en event_types"})
+		return
+	}
+
+	secret := req.Secret
+	if secret == "" {
+		generated, err := generateWebhookSecret()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "Error generando secreto del webhook",
+				"details": err.Error(),
+			})
+			return
+		}
+		secret = generated
+	}
+
+	now := time.Now()
+	subscription := model.WebhookSubscription{
+		ID:         uuid.New(),
+		Name:       req.Name,
+		URL:        req.URL,
+		Secret:     secret,
+		EventTypes: req.EventTypes,
+		IsActive:   true,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	if req.IsActive != nil {
+		subscription.IsActive = *req.IsActive
+	}
+
+	if err := h.dbClient.SaveWebhookSubscription(subscription); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Error guardando webhook",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    subscription,
+		"message": "Webhook creado exitosamente. Guarde el secreto: no se volverá a mostrar",
+	})
+}
+
+// GetWebhook obtiene una suscripción de webhook por ID
+func (h *WebhookHandler) GetWebhook(c *gin.Context) {
+	subscription, ok := h.loadWebhook(c)
+	if !ok {
+		return
+	}
+
+	subscription.Secret = ""
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    subscription,
+	})
+}
+
+// ListWebhooks lista las suscripciones filtrando opcionalmente por tipo y estado
+func (h *WebhookHandler) ListWebhooks(c *gin.Context) {
+	eventType := c.Query("event_type")
+
+	var isActive *bool
+	if isActiveStr := c.Query("is_active"); isActiveStr != "" {
+		value, err := strconv.ParseBool(isActiveStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "El parámetro 'is_active' debe ser true o false"})
+			return
+		}
+		isActive = &value
+	}
+
+	subscriptions, err := h.dbClient.GetWebhookSubscriptions(eventType, isActive)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Error obteniendo webhooks",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	for i := range subscriptions {
+		subscriptions[i].Secret = ""
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"webhooks": subscriptions,
+			"total":    len(subscriptions),
+		},
+	})
+}
+
+// UpdateWebhook actualiza una suscripción de webhook
+func (h *WebhookHandler) UpdateWebhook(c *gin.Context) {
+	subscription, ok := h.loadWebhook(c)
+	if !ok {
+		return
+	}
+
+	var req model.UpdateWebhookRequest
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Datos de webhook inválidos",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if req.Name != nil {
+		subscription.Name = *req.Name
+	}
+	if req.URL != nil {
+		subscription.URL = *req.URL
+	}
+	if req.Secret != nil && *req.Secret != "" {
+		subscription.Secret = *req.Secret
+	}
+	if req.EventTypes != nil {
+		if len(req.EventTypes) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Debe especificar al menos un tipo de notificación en event_types"})
+			return
+		}
+		subscription.EventTypes = req.EventTypes
+	}
+	if req.IsActive != nil {
+		subscription.IsActive = *req.IsActive
+	}
+	subscription.UpdatedAt = time.Now()
+
+	if err := h.dbClient.SaveWebhookSubscription(*subscription); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Error actualizando webhook",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	subscription.Secret = ""
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    subscription,
+		"message": "Webhook actualizado exitosamente",
+	})
+}
+
+// DeleteWebhook elimina una suscripción de webhook
+func (h *WebhookHandler) DeleteWebhook(c *gin.Context) {
+	subscription, ok := h.loadWebhook(c)
+	if !ok {
+		return
+	}
+
+	if err := h.dbClient.DeleteWebhookSubscription(subscription.ID.String()); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Error eliminando webhook",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Webhook eliminado exitosamente",
+	})
+}
+
+// ListWebhookDeliveries lista los intentos de entrega de un webhook, del más reciente
+// al más antiguo, filtrando opcionalmente por notificación
+func (h *WebhookHandler) ListWebhookDeliveries(c *gin.Context) {
+	subscription, ok := h.loadWebhook(c)
+	if !ok {
+		return
+	}
+
+	limit := 50
+	if limitStr := c.Query("limit"); limitStr != "" {
+		value, err := strconv.Atoi(limitStr)
+		if err != nil || value < 1 || value > 500 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "El parámetro 'limit' debe ser un número entre 1 y 500"})
+			return
+		}
+		limit = value
+	}
+
+	attempts, err := h.dbClient.GetWebhookDeliveryAttempts(subscription.ID.String(), c.Query("notification_id"), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Error obteniendo entregas del webhook",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"deliveries": attempts,
+			"total":      len(attempts),
+		},
+	})
+}
+
+// loadWebhook obtiene la suscripción indicada en la URL, respondiendo con error si no existe
+func (h *WebhookHandler) loadWebhook(c *gin.Context) (*model.WebhookSubscription, bool) {
+	subscriptionID := c.Param("id")
+	if _, err := uuid.Parse(subscriptionID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de webhook inválido"})
+		return nil, false
+	}
+
+	subscription, err := h.dbClient.GetWebhookSubscription(subscriptionID)
+	if err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Webhook no encontrado"})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Error obteniendo webhook",
+			"details": err.Error(),
+		})
+		return nil, false
+	}
+
+	return subscription, true
+}
+
+// generateWebhookSecret genera un secreto aleatorio para firmar los webhooks
+func generateWebhookSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(buf), nil
+}
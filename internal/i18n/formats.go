@@ -0,0 +1,92 @@
+package i18n
+
+// regionalFormat describe cómo se escriben fechas, horas y montos en un idioma
+type regionalFormat struct {
+	months      [12]string
+	date        string
+	clock       string
+	decimal     string
+	group       string
+	symbolFirst bool
+	symbolSpace bool
+	symbols     map[string]string
+}
+
+var (
+	spanishMonths = [12]string{
+		"enero", "febrero", "marzo", "abril", "mayo", "junio",
+		"julio", "agosto", "septiembre", "octubre", "noviembre", "diciembre",
+	}
+	englishMonths = [12]string{
+		"January", "February", "March", "April", "May", "June",
+		"July", "August", "September", "October", "November", "December",
+	}
+	portugueseMonths = [12]string{
+		"janeiro", "fevereiro", "março", "abril", "maio", "junho",
+		"julho", "agosto", "setembro", "outubro", "novembro", "dezembro",
+	}
+)
+
+// formats contiene los formatos regionales conocidos; un idioma sin formato propio usa
+// el de su idioma base y, en último caso, el de DefaultLocale
+var formats = map[string]regionalFormat{
+	"es": {
+		months:  spanishMonths,
+		date:    "{day} de {month} de {year}",
+		clock:   "15:04",
+		decimal: ",",
+		group:   ".",
+	},
+	"en": {
+		months:      englishMonths,
+		date:        "{month} {day}, {year}",
+		clock:       "3:04 PM",
+		decimal:     ".",
+		group:       ",",
+		symbolFirst: true,
+		symbols:     map[string]string{"USD": "$"},
+	},
+	"en-GB": {
+		months:      englishMonths,
+		date:        "{day} {month} {year}",
+		clock:       "15:04",
+		decimal:     ".",
+		group:       ",",
+		symbolFirst: true,
+	},
+	"pt": {
+		months:  portugueseMonths,
+		date:    "{day} de {month} de {year}",
+		clock:   "15:04",
+		decimal: ",",
+		group:   " ",
+	},
+	"pt-BR": {
+		months:      portugueseMonths,
+		date:        "{day} de {month} de {year}",
+		clock:       "15:04",
+		decimal:     ",",
+		group:       ".",
+		symbolFirst: true,
+		symbolSpace: true,
+	},
+}
+
+// currencySymbols son los símbolos de moneda comunes a todos los idiomas; una moneda
+// sin símbolo se muestra con su código ISO 4217
+var currencySymbols = map[string]string{
+	"EUR": "€",
+	"USD": "US$",
+	"BRL": "R$",
+	"GBP": "£",
+	"MXN": "MX$",
+}
+
+// zeroDecimalCurrencies son las monedas que no usan decimales
+var zeroDecimalCurrencies = map[string]bool{
+	"JPY": true,
+	"CLP": true,
+	"KRW": true,
+	"PYG": true,
+	"VND": true,
+}
@@ -0,0 +1,215 @@
+package i18n
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultLocale es el idioma del servicio y el último de toda cadena de respaldo
+const DefaultLocale = "es"
+
+// tagPattern reconoce etiquetas de idioma como "es", "pt-BR" o "zh-Hant-TW"
+var tagPattern = regexp.MustCompile(`^[a-zA-Z]{2,3}([-_][a-zA-Z0-9]{2,8})*$`)
+
+// Normalize devuelve la etiqueta en su forma canónica ("pt_br" → "pt-BR"), o vacío
+// si no es una etiqueta de idioma válida
+func Normalize(tag string) string {
+	tag = strings.TrimSpace(tag)
+	if !tagPattern.MatchString(tag) {
+		return ""
+	}
+
+	parts := strings.FieldsFunc(tag, func(r rune) bool { return r == '-' || r == '_' })
+	parts[0] = strings.ToLower(parts[0])
+	for i := 1; i < len(parts); i++ {
+		switch len(parts[i]) {
+		case 2:
+			// Región: "BR", "US"
+			parts[i] = strings.ToUpper(parts[i])
+		case 4:
+			// Escritura: "Hant"
+			parts[i] = strings.ToUpper(parts[i][:1]) + strings.ToLower(parts[i][1:])
+		default:
+			parts[i] = strings.ToLower(parts[i])
+		}
+	}
+	return strings.Join(parts, "-")
+}
+
+// Chain devuelve los idiomas a probar, del más al menos específico, terminando en
+// DefaultLocale: Chain("pt-BR") es [pt-BR pt es]
+func Chain(tag string) []string {
+	var chain []string
+	seen := make(map[string]bool)
+	add := func(tag string) {
+		if tag != "" && !seen[tag] {
+			seen[tag] = true
+			chain = append(chain, tag)
+		}
+	}
+
+	tag = Normalize(tag)
+	for tag != "" {
+		add(tag)
+		cut := strings.LastIndex(tag, "-")
+		if cut < 0 {
+			break
+		}
+		tag = tag[:cut]
+	}
+	add(DefaultLocale)
+	return chain
+}
+
+// Formatter formatea textos, fechas, plazos y montos en un idioma. Con Location las
+// fechas se muestran en esa zona horaria; sin ella, en la zona con la que llegan.
+type Formatter struct {
+	Locale   string
+	Location *time.Location
+}
+
+// New crea un formateador para el idioma indicado; uno vacío o inválido usa DefaultLocale
+func New(locale string, location *time.Location) Formatter {
+	locale = Normalize(locale)
+	if locale == "" {
+		locale = DefaultLocale
+	}
+	return Formatter{
+		Locale:   locale,
+		Location: location,
+	}
+}
+
+// T devuelve el mensaje traducido con la clave indicada, con args aplicados como en fmt.Sprintf
+func (f Formatter) T(key string, args ...interface{}) string {
+	for _, tag := range Chain(f.Locale) {
+		if message, ok := messages[tag][key]; ok {
+			if len(args) == 0 {
+				return message
+			}
+			return fmt.Sprintf(message, args...)
+		}
+	}
+	return key
+}
+
+// DateTime formatea fecha, hora y zona horaria: "1 de marzo de 2026, 20:00 (GMT-03:00)"
+func (f Formatter) DateTime(t time.Time) string {
+	t = f.in(t)
+	return fmt.Sprintf("%s, %s (%s)", f.Date(t), f.Time(t), f.Zone(t))
+}
+
+// Date formatea la fecha con el nombre del mes: "1 de marzo de 2026", "March 1, 2026"
+func (f Formatter) Date(t time.Time) string {
+	t = f.in(t)
+	format := f.format()
+	return strings.NewReplacer(
+		"{day}", strconv.Itoa(t.Day()),
+		"{month}", format.months[t.Month()-1],
+		"{year}", strconv.Itoa(t.Year()),
+	).Replace(format.date)
+}
+
+// Time formatea la hora: "20:00", "8:00 PM"
+func (f Formatter) Time(t time.Time) string {
+	return f.in(t).Format(f.format().clock)
+}
+
+// Zone describe la zona horaria por su diferencia con UTC: "GMT-03:00", o "UTC"
+func (f Formatter) Zone(t time.Time) string {
+	t = f.in(t)
+	if _, offset := t.Zone(); offset == 0 {
+		return "UTC"
+	}
+	return "GMT" + t.Format("-07:00")
+}
+
+// Money formatea un monto con el símbolo de la moneda: "1.234,50 €", "$1,234.50", "R$ 1.234,50"
+func (f Formatter) Money(amount float64, currency string) string {
+	currency = strings.ToUpper(currency)
+	format := f.format()
+
+	decimals := 2
+	if zeroDecimalCurrencies[currency] {
+		decimals = 0
+	}
+
+	negative := amount < 0
+	scale := math.Pow10(decimals)
+	units := int64(math.Round(math.Abs(amount) * scale))
+	whole := strconv.FormatInt(units/int64(scale), 10)
+
+	var grouped strings.Builder
+	for i, digit := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			grouped.WriteString(format.group)
+		}
+		grouped.WriteRune(digit)
+	}
+	number := grouped.String()
+	if decimals > 0 {
+		number += format.decimal + fmt.Sprintf("%0*d", decimals, units%int64(scale))
+	}
+	if negative {
+		number = "-" + number
+	}
+
+	symbol := currency
+	if override, ok := format.symbols[currency]; ok {
+		symbol = override
+	} else if common, ok := currencySymbols[currency]; ok {
+		symbol = common
+	}
+
+	switch {
+	case format.symbolFirst && (format.symbolSpace || symbol == currency):
+		return symbol + " " + number
+	case format.symbolFirst:
+		return symbol + number
+	default:
+		return number + " " + symbol
+	}
+}
+
+// Duration describe un plazo en minutos, horas o días, redondeando hacia arriba: "30 minutos", "2 hours"
+func (f Formatter) Duration(d time.Duration) string {
+	minutes := int((d + time.Minute - 1) / time.Minute)
+	switch {
+	case minutes < 60:
+		return f.plural(minutes, "minute")
+	case minutes < 48*60:
+		return f.plural((minutes+59)/60, "hour")
+	default:
+		return f.plural((minutes+24*60-1)/(24*60), "day")
+	}
+}
+
+// plural antepone la cantidad a la forma singular o plural de la unidad
+func (f Formatter) plural(n int, unit string) string {
+	if n == 1 {
+		return "1 " + f.T(unit)
+	}
+	return fmt.Sprintf("%d %s", n, f.T(unit+"s"))
+}
+
+// in pasa la fecha a la zona horaria del formateador, si tiene una
+func (f Formatter) in(t time.Time) time.Time {
+	if f.Location != nil {
+		return t.In(f.Location)
+	}
+	return t
+}
+
+// format devuelve el formato regional más específico disponible para el idioma
+func (f Formatter) format() regionalFormat {
+	for _, tag := range Chain(f.Locale) {
+		if format, ok := formats[tag]; ok {
+			return format
+		}
+	}
+	return formats[DefaultLocale]
+}
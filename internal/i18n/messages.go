@@ -0,0 +1,54 @@
+package i18n
+
+// messages contiene los textos que el servicio agrega a las notificaciones, por idioma.
+// Una clave que falta en un idioma se busca en el siguiente de su cadena de respaldo.
+var messages = map[string]map[string]string{
+	"es": {
+		"date_change":             "Fecha: %s → %s",
+		"time_change":             "Hora: %s → %s",
+		"location_change":         "Lugar: %s → %s",
+		"time_until":              "en %s",
+		"minute":                  "minuto",
+		"minutes":                 "minutos",
+		"hour":                    "hora",
+		"hours":                   "horas",
+		"day":                     "día",
+		"days":                    "días",
+		"payment_failed_reason":   "el medio de pago fue rechazado",
+		"payment_failed_steps":    "Puedes intentarlo de nuevo con otro medio de pago desde tu cuenta.",
+		"payment_failed_retry":    "Puedes intentarlo de nuevo en %s",
+		"reservation_description": "Reserva %s",
+	},
+	"en": {
+		"date_change":             "Date: %s → %s",
+		"time_change":             "Time: %s → %s",
+		"location_change":         "Venue: %s → %s",
+		"time_until":              "in %s",
+		"minute":                  "minute",
+		"minutes":                 "minutes",
+		"hour":                    "hour",
+		"hours":                   "hours",
+		"day":                     "day",
+		"days":                    "days",
+		"payment_failed_reason":   "the payment method was declined",
+		"payment_failed_steps":    "You can try again with a different payment method from your account.",
+		"payment_failed_retry":    "You can try again at %s",
+		"reservation_description": "Reservation %s",
+	},
+	"pt": {
+		"date_change":             "Data: %s → %s",
+		"time_change":             "Horário: %s → %s",
+		"location_change":         "Local: %s → %s",
+		"time_until":              "em %s",
+		"minute":                  "minuto",
+		"minutes":                 "minutos",
+		"hour":                    "hora",
+		"hours":                   "horas",
+		"day":                     "dia",
+		"days":                    "dias",
+		"payment_failed_reason":   "o meio de pagamento foi recusado",
+		"payment_failed_steps":    "Você pode tentar novamente com outro meio de pagamento na sua conta.",
+		"payment_failed_retry":    "Você pode tentar novamente em %s",
+		"reservation_description": "Reserva %s",
+	},
+}
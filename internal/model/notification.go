@@ -8,19 +8,29 @@ import (
 
 // Notification representa una notificación en el sistema
 type Notification struct {
-	ID         uuid.UUID              `json:"id" db:"id"`
-	Type       NotificationType       `json:"type" db:"type"`
-	Status     NotificationStatus     `json:"status" db:"status"`
-	Priority   NotificationPriority   `json:"priority" db:"priority"`
-	Recipient  string                 `json:"recipient" db:"recipient"`
-	Subject    string                 `json:"subject" db:"subject"`
-	Content    string                 `json:"content" db:"content"`
-	TemplateID string                 `json:"template_id" db:"template_id"`
-	Data       map[string]interface{} `json:"data" db:"data"`
-	SentAt     *time.Time             `json:"sent_at" db:"sent_at"`
-	ReadAt     *time.Time             `json:"read_at" db:"read_at"`
-	CreatedAt  time.Time              `json:"created_at" db:"created_at"`
-	UpdatedAt  time.Time              `json:"updated_at" db:"updated_at"`
+	ID              uuid.UUID              `json:"id" db:"id"`
+	Type            NotificationType       `json:"type" db:"type"`
+	Status          NotificationStatus     `json:"status" db:"status"`
+	Priority        NotificationPriority   `json:"priority" db:"priority"`
+	Recipient       string                 `json:"recipient" db:"recipient"`
+	Subject         string                 `json:"subject" db:"subject"`
+	Content         string                 `json:"content" db:"content"`
+	HTMLContent     string                 `json:"html_content,omitempty" db:"html_content"`
+	TemplateID      string                 `json:"template_id" db:"template_id"`
+	TemplateVersion int                    `json:"template_version,omitempty" db:"template_version"`
+	Locale          string                 `json:"locale,omitempty" db:"locale"`
+	Data            map[string]interface{} `json:"data" db:"data"`
+	Attachments     []Attachment           `json:"attachments,omitempty" db:"attachments"`
+	Deliveries      []Delivery             `json:"deliveries" db:"deliveries"`
+	Source          interface{}            `json:"-" db:"-"`
+	SendAt          *time.Time             `json:"send_at,omitempty" db:"send_at"`
+	LockedUntil     *time.Time             `json:"locked_until,omitempty" db:"locked_until"`
+	NextAttemptAt   *time.Time             `json:"next_attempt_at,omitempty" db:"next_attempt_at"`
+	Attempts        int                    `json:"attempts,omitempty" db:"attempts"`
+	SentAt          *time.Time             `json:"sent_at" db:"sent_at"`
+	ReadAt          *time.Time             `json:"read_at" db:"read_at"`
+	CreatedAt       time.Time              `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time              `json:"updated_at" db:"updated_at"`
 }
 
 // NotificationType define los tipos de notificaciones
@@ -41,6 +51,20 @@ const (
 	NotificationTypePasswordReset        NotificationType = "password_reset"
 )
 
+// transactionalTypes son los tipos que el usuario no puede desactivar porque
+// forman parte de una operación que él mismo inició
+var transactionalTypes = map[NotificationType]bool{
+	NotificationTypePasswordReset:   true,
+	NotificationTypePaymentReceived: true,
+	NotificationTypePaymentFailed:   true,
+	NotificationTypeTicketGenerated: true,
+}
+
+// IsTransactional indica si el tipo se envía siempre, sin tener en cuenta las preferencias
+func (t NotificationType) IsTransactional() bool {
+	return transactionalTypes[t]
+}
+
 // NotificationStatus define el estado de una notificación
 type NotificationStatus string
 
@@ -51,6 +75,12 @@ const (
 	NotificationStatusDelivered NotificationStatus = "delivered"
 	NotificationStatusFailed    NotificationStatus = "failed"
 	NotificationStatusRead      NotificationStatus = "read"
+	// NotificationStatusSuppressed indica que las preferencias del usuario impidieron el envío
+	NotificationStatusSuppressed NotificationStatus = "suppressed"
+	// NotificationStatusScheduled indica que la notificación espera su hora de envío (send_at)
+	NotificationStatusScheduled NotificationStatus = "scheduled"
+	// NotificationStatusCancelled indica que se canceló una notificación programada antes de enviarla
+	NotificationStatusCancelled NotificationStatus = "cancelled"
 )
 
 // NotificationPriority define la prioridad de una notificación
@@ -63,15 +93,64 @@ const (
 	NotificationPriorityUrgent NotificationPriority = "urgent"
 )
 
+// ChannelType identifica el canal por el que se entrega una notificación
+type ChannelType string
+
+const (
+	ChannelEmail   ChannelType = "email"
+	ChannelSMS     ChannelType = "sms"
+	ChannelPush    ChannelType = "push"
+	ChannelWebhook ChannelType = "webhook"
+)
+
+// ChannelRecipient indica un canal de entrega y la dirección del destinatario en ese canal
+type ChannelRecipient struct {
+	Channel   ChannelType `json:"channel" binding:"required"`
+	Recipient string      `json:"recipient" binding:"required"`
+}
+
+// Delivery representa el resultado de la entrega de una notificación por un canal
+type Delivery struct {
+	Channel           ChannelType        `json:"channel"`
+	Recipient         string             `json:"recipient"`
+	Status            NotificationStatus `json:"status"`
+	ProviderMessageID string             `json:"provider_message_id,omitempty"`
+	Error             string             `json:"error,omitempty"`
+	Metadata          map[string]string  `json:"metadata,omitempty"`
+	Attempts          int                `json:"attempts"`
+	SuppressedReason  SuppressionReason  `json:"suppressed_reason,omitempty"`
+	SentAt            *time.Time         `json:"sent_at,omitempty"`
+}
+
 // CreateNotificationRequest representa la solicitud para crear una notificación
 type CreateNotificationRequest struct {
-	Type       NotificationType       `json:"type" binding:"required"`
-	Priority   NotificationPriority   `json:"priority"`
-	Recipient  string                 `json:"recipient" binding:"required"`
-	Subject    string                 `json:"subject" binding:"required"`
-	Content    string                 `json:"content" binding:"required"`
-	TemplateID string                 `json:"template_id"`
-	Data       map[string]interface{} `json:"data"`
+	Type            NotificationType       `json:"type" binding:"required"`
+	Priority        NotificationPriority   `json:"priority"`
+	Recipient       string                 `json:"recipient"`
+	Channels        []ChannelRecipient     `json:"channels"`
+	Subject         string                 `json:"subject"`
+	Content         string                 `json:"content"`
+	HTMLContent     string                 `json:"html_content"`
+	TemplateID      string                 `json:"template_id"`
+	TemplateVersion int                    `json:"template_version"`
+	Data            map[string]interface{} `json:"data"`
+	Attachments     []Attachment           `json:"attachments"`
+	// Locale elige la variante de idioma de la plantilla; si no se indica, se usa el
+	// idioma de las preferencias del destinatario
+	Locale string `json:"locale"`
+	// SendAt programa el envío. Sin desplazamiento horario ("2026-03-01T09:00") se
+	// interpreta en Timezone o, si no se indica, en la zona horaria del destinatario.
+	SendAt   string `json:"send_at"`
+	Timezone string `json:"timezone"`
+}
+
+// Attachment representa un archivo adjunto de una notificación. El contenido
+// se indica en base64 (Content) o por referencia a una URL descargable (URL).
+type Attachment struct {
+	Filename    string `json:"filename" binding:"required"`
+	ContentType string `json:"content_type"`
+	Content     string `json:"content,omitempty"`
+	URL         string `json:"url,omitempty"`
 }
 
 // UpdateNotificationRequest representa la solicitud para actualizar una notificación
@@ -81,17 +160,80 @@ type UpdateNotificationRequest struct {
 	ReadAt *time.Time          `json:"read_at"`
 }
 
-// NotificationTemplate representa una plantilla de notificación
+// MarkReadRequest representa la solicitud para marcar varias notificaciones como leídas
+type MarkReadRequest struct {
+	NotificationIDs []string `json:"notification_ids" binding:"required"`
+}
+
+// NotificationTemplate representa una plantilla de notificación. Subject, Content y
+// HTMLContent están en el idioma Locale; Locales tiene las traducciones por idioma.
 type NotificationTemplate struct {
-	ID        uuid.UUID        `json:"id" db:"id"`
-	Name      string           `json:"name" db:"name"`
-	Type      NotificationType `json:"type" db:"type"`
-	Subject   string           `json:"subject" db:"subject"`
-	Content   string           `json:"content" db:"content"`
-	Variables []string         `json:"variables" db:"variables"`
-	IsActive  bool             `json:"is_active" db:"is_active"`
-	CreatedAt time.Time        `json:"created_at" db:"created_at"`
-	UpdatedAt time.Time        `json:"updated_at" db:"updated_at"`
+	ID          uuid.UUID                 `json:"id" db:"id"`
+	Name        string                    `json:"name" db:"name"`
+	Type        NotificationType          `json:"type" db:"type"`
+	Subject     string                    `json:"subject" db:"subject"`
+	Content     string                    `json:"content" db:"content"`
+	HTMLContent string                    `json:"html_content" db:"html_content"`
+	Locale      string                    `json:"locale" db:"locale"`
+	Locales     map[string]TemplateLocale `json:"locales,omitempty" db:"locales"`
+	Variables   []string                  `json:"variables" db:"variables"`
+	Version     int                       `json:"version" db:"version"`
+	IsActive    bool                      `json:"is_active" db:"is_active"`
+	CreatedAt   time.Time                 `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time                 `json:"updated_at" db:"updated_at"`
+}
+
+// TemplateLocale es la traducción de una plantilla a un idioma. Si no hay traducción al
+// idioma pedido se usa la del siguiente de la cadena de respaldo (pt-BR → pt → es).
+type TemplateLocale struct {
+	Subject     string `json:"subject"`
+	Content     string `json:"content,omitempty"`
+	HTMLContent string `json:"html_content,omitempty"`
+}
+
+// TemplateVersion representa una revisión inmutable de una plantilla
+type TemplateVersion struct {
+	TemplateID  uuid.UUID                 `json:"template_id" db:"template_id"`
+	Version     int                       `json:"version" db:"version"`
+	Name        string                    `json:"name" db:"name"`
+	Type        NotificationType          `json:"type" db:"type"`
+	Subject     string                    `json:"subject" db:"subject"`
+	Content     string                    `json:"content" db:"content"`
+	HTMLContent string                    `json:"html_content" db:"html_content"`
+	Locale      string                    `json:"locale" db:"locale"`
+	Locales     map[string]TemplateLocale `json:"locales,omitempty" db:"locales"`
+	Variables   []string                  `json:"variables" db:"variables"`
+	CreatedAt   time.Time                 `json:"created_at" db:"created_at"`
+}
+
+// RollbackTemplateRequest representa la solicitud para volver a una versión anterior
+type RollbackTemplateRequest struct {
+	Version int `json:"version" binding:"required"`
+}
+
+// CreateTemplateRequest representa la solicitud para crear una plantilla
+type CreateTemplateRequest struct {
+	Name        string                    `json:"name" binding:"required"`
+	Type        NotificationType          `json:"type" binding:"required"`
+	Subject     string                    `json:"subject" binding:"required"`
+	Content     string                    `json:"content"`
+	HTMLContent string                    `json:"html_content"`
+	Locale      string                    `json:"locale"`
+	Locales     map[string]TemplateLocale `json:"locales"`
+	Variables   []string                  `json:"variables"`
+	IsActive    *bool                     `json:"is_active"`
+}
+
+// UpdateTemplateRequest representa la solicitud para actualizar una plantilla
+type UpdateTemplateRequest struct {
+	Name        *string                   `json:"name"`
+	Type        *NotificationType         `json:"type"`
+	Subject     *string                   `json:"subject"`
+	Content     *string                   `json:"content"`
+	HTMLContent *string                   `json:"html_content"`
+	Locale      *string                   `json:"locale"`
+	Locales     map[string]TemplateLocale `json:"locales"`
+	Variables   []string                  `json:"variables"`
 }
 
 // EventNotification representa una notificación específica de evento
@@ -100,9 +242,25 @@ type EventNotification struct {
 	EventName string               `json:"event_name" binding:"required"`
 	EventDate time.Time            `json:"event_date" binding:"required"`
 	Location  string               `json:"location" binding:"required"`
-	Recipient string               `json:"recipient" binding:"required"`
+	Recipient string               `json:"recipient"`
 	Type      NotificationType     `json:"type" binding:"required"`
 	Priority  NotificationPriority `json:"priority"`
+	Channels  []ChannelRecipient   `json:"channels,omitempty"`
+	Locale    string               `json:"locale,omitempty"`
+	Timezone  string               `json:"timezone,omitempty"`
+}
+
+// EventUpdateNotification representa la solicitud para notificar a los asistentes un
+// cambio de fecha o de lugar del evento. Attendees se notifica al momento; el resto de
+// la audiencia del evento, con un envío masivo.
+type EventUpdateNotification struct {
+	EventName    string               `json:"event_name" binding:"required"`
+	OldEventDate time.Time            `json:"old_event_date" binding:"required"`
+	NewEventDate time.Time            `json:"new_event_date" binding:"required"`
+	OldLocation  string               `json:"old_location" binding:"required"`
+	NewLocation  string               `json:"new_location" binding:"required"`
+	Priority     NotificationPriority `json:"priority"`
+	Attendees    []ReminderAttendee   `json:"attendees,omitempty" binding:"dive"`
 }
 
 // ReservationNotification representa una notificación específica de reserva
@@ -115,6 +273,65 @@ type ReservationNotification struct {
 	Recipient     string               `json:"recipient" binding:"required"`
 	Type          NotificationType     `json:"type" binding:"required"`
 	Priority      NotificationPriority `json:"priority"`
+	Ticket        *Attachment          `json:"ticket,omitempty"`
+	Channels      []ChannelRecipient   `json:"channels,omitempty"`
+	Locale        string               `json:"locale,omitempty"`
+	Timezone      string               `json:"timezone,omitempty"`
+}
+
+// TicketNotification representa la notificación de una entrada emitida para una reserva.
+// QRCodeURL, si se indica, se adjunta como imagen.
+type TicketNotification struct {
+	ReservationID string               `json:"reservation_id" binding:"required"`
+	EventID       string               `json:"event_id" binding:"required"`
+	EventName     string               `json:"event_name" binding:"required"`
+	EventDate     time.Time            `json:"event_date" binding:"required"`
+	Location      string               `json:"location" binding:"required"`
+	Recipient     string               `json:"recipient" binding:"required"`
+	TicketCode    string               `json:"ticket_code" binding:"required"`
+	QRCodeURL     string               `json:"qr_code_url" binding:"omitempty,url"`
+	Priority      NotificationPriority `json:"priority"`
+	Ticket        *Attachment          `json:"ticket,omitempty"`
+	Channels      []ChannelRecipient   `json:"channels,omitempty"`
+	Locale        string               `json:"locale,omitempty"`
+	Timezone      string               `json:"timezone,omitempty"`
+}
+
+// PaymentNotification representa la notificación de un pago recibido o rechazado.
+// Amount está en la unidad de la moneda (150.5 son 150,50).
+type PaymentNotification struct {
+	PaymentID     string               `json:"payment_id" binding:"required"`
+	Recipient     string               `json:"recipient"`
+	Channels      []ChannelRecipient   `json:"channels,omitempty"`
+	Amount        float64              `json:"amount" binding:"required,gt=0"`
+	Currency      string               `json:"currency" binding:"required,len=3"`
+	ReceiptNumber string               `json:"receipt_number"`
+	Receipt       *Attachment          `json:"receipt,omitempty"`
+	ReservationID string               `json:"reservation_id"`
+	FailureReason string               `json:"failure_reason"`
+	RetryURL      string               `json:"retry_url" binding:"omitempty,url"`
+	Priority      NotificationPriority `json:"priority"`
+	Locale        string               `json:"locale,omitempty"`
+}
+
+// WelcomeNotification representa la bienvenida a un usuario recién registrado
+type WelcomeNotification struct {
+	Recipient string               `json:"recipient"`
+	Channels  []ChannelRecipient   `json:"channels,omitempty"`
+	UserName  string               `json:"user_name" binding:"required"`
+	Priority  NotificationPriority `json:"priority"`
+	Locale    string               `json:"locale,omitempty"`
+}
+
+// PasswordResetNotification representa el envío de un enlace para restablecer la contraseña
+type PasswordResetNotification struct {
+	Recipient string               `json:"recipient"`
+	Channels  []ChannelRecipient   `json:"channels,omitempty"`
+	ResetURL  string               `json:"reset_url" binding:"required,url"`
+	ExpiresAt time.Time            `json:"expires_at" binding:"required"`
+	Priority  NotificationPriority `json:"priority"`
+	Locale    string               `json:"locale,omitempty"`
+	Timezone  string               `json:"timezone,omitempty"`
 }
 
 // BulkNotificationRequest representa una solicitud para enviar múltiples notificaciones
@@ -122,5 +339,439 @@ type BulkNotificationRequest struct {
 	Notifications []CreateNotificationRequest `json:"notifications" binding:"required"`
 	TemplateID    string                      `json:"template_id"`
 	Priority      NotificationPriority        `json:"priority"`
+	SendAt        string                      `json:"send_at"`
+	Timezone      string                      `json:"timezone"`
+}
+
+// RescheduleNotificationRequest representa la solicitud para cambiar la hora de envío
+// de una notificación programada
+type RescheduleNotificationRequest struct {
+	SendAt   string `json:"send_at" binding:"required"`
+	Timezone string `json:"timezone"`
+}
+
+// WebhookSubscription representa un sistema externo suscrito a notificaciones por HTTP
+type WebhookSubscription struct {
+	ID         uuid.UUID          `json:"id" db:"id"`
+	Name       string             `json:"name" db:"name"`
+	URL        string             `json:"url" db:"url"`
+	Secret     string             `json:"secret,omitempty" db:"secret"`
+	EventTypes []NotificationType `json:"event_types" db:"event_types"`
+	IsActive   bool               `json:"is_active" db:"is_active"`
+	CreatedAt  time.Time          `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time          `json:"updated_at" db:"updated_at"`
+}
+
+// Subscribes indica si la suscripción recibe notificaciones del tipo dado
+func (w WebhookSubscription) Subscribes(notificationType NotificationType) bool {
+	for _, eventType := range w.EventTypes {
+		if eventType == notificationType {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDeliveryAttempt representa un intento de entrega de un webhook
+type WebhookDeliveryAttempt struct {
+	ID             uuid.UUID        `json:"id" db:"id"`
+	SubscriptionID uuid.UUID        `json:"subscription_id" db:"subscription_id"`
+	NotificationID string           `json:"notification_id" db:"notification_id"`
+	EventType      NotificationType `json:"event_type" db:"event_type"`
+	URL            string           `json:"url" db:"url"`
+	Attempt        int              `json:"attempt" db:"attempt"`
+	StatusCode     int              `json:"status_code,omitempty" db:"status_code"`
+	Success        bool             `json:"success" db:"success"`
+	Error          string           `json:"error,omitempty" db:"error"`
+	ResponseBody   string           `json:"response_body,omitempty" db:"response_body"`
+	DurationMs     int64            `json:"duration_ms" db:"duration_ms"`
+	CreatedAt      time.Time        `json:"created_at" db:"created_at"`
+}
+
+// CreateWebhookRequest representa la solicitud para crear una suscripción de webhook
+type CreateWebhookRequest struct {
+	Name       string             `json:"name" binding:"required"`
+	URL        string             `json:"url" binding:"required,url"`
+	Secret     string             `json:"secret"`
+	EventTypes []NotificationType `json:"event_types" binding:"required"`
+	IsActive   *bool              `json:"is_active"`
+}
+
+// UpdateWebhookRequest representa la solicitud para actualizar una suscripción de webhook
+type UpdateWebhookRequest struct {
+	Name       *string            `json:"name"`
+	URL        *string            `json:"url" binding:"omitempty,url"`
+	Secret     *string            `json:"secret"`
+	EventTypes []NotificationType `json:"event_types"`
+	IsActive   *bool              `json:"is_active"`
+}
+
+// DevicePlatform identifica la plataforma de un dispositivo móvil
+type DevicePlatform string
+
+const (
+	DevicePlatformIOS     DevicePlatform = "ios"
+	DevicePlatformAndroid DevicePlatform = "android"
+)
+
+// DeviceToken representa un dispositivo móvil registrado para recibir notificaciones push
+type DeviceToken struct {
+	UserID     string         `json:"user_id" db:"user_id"`
+	Token      string         `json:"token" db:"token"`
+	Platform   DevicePlatform `json:"platform" db:"platform"`
+	AppVersion string         `json:"app_version,omitempty" db:"app_version"`
+	CreatedAt  time.Time      `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at" db:"updated_at"`
+}
+
+// RegisterDeviceRequest representa la solicitud para registrar un dispositivo
+type RegisterDeviceRequest struct {
+	Token      string         `json:"token" binding:"required"`
+	Platform   DevicePlatform `json:"platform" binding:"required,oneof=ios android"`
+	AppVersion string         `json:"app_version"`
+}
+
+// RefreshDeviceRequest representa la solicitud para reemplazar el token de un dispositivo
+type RefreshDeviceRequest struct {
+	OldToken string `json:"old_token" binding:"required"`
+	Token    string `json:"token" binding:"required"`
 }
 
+// SuppressionReason indica por qué no se envió una notificación
+type SuppressionReason string
+
+const (
+	SuppressionTypeOptOut    SuppressionReason = "type_opted_out"
+	SuppressionChannelOptOut SuppressionReason = "channel_opted_out"
+	SuppressionQuietHours    SuppressionReason = "quiet_hours"
+	// Motivos de la lista de supresión de emails
+	SuppressionBounce    SuppressionReason = "address_bounced"
+	SuppressionComplaint SuppressionReason = "address_complained"
+	SuppressionManual    SuppressionReason = "manual"
+)
+
+// UserPreferences representa las preferencias de notificación de un destinatario.
+// Los tipos y canales que no aparecen en Types o Channels están habilitados.
+type UserPreferences struct {
+	Recipient  string                    `json:"recipient" db:"recipient"`
+	Language   string                    `json:"language,omitempty" db:"language"`
+	Timezone   string                    `json:"timezone,omitempty" db:"timezone"`
+	QuietHours *QuietHours               `json:"quiet_hours,omitempty" db:"quiet_hours"`
+	Types      map[NotificationType]bool `json:"types,omitempty" db:"types"`
+	Channels   map[ChannelType]bool      `json:"channels,omitempty" db:"channels"`
+	UpdatedAt  time.Time                 `json:"updated_at" db:"updated_at"`
+}
+
+// QuietHours es la franja horaria, en la zona del usuario, en la que no se le
+// interrumpe. Si Start es posterior a End la franja cruza la medianoche.
+type QuietHours struct {
+	Start string `json:"start" binding:"required"`
+	End   string `json:"end" binding:"required"`
+}
+
+// quietHoursChannels son los canales que interrumpen al usuario y respetan las horas de silencio
+var quietHoursChannels = map[ChannelType]bool{
+	ChannelPush: true,
+	ChannelSMS:  true,
+}
+
+// Allows indica si se puede enviar una notificación del tipo indicado por el canal
+// en el instante dado y, si no, el motivo. Los tipos transaccionales siempre se
+// envían y las urgentes ignoran las horas de silencio.
+func (p *UserPreferences) Allows(notificationType NotificationType, channel ChannelType, priority NotificationPriority, at time.Time) (bool, SuppressionReason) {
+	if p == nil || notificationType.IsTransactional() {
+		return true, ""
+	}
+	if enabled, ok := p.Types[notificationType]; ok && !enabled {
+		return false, SuppressionTypeOptOut
+	}
+	if enabled, ok := p.Channels[channel]; ok && !enabled {
+		return false, SuppressionChannelOptOut
+	}
+	if priority != NotificationPriorityUrgent && quietHoursChannels[channel] && p.InQuietHours(at) {
+		return false, SuppressionQuietHours
+	}
+	return true, ""
+}
+
+// InQuietHours indica si el instante cae dentro de las horas de silencio del usuario
+func (p *UserPreferences) InQuietHours(at time.Time) bool {
+	if p.QuietHours == nil {
+		return false
+	}
+	start, err := ParseClock(p.QuietHours.Start)
+	if err != nil {
+		return false
+	}
+	end, err := ParseClock(p.QuietHours.End)
+	if err != nil {
+		return false
+	}
+
+	location := time.UTC
+	if p.Timezone != "" {
+		if loaded, err := time.LoadLocation(p.Timezone); err == nil {
+			location = loaded
+		}
+	}
+	local := at.In(location)
+	minute := local.Hour()*60 + local.Minute()
+
+	if start <= end {
+		return minute >= start && minute < end
+	}
+	return minute >= start || minute < end
+}
+
+// ParseClock convierte una hora "HH:MM" en minutos desde la medianoche
+func ParseClock(value string) (int, error) {
+	clock, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, err
+	}
+	return clock.Hour()*60 + clock.Minute(), nil
+}
+
+// UpdatePreferencesRequest representa la solicitud para actualizar las preferencias.
+// Los campos omitidos se conservan; types y channels se combinan con los existentes.
+type UpdatePreferencesRequest struct {
+	Language   *string     `json:"language"`
+	Timezone   *string     `json:"timezone"`
+	QuietHours *QuietHours `json:"quiet_hours"`
+	// ClearQuietHours desactiva las horas de silencio
+	ClearQuietHours bool                      `json:"clear_quiet_hours"`
+	Types           map[NotificationType]bool `json:"types"`
+	Channels        map[ChannelType]bool      `json:"channels"`
+}
+
+// EmailSuppression representa una dirección a la que no se envían emails porque
+// rebotó de forma permanente, se quejó de spam o se añadió manualmente
+type EmailSuppression struct {
+	Email          string            `json:"email" db:"email"`
+	Reason         SuppressionReason `json:"reason" db:"reason"`
+	Details        string            `json:"details,omitempty" db:"details"`
+	NotificationID string            `json:"notification_id,omitempty" db:"notification_id"`
+	CreatedAt      time.Time         `json:"created_at" db:"created_at"`
+}
+
+// CreateSuppressionRequest representa la solicitud para suprimir una dirección manualmente
+type CreateSuppressionRequest struct {
+	Email   string `json:"email" binding:"required,email"`
+	Details string `json:"details"`
+}
+
+// ReminderType indica con cuánta anticipación al evento se envía un recordatorio
+type ReminderType string
+
+const (
+	ReminderType24hBefore   ReminderType = "24h_before"
+	ReminderType1hBefore    ReminderType = "1h_before"
+	ReminderType15minBefore ReminderType = "15min_before"
+)
+
+// DefaultReminderTypes son los recordatorios que se programan si no se indican otros
+var DefaultReminderTypes = []ReminderType{ReminderType24hBefore, ReminderType1hBefore, ReminderType15minBefore}
+
+// reminderOffsets contiene la anticipación de cada tipo de recordatorio
+var reminderOffsets = map[ReminderType]time.Duration{
+	ReminderType24hBefore:   24 * time.Hour,
+	ReminderType1hBefore:    time.Hour,
+	ReminderType15minBefore: 15 * time.Minute,
+}
+
+// Offset devuelve la anticipación del recordatorio; ok es false si el tipo no es válido
+func (t ReminderType) Offset() (time.Duration, bool) {
+	offset, ok := reminderOffsets[t]
+	return offset, ok
+}
+
+// ReminderStatus representa el estado de un recordatorio programado
+type ReminderStatus string
+
+const (
+	// ReminderStatusPending espera su hora de envío en DynamoDB
+	ReminderStatusPending ReminderStatus = "pending"
+	// ReminderStatusEnqueued ya está en la cola de recordatorios, con el retardo que le falta
+	ReminderStatusEnqueued  ReminderStatus = "enqueued"
+	ReminderStatusSent      ReminderStatus = "sent"
+	ReminderStatusCancelled ReminderStatus = "cancelled"
+	// ReminderStatusSkipped no se envió porque su hora pasó, por ejemplo al adelantar el evento
+	ReminderStatusSkipped ReminderStatus = "skipped"
+)
+
+// ScheduledReminder representa un recordatorio de evento programado para un asistente.
+// Su ID combina el tipo y el destinatario, así que cada asistente tiene uno por tipo.
+type ScheduledReminder struct {
+	EventID      string             `json:"event_id" db:"event_id"`
+	ID           string             `json:"id" db:"reminder_id"`
+	EventName    string             `json:"event_name" db:"event_name"`
+	EventDate    time.Time          `json:"event_date" db:"event_date"`
+	Location     string             `json:"location" db:"location"`
+	Recipient    string             `json:"recipient" db:"recipient"`
+	Channels     []ChannelRecipient `json:"channels,omitempty" db:"channels"`
+	ReminderType ReminderType       `json:"reminder_type" db:"reminder_type"`
+	FireAt       time.Time          `json:"fire_at" db:"fire_at"`
+	Status       ReminderStatus     `json:"status" db:"status"`
+	CreatedAt    time.Time          `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time          `json:"updated_at" db:"updated_at"`
+	SentAt       *time.Time         `json:"sent_at,omitempty" db:"sent_at"`
+}
+
+// ReminderID construye el ID del recordatorio de un destinatario
+func ReminderID(reminderType ReminderType, recipient string) string {
+	return string(reminderType) + "#" + recipient
+}
+
+// ReminderAttendee es un asistente que recibe los recordatorios del evento
+type ReminderAttendee struct {
+	Recipient string             `json:"recipient" binding:"required"`
+	Channels  []ChannelRecipient `json:"channels,omitempty"`
+}
+
+// ScheduleRemindersRequest representa la solicitud para programar los recordatorios de
+// un evento. Sin reminder_types se programan los de 24 horas, 1 hora y 15 minutos antes.
+type ScheduleRemindersRequest struct {
+	EventName     string             `json:"event_name" binding:"required"`
+	EventDate     time.Time          `json:"event_date" binding:"required"`
+	Location      string             `json:"location" binding:"required"`
+	Attendees     []ReminderAttendee `json:"attendees" binding:"required,min=1,dive"`
+	ReminderTypes []ReminderType     `json:"reminder_types,omitempty"`
+}
+
+// RescheduleRemindersRequest representa la solicitud para mover los recordatorios
+// de un evento a una nueva fecha y, opcionalmente, a un nuevo lugar
+type RescheduleRemindersRequest struct {
+	EventDate time.Time `json:"event_date" binding:"required"`
+	Location  string    `json:"location"`
+}
+
+// EventAudienceMember es un destinatario con al menos una reserva para el evento
+type EventAudienceMember struct {
+	EventID        string             `json:"event_id" db:"event_id"`
+	Recipient      string             `json:"recipient" db:"recipient"`
+	ReservationIDs []string           `json:"reservation_ids" db:"reservation_ids"`
+	Channels       []ChannelRecipient `json:"channels,omitempty" db:"channels"`
+	UpdatedAt      time.Time          `json:"updated_at" db:"updated_at"`
+}
+
+// FanoutJobStatus representa el estado de un envío a toda la audiencia de un evento
+type FanoutJobStatus string
+
+const (
+	FanoutJobStatusRunning   FanoutJobStatus = "running"
+	FanoutJobStatusCompleted FanoutJobStatus = "completed"
+	FanoutJobStatusFailed    FanoutJobStatus = "failed"
+)
+
+// FanoutJob es el envío de una notificación de evento a todos los que tienen reserva.
+// Se encola por lotes y guarda su avance, de modo que si se interrumpe se retoma
+// desde el último lote encolado.
+type FanoutJob struct {
+	ID                string               `json:"id" db:"id"`
+	EventID           string               `json:"event_id" db:"event_id"`
+	Type              NotificationType     `json:"type" db:"type"`
+	Status            FanoutJobStatus      `json:"status" db:"status"`
+	EventName         string               `json:"event_name" db:"event_name"`
+	EventDate         time.Time            `json:"event_date" db:"event_date"`
+	Location          string               `json:"location" db:"location"`
+	PreviousEventDate *time.Time           `json:"previous_event_date,omitempty" db:"previous_event_date"`
+	PreviousLocation  string               `json:"previous_location,omitempty" db:"previous_location"`
+	Priority          NotificationPriority `json:"priority" db:"priority"`
+	// Exclude son destinatarios que ya se notificaron fuera del envío masivo
+	Exclude  []string `json:"-" db:"exclude"`
+	Cursor   string   `json:"-" db:"cursor"`
+	Total    int      `json:"total" db:"total"`
+	Enqueued int      `json:"enqueued" db:"enqueued"`
+	// Skipped son los destinatarios excluidos o con canales inválidos
+	Skipped     int        `json:"skipped" db:"skipped"`
+	Batches     int        `json:"batches" db:"batches"`
+	Error       string     `json:"error,omitempty" db:"error"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at" db:"updated_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty" db:"completed_at"`
+}
+
+// Progress devuelve el porcentaje de destinatarios ya procesados (encolados u omitidos)
+func (j FanoutJob) Progress() float64 {
+	if j.Status == FanoutJobStatusCompleted {
+		return 100
+	}
+	if j.Total == 0 {
+		return 0
+	}
+	progress := float64(j.Enqueued+j.Skipped) * 100 / float64(j.Total)
+	if progress > 100 {
+		// Se sumaron reservas después de contar la audiencia
+		progress = 100
+	}
+	return progress
+}
+
+// IdempotencyStatus indica si la operación asociada a una clave de idempotencia terminó
+type IdempotencyStatus string
+
+const (
+	IdempotencyStatusInProgress IdempotencyStatus = "in_progress"
+	IdempotencyStatusCompleted  IdempotencyStatus = "completed"
+)
+
+// IdempotencyRecord registra una operación hecha con una clave de idempotencia, ya sea
+// una solicitud HTTP con Idempotency-Key o un mensaje de cola. Mientras está en curso
+// la clave queda tomada hasta LockedUntil; al terminar guarda la respuesta original
+// para devolverla en las repeticiones. Se borra sola al llegar a ExpiresAt.
+type IdempotencyRecord struct {
+	Key            string            `json:"key" db:"idempotency_key"`
+	Status         IdempotencyStatus `json:"status" db:"status"`
+	RequestHash    string            `json:"request_hash,omitempty" db:"request_hash"`
+	ResponseStatus int               `json:"response_status,omitempty" db:"response_status"`
+	ResponseBody   string            `json:"response_body,omitempty" db:"response_body"`
+	LockedUntil    time.Time         `json:"locked_until" db:"locked_until"`
+	CreatedAt      time.Time         `json:"created_at" db:"created_at"`
+	ExpiresAt      time.Time         `json:"expires_at" db:"expires_at"`
+}
+
+// DeadLetterMessage es un mensaje que pasó a la cola de mensajes fallidos (DLQ) de su
+// cola porque falló con un error permanente o agotó sus reintentos
+type DeadLetterMessage struct {
+	MessageID       string      `json:"message_id"`
+	QueueType       string      `json:"queue_type"`
+	SourceMessageID string      `json:"source_message_id,omitempty"`
+	FailureReason   string      `json:"failure_reason,omitempty"`
+	FailedAt        *time.Time  `json:"failed_at,omitempty"`
+	RetryCount      int         `json:"retry_count"`
+	Body            interface{} `json:"body"`
+}
+
+// QueueStatus es el estado de una cola SQS según sus atributos aproximados
+type QueueStatus struct {
+	QueueType string `json:"queue_type"`
+	Visible   int64  `json:"visible"`
+	InFlight  int64  `json:"in_flight"`
+	Delayed   int64  `json:"delayed"`
+	// OldestMessageAgeSeconds es la edad del mensaje más antiguo que recibieron los
+	// consumidores de esta instancia en el último minuto; falta si la cola está vacía o
+	// no recibieron ninguno
+	OldestMessageAgeSeconds *int64 `json:"oldest_message_age_seconds,omitempty"`
+	// DeadLetters son los mensajes en la cola de mensajes fallidos, si la cola tiene una
+	DeadLetters *int64 `json:"dead_letters,omitempty"`
+}
+
+// QueueMetrics resume lo que procesaron los consumidores de una cola en esta instancia
+// desde que arrancó el servicio; se llevan en memoria y se pierden al reiniciar. Cada mensaje procesado termina completado (entregado, o descartado por
+// repetido o cancelado), reintentado (reencolado con retardo), en la cola de mensajes
+// fallidos, o fallido si quedó en la cola para que SQS vuelva a entregarlo. FailureRate
+// es la proporción de mensajes procesados que no se completaron.
+type QueueMetrics struct {
+	QueueType           string     `json:"queue_type"`
+	Since               time.Time  `json:"since"`
+	Processed           int64      `json:"processed"`
+	Completed           int64      `json:"completed"`
+	Retried             int64      `json:"retried"`
+	DeadLettered        int64      `json:"dead_lettered"`
+	Failed              int64      `json:"failed"`
+	FailureRate         float64    `json:"failure_rate"`
+	ThroughputPerMinute int64      `json:"throughput_per_minute"`
+	AverageProcessingMs float64    `json:"average_processing_ms"`
+	LastMessageAt       *time.Time `json:"last_message_at,omitempty"`
+	LastFailureAt       *time.Time `json:"last_failure_at,omitempty"`
+}
@@ -0,0 +1,203 @@
+package push
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// APNsProductionURL es el endpoint de producción de Apple Push Notification service
+	APNsProductionURL = "https://api.push.apple.com"
+	// APNsSandboxURL es el endpoint para aplicaciones de desarrollo
+	APNsSandboxURL = "https://api.sandbox.push.apple.com"
+
+	// apnsTokenLifetime renueva el JWT antes de la hora que admite APNs
+	apnsTokenLifetime = 50 * time.Minute
+)
+
+// apnsInvalidReasons son los rechazos de APNs que invalidan el token del dispositivo
+var apnsInvalidReasons = map[string]bool{
+	"BadDeviceToken":         true,
+	"Unregistered":           true,
+	"DeviceTokenNotForTopic": true,
+}
+
+// APNsTransport envía notificaciones a dispositivos iOS con la API HTTP/2 de APNs,
+// autenticándose con un JWT firmado con la clave .p8 del equipo
+type APNsTransport struct {
+	baseURL    string
+	topic      string
+	keyID      string
+	teamID     string
+	key        *ecdsa.PrivateKey
+	httpClient *http.Client
+
+	mu          sync.Mutex
+	token       string
+	tokenIssued time.Time
+}
+
+// NewAPNsTransport crea el transporte de APNs. topic es el bundle ID de la aplicación.
+// Sin clave las peticiones no se autentican, lo que solo sirve contra un servidor falso.
+func NewAPNsTransport(baseURL, topic, keyID, teamID string, key *ecdsa.PrivateKey) *APNsTransport {
+	if baseURL == "" {
+		baseURL = APNsProductionURL
+	}
+	return &APNsTransport{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		topic:      topic,
+		keyID:      keyID,
+		teamID:     teamID,
+		key:        key,
+		httpClient: &http.Client{Timeout: defaultTimeout},
+	}
+}
+
+// LoadAPNsKey lee la clave privada .p8 (PKCS#8 en PEM) descargada de Apple
+func LoadAPNsKey(path string) (*ecdsa.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading APNs key: %w", err)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("APNs key is not PEM encoded")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing APNs key: %w", err)
+	}
+	key, ok := parsed.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("APNs key is not an ECDSA key")
+	}
+	return key, nil
+}
+
+// Send entrega el mensaje al dispositivo y devuelve el apns-id asignado
+func (t *APNsTransport) Send(ctx context.Context, token string, message Message) (string, error) {
+	payload := map[string]interface{}{
+		"aps": map[string]interface{}{
+			"alert": map[string]string{
+				"title": message.Title,
+				"body":  message.Body,
+			},
+			"sound": "default",
+		},
+	}
+	// Los datos propios van en la raíz del payload, fuera de "aps"
+	for key, value := range message.Data {
+		if key != "aps" {
+			payload[key] = value
+		}
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return "", fmt.Errorf("error encoding APNs payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.baseURL+"/3/device/"+token, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("apns-push-type", "alert")
+	if t.topic != "" {
+		req.Header.Set("apns-topic", t.topic)
+	}
+	if message.High {
+		req.Header.Set("apns-priority", "10")
+	} else {
+		req.Header.Set("apns-priority", "5")
+	}
+	if message.CollapseID != "" {
+		req.Header.Set("apns-collapse-id", message.CollapseID)
+	}
+	if t.key != nil {
+		jwt, err := t.authToken()
+		if err != nil {
+			return "", err
+		}
+		req.Header.Set("Authorization", "bearer "+jwt)
+	}
+
+	resp, err := t.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("error sending APNs request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusOK {
+		return resp.Header.Get("apns-id"), nil
+	}
+
+	var apnsErr struct {
+		Reason string `json:"reason"`
+	}
+	responseBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	if err := json.Unmarshal(responseBody, &apnsErr); err != nil || apnsErr.Reason == "" {
+		apnsErr.Reason = strings.TrimSpace(string(responseBody))
+	}
+
+	// Un 403 por token de proveedor caducado se resuelve renovando el JWT
+	if apnsErr.Reason == "ExpiredProviderToken" {
+		t.mu.Lock()
+		t.token = ""
+		t.mu.Unlock()
+	}
+
+	return "", &ProviderError{
+		StatusCode: resp.StatusCode,
+		Reason:     apnsErr.Reason,
+		invalid:    resp.StatusCode == http.StatusGone || apnsInvalidReasons[apnsErr.Reason],
+	}
+}
+
+// authToken devuelve el JWT de proveedor, firmando uno nuevo cuando caduca
+func (t *APNsTransport) authToken() (string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.token != "" && time.Since(t.tokenIssued) < apnsTokenLifetime {
+		return t.token, nil
+	}
+
+	now := time.Now()
+	token, err := signJWT(
+		map[string]interface{}{"alg": "ES256", "kid": t.keyID},
+		map[string]interface{}{"iss": t.teamID, "iat": now.Unix()},
+		func(digest []byte) ([]byte, error) {
+			r, s, err := ecdsa.Sign(rand.Reader, t.key, digest)
+			if err != nil {
+				return nil, err
+			}
+			// ES256 usa la concatenación r||s de 32 bytes cada uno, no DER
+			signature := make([]byte, 64)
+			r.FillBytes(signature[:32])
+			s.FillBytes(signature[32:])
+			return signature, nil
+		},
+	)
+	if err != nil {
+		return "", err
+	}
+
+	t.token = token
+	t.tokenIssued = now
+	return token, nil
+}
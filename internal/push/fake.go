@@ -0,0 +1,159 @@
+package push
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// FakeInvalidTokenPrefix marca los tokens que el servidor falso rechaza como inválidos
+const FakeInvalidTokenPrefix = "invalid"
+
+// FakeMessage es una notificación recibida por el servidor falso
+type FakeMessage struct {
+	ID         string          `json:"id"`
+	Provider   string          `json:"provider"`
+	Token      string          `json:"token"`
+	Headers    http.Header     `json:"headers"`
+	Payload    json.RawMessage `json:"payload"`
+	ReceivedAt time.Time       `json:"received_at"`
+}
+
+// FakeServer emula las APIs de APNs y FCM para desarrollo y pruebas. Acepta
+// cualquier token salvo los que empiezan por FakeInvalidTokenPrefix, que se
+// responden como dispositivos dados de baja. GET /messages lista lo recibido y
+// DELETE /messages lo vacía.
+type FakeServer struct {
+	mu       sync.Mutex
+	messages []FakeMessage
+}
+
+// NewFakeServer crea un servidor falso de push
+func NewFakeServer() *FakeServer {
+	return &FakeServer{}
+}
+
+// Messages devuelve las notificaciones recibidas
+func (s *FakeServer) Messages() []FakeMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]FakeMessage(nil), s.messages...)
+}
+
+// Reset descarta las notificaciones recibidas
+func (s *FakeServer) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.messages = nil
+}
+
+// ServeHTTP atiende las rutas de APNs (/3/device/:token) y FCM (/v1/projects/:id/messages:send)
+func (s *FakeServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.URL.Path == "/messages" && r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, s.Messages())
+	case r.URL.Path == "/messages" && r.Method == http.MethodDelete:
+		s.Reset()
+		w.WriteHeader(http.StatusNoContent)
+	case strings.HasPrefix(r.URL.Path, "/3/device/") && r.Method == http.MethodPost:
+		s.serveAPNs(w, r)
+	case strings.HasPrefix(r.URL.Path, "/v1/projects/") && strings.HasSuffix(r.URL.Path, "/messages:send") && r.Method == http.MethodPost:
+		s.serveFCM(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// serveAPNs responde como APNs: 200 con apns-id o 410 Unregistered
+func (s *FakeServer) serveAPNs(w http.ResponseWriter, r *http.Request) {
+	token := strings.TrimPrefix(r.URL.Path, "/3/device/")
+	payload, err := io.ReadAll(r.Body)
+	if err != nil || !json.Valid(payload) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"reason": "BadPayload"})
+		return
+	}
+	if token == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"reason": "MissingDeviceToken"})
+		return
+	}
+	if strings.HasPrefix(token, FakeInvalidTokenPrefix) {
+		writeJSON(w, http.StatusGone, map[string]interface{}{
+			"reason":    "Unregistered",
+			"timestamp": time.Now().UnixMilli(),
+		})
+		return
+	}
+
+	id := s.record("apns", token, r.Header, payload)
+	w.Header().Set("apns-id", id)
+	w.WriteHeader(http.StatusOK)
+}
+
+// serveFCM responde como la API v1 de FCM: 200 con el nombre del mensaje o 404 UNREGISTERED
+func (s *FakeServer) serveFCM(w http.ResponseWriter, r *http.Request) {
+	payload, err := io.ReadAll(r.Body)
+	var body struct {
+		Message struct {
+			Token string `json:"token"`
+		} `json:"message"`
+	}
+	if err != nil || json.Unmarshal(payload, &body) != nil || body.Message.Token == "" {
+		writeJSON(w, http.StatusBadRequest, fakeFCMError(http.StatusBadRequest, "INVALID_ARGUMENT",
+			"The registration token is not a valid FCM registration token", "INVALID_ARGUMENT"))
+		return
+	}
+	if strings.HasPrefix(body.Message.Token, FakeInvalidTokenPrefix) {
+		writeJSON(w, http.StatusNotFound, fakeFCMError(http.StatusNotFound, "NOT_FOUND",
+			"Requested entity was not found.", "UNREGISTERED"))
+		return
+	}
+
+	id := s.record("fcm", body.Message.Token, r.Header, payload)
+	project := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/v1/projects/"), "/messages:send")
+	writeJSON(w, http.StatusOK, map[string]string{"name": "projects/" + project + "/messages/" + id})
+}
+
+// record guarda la notificación recibida y devuelve su ID
+func (s *FakeServer) record(provider, token string, headers http.Header, payload []byte) string {
+	message := FakeMessage{
+		ID:         uuid.New().String(),
+		Provider:   provider,
+		Token:      token,
+		Headers:    headers.Clone(),
+		Payload:    payload,
+		ReceivedAt: time.Now(),
+	}
+
+	s.mu.Lock()
+	s.messages = append(s.messages, message)
+	s.mu.Unlock()
+
+	return message.ID
+}
+
+// fakeFCMError arma un cuerpo de error con el formato de la API v1
+func fakeFCMError(code int, status, message, errorCode string) map[string]interface{} {
+	return map[string]interface{}{
+		"error": map[string]interface{}{
+			"code":    code,
+			"message": message,
+			"status":  status,
+			"details": []map[string]string{{
+				"@type":     "type.googleapis.com/google.firebase.fcm.v1.FcmError",
+				"errorCode": errorCode,
+			}},
+		},
+	}
+}
+
+// writeJSON responde con el valor codificado como JSON
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(value)
+}
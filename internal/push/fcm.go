@@ -0,0 +1,262 @@
+package push
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// FCMBaseURL es el endpoint de la API HTTP v1 de Firebase Cloud Messaging
+	FCMBaseURL = "https://fcm.googleapis.com"
+
+	// fcmScope es el permiso OAuth necesario para enviar mensajes
+	fcmScope = "https://www.googleapis.com/auth/firebase.messaging"
+)
+
+// FCMCredentials son los campos de la cuenta de servicio de Firebase que se usan
+type FCMCredentials struct {
+	ProjectID   string `json:"project_id"`
+	ClientEmail string `json:"client_email"`
+	PrivateKey  string `json:"private_key"`
+	TokenURI    string `json:"token_uri"`
+
+	key *rsa.PrivateKey
+}
+
+// LoadFCMCredentials lee el JSON de la cuenta de servicio descargado de Firebase
+func LoadFCMCredentials(path string) (*FCMCredentials, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading FCM credentials: %w", err)
+	}
+
+	var credentials FCMCredentials
+	if err := json.Unmarshal(data, &credentials); err != nil {
+		return nil, fmt.Errorf("error parsing FCM credentials: %w", err)
+	}
+	if credentials.ClientEmail == "" || credentials.PrivateKey == "" {
+		return nil, errors.New("FCM credentials must include client_email and private_key")
+	}
+	if credentials.TokenURI == "" {
+		credentials.TokenURI = "https://oauth2.googleapis.com/token"
+	}
+
+	block, _ := pem.Decode([]byte(credentials.PrivateKey))
+	if block == nil {
+		return nil, errors.New("FCM private key is not PEM encoded")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing FCM private key: %w", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("FCM private key is not an RSA key")
+	}
+	credentials.key = key
+
+	return &credentials, nil
+}
+
+// FCMTransport envía notificaciones a dispositivos Android con la API HTTP v1 de
+// FCM, obteniendo tokens de acceso OAuth con la cuenta de servicio
+type FCMTransport struct {
+	baseURL     string
+	projectID   string
+	credentials *FCMCredentials
+	httpClient  *http.Client
+
+	mu          sync.Mutex
+	accessToken string
+	expiresAt   time.Time
+}
+
+// NewFCMTransport crea el transporte de FCM. Si projectID está vacío se toma de las
+// credenciales. Sin credenciales las peticiones no se autentican, lo que solo sirve
+// contra un servidor falso.
+func NewFCMTransport(baseURL, projectID string, credentials *FCMCredentials) *FCMTransport {
+	if baseURL == "" {
+		baseURL = FCMBaseURL
+	}
+	if projectID == "" && credentials != nil {
+		projectID = credentials.ProjectID
+	}
+	return &FCMTransport{
+		baseURL:     strings.TrimRight(baseURL, "/"),
+		projectID:   projectID,
+		credentials: credentials,
+		httpClient:  &http.Client{Timeout: defaultTimeout},
+	}
+}
+
+// fcmError es el cuerpo de error de la API v1
+type fcmError struct {
+	Error struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+		Status  string `json:"status"`
+		Details []struct {
+			Type      string `json:"@type"`
+			ErrorCode string `json:"errorCode"`
+		} `json:"details"`
+	} `json:"error"`
+}
+
+// Send entrega el mensaje al dispositivo y devuelve el nombre del mensaje en FCM
+func (t *FCMTransport) Send(ctx context.Context, token string, message Message) (string, error) {
+	androidPriority := "normal"
+	if message.High {
+		androidPriority = "high"
+	}
+	android := map[string]interface{}{"priority": androidPriority}
+	if message.CollapseID != "" {
+		android["collapse_key"] = message.CollapseID
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"message": map[string]interface{}{
+			"token": token,
+			"notification": map[string]string{
+				"title": message.Title,
+				"body":  message.Body,
+			},
+			"data":    message.Data,
+			"android": android,
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("error encoding FCM payload: %w", err)
+	}
+
+	endpoint := fmt.Sprintf("%s/v1/projects/%s/messages:send", t.baseURL, url.PathEscape(t.projectID))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if t.credentials != nil {
+		accessToken, err := t.token(ctx)
+		if err != nil {
+			return "", err
+		}
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+	}
+
+	resp, err := t.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("error sending FCM request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	responseBody, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if resp.StatusCode == http.StatusOK {
+		var result struct {
+			Name string `json:"name"`
+		}
+		json.Unmarshal(responseBody, &result)
+		return result.Name, nil
+	}
+
+	var fcmErr fcmError
+	reason := strings.TrimSpace(string(responseBody))
+	invalid := false
+	if err := json.Unmarshal(responseBody, &fcmErr); err == nil && fcmErr.Error.Status != "" {
+		reason = fcmErr.Error.Status + ": " + fcmErr.Error.Message
+		for _, detail := range fcmErr.Error.Details {
+			if detail.ErrorCode == "UNREGISTERED" {
+				invalid = true
+			}
+		}
+		// Un token mal formado se informa como argumento inválido sobre message.token
+		if fcmErr.Error.Status == "INVALID_ARGUMENT" && strings.Contains(fcmErr.Error.Message, "registration token") {
+			invalid = true
+		}
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		t.mu.Lock()
+		t.accessToken = ""
+		t.mu.Unlock()
+	}
+
+	return "", &ProviderError{
+		StatusCode: resp.StatusCode,
+		Reason:     reason,
+		invalid:    invalid || resp.StatusCode == http.StatusNotFound,
+	}
+}
+
+// token devuelve un token de acceso OAuth válido, solicitando uno nuevo cuando caduca
+func (t *FCMTransport) token(ctx context.Context) (string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.accessToken != "" && time.Now().Before(t.expiresAt) {
+		return t.accessToken, nil
+	}
+
+	now := time.Now()
+	assertion, err := signJWT(
+		map[string]interface{}{"alg": "RS256", "typ": "JWT"},
+		map[string]interface{}{
+			"iss":   t.credentials.ClientEmail,
+			"scope": fcmScope,
+			"aud":   t.credentials.TokenURI,
+			"iat":   now.Unix(),
+			"exp":   now.Add(time.Hour).Unix(),
+		},
+		func(digest []byte) ([]byte, error) {
+			return rsa.SignPKCS1v15(rand.Reader, t.credentials.key, crypto.SHA256, digest)
+		},
+	)
+	if err != nil {
+		return "", err
+	}
+
+	form := url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {assertion},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.credentials.TokenURI, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := t.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("error requesting FCM access token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if resp.StatusCode != http.StatusOK {
+		responseBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return "", fmt.Errorf("FCM token endpoint returned %d: %s", resp.StatusCode, strings.TrimSpace(string(responseBody)))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("error decoding FCM access token: %w", err)
+	}
+
+	// Renovar un minuto antes de que caduque
+	t.accessToken = result.AccessToken
+	t.expiresAt = now.Add(time.Duration(result.ExpiresIn)*time.Second - time.Minute)
+	return t.accessToken, nil
+}
@@ -0,0 +1,82 @@
+package push
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// ErrInvalidToken indica que el proveedor rechazó el token del dispositivo de forma
+// definitiva (desinstalado, expirado o de otra aplicación) y debe eliminarse
+var ErrInvalidToken = errors.New("invalid device token")
+
+// defaultTimeout limita cada petición a los proveedores de push
+const defaultTimeout = 10 * time.Second
+
+// Message es el contenido de una notificación push, independiente del proveedor
+type Message struct {
+	Title string
+	Body  string
+	// Data viaja como pares clave/valor de texto hasta la aplicación
+	Data map[string]string
+	// High entrega de inmediato aunque el dispositivo esté en ahorro de energía
+	High bool
+	// CollapseID hace que una notificación reemplace a otra anterior con el mismo ID
+	CollapseID string
+}
+
+// Transport entrega un mensaje a un token de dispositivo y devuelve el ID del proveedor
+type Transport interface {
+	Send(ctx context.Context, token string, message Message) (string, error)
+}
+
+// ProviderError es un rechazo del proveedor. Los errores de token inválido
+// además envuelven ErrInvalidToken.
+type ProviderError struct {
+	StatusCode int
+	Reason     string
+	invalid    bool
+}
+
+func (e *ProviderError) Error() string {
+	return fmt.Sprintf("push provider returned %d: %s", e.StatusCode, e.Reason)
+}
+
+// Unwrap permite comprobar con errors.Is si el token es inválido
+func (e *ProviderError) Unwrap() error {
+	if e.invalid {
+		return ErrInvalidToken
+	}
+	return nil
+}
+
+// Retryable indica si el error es transitorio (rate limiting o fallo del proveedor)
+func (e *ProviderError) Retryable() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+}
+
+// signJWT codifica y firma un JWT compacto con el algoritmo indicado en la cabecera
+func signJWT(header, claims map[string]interface{}, sign func(digest []byte) ([]byte, error)) (string, error) {
+	headerJSON, err := json.Marshal(header)
+	if err != nil {
+		return "", err
+	}
+	claimsJSON, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	unsigned := base64.RawURLEncoding.EncodeToString(headerJSON) + "." + base64.RawURLEncoding.EncodeToString(claimsJSON)
+	digest := sha256.Sum256([]byte(unsigned))
+
+	signature, err := sign(digest[:])
+	if err != nil {
+		return "", fmt.Errorf("error signing JWT: %w", err)
+	}
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
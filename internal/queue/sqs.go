@@ -4,11 +4,17 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strconv"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
 )
 
+// MaxDelay es el máximo retardo de entrega que admite SQS
+const MaxDelay = 15 * time.Minute
+
 // NotificationMessage representa un mensaje de notificación en la cola SQS
 type NotificationMessage struct {
 	ID         string                 `json:"id"`
@@ -25,38 +31,86 @@ type NotificationMessage struct {
 
 // EventNotificationMessage representa un mensaje de notificación de evento
 type EventNotificationMessage struct {
-	EventID    string `json:"event_id"`
-	EventName  string `json:"event_name"`
-	EventDate  string `json:"event_date"`
-	Location   string `json:"location"`
-	Recipient  string `json:"recipient"`
-	Type       string `json:"type"`
-	Priority   string `json:"priority"`
-	TemplateID string `json:"template_id"`
+	EventID    string             `json:"event_id"`
+	EventName  string             `json:"event_name"`
+	EventDate  string             `json:"event_date"`
+	Location   string             `json:"location"`
+	Recipient  string             `json:"recipient"`
+	Type       string             `json:"type"`
+	Priority   string             `json:"priority"`
+	TemplateID string             `json:"template_id"`
+	Channels   []ChannelRecipient `json:"channels,omitempty"`
+	// Fecha y lugar anteriores, en las notificaciones de evento actualizado
+	PreviousEventDate string `json:"previous_event_date,omitempty"`
+	PreviousLocation  string `json:"previous_location,omitempty"`
+	// Idioma y zona horaria del destinatario; si faltan se toman de sus preferencias
+	Locale   string `json:"locale,omitempty"`
+	Timezone string `json:"timezone,omitempty"`
+	// IdempotencyKey identifica el envío; las entregas repetidas con la misma clave se descartan
+	IdempotencyKey string `json:"idempotency_key,omitempty"`
+	// RetryCount cuenta los reintentos; el consumidor lo incrementa al reencolar el mensaje
+	RetryCount int `json:"retry_count,omitempty"`
 }
 
 // ReservationNotificationMessage representa un mensaje de notificación de reserva
 type ReservationNotificationMessage struct {
-	ReservationID string `json:"reservation_id"`
-	EventID       string `json:"event_id"`
-	EventName     string `json:"event_name"`
-	EventDate     string `json:"event_date"`
-	Location      string `json:"location"`
-	Recipient     string `json:"recipient"`
-	Type          string `json:"type"`
-	Priority      string `json:"priority"`
-	TemplateID    string `json:"template_id"`
+	ReservationID string             `json:"reservation_id"`
+	EventID       string             `json:"event_id"`
+	EventName     string             `json:"event_name"`
+	EventDate     string             `json:"event_date"`
+	Location      string             `json:"location"`
+	Recipient     string             `json:"recipient"`
+	Type          string             `json:"type"`
+	Priority      string             `json:"priority"`
+	TemplateID    string             `json:"template_id"`
+	Attachments   []Attachment       `json:"attachments,omitempty"`
+	Channels      []ChannelRecipient `json:"channels,omitempty"`
+	// Código de la entrada y URL de su QR, en las notificaciones de entrada emitida
+	TicketCode string `json:"ticket_code,omitempty"`
+	QRCodeURL  string `json:"qr_code_url,omitempty"`
+	// Idioma y zona horaria del destinatario; si faltan se toman de sus preferencias
+	Locale   string `json:"locale,omitempty"`
+	Timezone string `json:"timezone,omitempty"`
+	// IdempotencyKey identifica el envío; las entregas repetidas con la misma clave se descartan
+	IdempotencyKey string `json:"idempotency_key,omitempty"`
+	// RetryCount cuenta los reintentos; el consumidor lo incrementa al reencolar el mensaje
+	RetryCount int `json:"retry_count,omitempty"`
+}
+
+// Attachment representa un adjunto dentro de un mensaje de la cola. Por el
+// límite de tamaño de SQS se prefiere la referencia por URL al contenido en base64.
+type Attachment struct {
+	Filename    string `json:"filename"`
+	ContentType string `json:"content_type"`
+	Content     string `json:"content,omitempty"`
+	URL         string `json:"url,omitempty"`
 }
 
 // ReminderMessage representa un mensaje de recordatorio
 type ReminderMessage struct {
-	EventID      string `json:"event_id"`
-	EventName    string `json:"event_name"`
-	EventDate    string `json:"event_date"`
-	Location     string `json:"location"`
-	Recipient    string `json:"recipient"`
-	ReminderType string `json:"reminder_type"` // "24h_before", "1h_before", "15min_before"
-	TemplateID   string `json:"template_id"`
+	EventID      string             `json:"event_id"`
+	EventName    string             `json:"event_name"`
+	EventDate    string             `json:"event_date"`
+	Location     string             `json:"location"`
+	Recipient    string             `json:"recipient"`
+	ReminderType string             `json:"reminder_type"` // "24h_before", "1h_before", "15min_before"
+	TemplateID   string             `json:"template_id"`
+	Channels     []ChannelRecipient `json:"channels,omitempty"`
+	// ReminderID identifica el recordatorio programado que originó el mensaje, si lo hay
+	ReminderID string `json:"reminder_id,omitempty"`
+	// Idioma y zona horaria del destinatario; si faltan se toman de sus preferencias
+	Locale   string `json:"locale,omitempty"`
+	Timezone string `json:"timezone,omitempty"`
+	// IdempotencyKey identifica el envío; las entregas repetidas con la misma clave se descartan
+	IdempotencyKey string `json:"idempotency_key,omitempty"`
+	// RetryCount cuenta los reintentos; el consumidor lo incrementa al reencolar el mensaje
+	RetryCount int `json:"retry_count,omitempty"`
+}
+
+// ChannelRecipient indica un canal de entrega y la dirección del destinatario en ese canal
+type ChannelRecipient struct {
+	Channel   string `json:"channel"`
+	Recipient string `json:"recipient"`
 }
 
 // SQSClient maneja las operaciones con las colas SQS
@@ -88,7 +142,7 @@ func (s *SQSClient) SendNotificationMessage(ctx context.Context, msg Notificatio
 	_, err = s.Client.SendMessage(ctx, &sqs.SendMessageInput{
 		QueueUrl:    aws.String(s.QueueURL),
 		MessageBody: aws.String(string(body)),
-		MessageAttributes: map[string]sqs.MessageAttributeValue{
+		MessageAttributes: map[string]types.MessageAttributeValue{
 			"Type": {
 				DataType:    aws.String("String"),
 				StringValue: aws.String(msg.Type),
@@ -119,7 +173,7 @@ func (s *SQSClient) SendEventNotification(ctx context.Context, msg EventNotifica
 	_, err = s.Client.SendMessage(ctx, &sqs.SendMessageInput{
 		QueueUrl:    aws.String(s.QueueURL),
 		MessageBody: aws.String(string(body)),
-		MessageAttributes: map[string]sqs.MessageAttributeValue{
+		MessageAttributes: map[string]types.MessageAttributeValue{
 			"Type": {
 				DataType:    aws.String("String"),
 				StringValue: aws.String("event_notification"),
@@ -140,6 +194,61 @@ func (s *SQSClient) SendEventNotification(ctx context.Context, msg EventNotifica
 	return nil
 }
 
+// MaxBatchSize es el máximo de mensajes que admite SQS en un envío por lotes
+const MaxBatchSize = 10
+
+// SendEventNotificationBatch envía hasta MaxBatchSize notificaciones de evento en una
+// sola llamada. Devuelve los índices de los mensajes que SQS no aceptó, para reintentarlos.
+func (s *SQSClient) SendEventNotificationBatch(ctx context.Context, msgs []EventNotificationMessage) ([]int, error) {
+	if len(msgs) > MaxBatchSize {
+		return nil, fmt.Errorf("batch of %d messages exceeds the SQS limit of %d", len(msgs), MaxBatchSize)
+	}
+
+	entries := make([]types.SendMessageBatchRequestEntry, len(msgs))
+	for i, msg := range msgs {
+		body, err := json.Marshal(msg)
+		if err != nil {
+			return nil, fmt.Errorf("error marshaling event notification message: %w", err)
+		}
+		entries[i] = types.SendMessageBatchRequestEntry{
+			Id:          aws.String(strconv.Itoa(i)),
+			MessageBody: aws.String(string(body)),
+			MessageAttributes: map[string]types.MessageAttributeValue{
+				"Type": {
+					DataType:    aws.String("String"),
+					StringValue: aws.String("event_notification"),
+				},
+				"EventID": {
+					DataType:    aws.String("String"),
+					StringValue: aws.String(msg.EventID),
+				},
+				"Priority": {
+					DataType:    aws.String("String"),
+					StringValue: aws.String(msg.Priority),
+				},
+			},
+		}
+	}
+
+	result, err := s.Client.SendMessageBatch(ctx, &sqs.SendMessageBatchInput{
+		QueueUrl: aws.String(s.QueueURL),
+		Entries:  entries,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error sending event notification batch: %w", err)
+	}
+
+	failed := make([]int, 0, len(result.Failed))
+	for _, entry := range result.Failed {
+		index, err := strconv.Atoi(aws.ToString(entry.Id))
+		if err != nil {
+			return nil, fmt.Errorf("unexpected batch entry id %q", aws.ToString(entry.Id))
+		}
+		failed = append(failed, index)
+	}
+	return failed, nil
+}
+
 // SendReservationNotification envía una notificación de reserva
 func (s *SQSClient) SendReservationNotification(ctx context.Context, msg ReservationNotificationMessage) error {
 	body, err := json.Marshal(msg)
@@ -150,7 +259,7 @@ func (s *SQSClient) SendReservationNotification(ctx context.Context, msg Reserva
 	_, err = s.Client.SendMessage(ctx, &sqs.SendMessageInput{
 		QueueUrl:    aws.String(s.QueueURL),
 		MessageBody: aws.String(string(body)),
-		MessageAttributes: map[string]sqs.MessageAttributeValue{
+		MessageAttributes: map[string]types.MessageAttributeValue{
 			"Type": {
 				DataType:    aws.String("String"),
 				StringValue: aws.String("reservation_notification"),
@@ -173,15 +282,26 @@ func (s *SQSClient) SendReservationNotification(ctx context.Context, msg Reserva
 
 // SendReminderMessage envía un mensaje de recordatorio
 func (s *SQSClient) SendReminderMessage(ctx context.Context, msg ReminderMessage) error {
+	return s.SendDelayedReminderMessage(ctx, msg, 0)
+}
+
+// SendDelayedReminderMessage envía un mensaje de recordatorio que no se entrega hasta
+// que pasa delay. SQS admite como máximo 15 minutos; un retardo mayor se recorta.
+func (s *SQSClient) SendDelayedReminderMessage(ctx context.Context, msg ReminderMessage, delay time.Duration) error {
+	if delay > MaxDelay {
+		delay = MaxDelay
+	}
+
 	body, err := json.Marshal(msg)
 	if err != nil {
 		return fmt.Errorf("error marshaling reminder message: %w", err)
 	}
 
 	_, err = s.Client.SendMessage(ctx, &sqs.SendMessageInput{
-		QueueUrl:    aws.String(s.QueueURL),
-		MessageBody: aws.String(string(body)),
-		MessageAttributes: map[string]sqs.MessageAttributeValue{
+		QueueUrl:     aws.String(s.QueueURL),
+		MessageBody:  aws.String(string(body)),
+		DelaySeconds: int32(delay.Seconds()),
+		MessageAttributes: map[string]types.MessageAttributeValue{
 			"Type": {
 				DataType:    aws.String("String"),
 				StringValue: aws.String("reminder"),
@@ -203,7 +323,7 @@ func (s *SQSClient) SendReminderMessage(ctx context.Context, msg ReminderMessage
 }
 
 // ReceiveMessages recibe mensajes de la cola
-func (s *SQSClient) ReceiveMessages(ctx context.Context, maxMessages int32) ([]sqs.Message, error) {
+func (s *SQSClient) ReceiveMessages(ctx context.Context, maxMessages int32) ([]types.Message, error) {
 	resp, err := s.Client.ReceiveMessage(ctx, &sqs.ReceiveMessageInput{
 		QueueUrl:            aws.String(s.QueueURL),
 		MaxNumberOfMessages: maxMessages,
@@ -211,6 +331,9 @@ func (s *SQSClient) ReceiveMessages(ctx context.Context, maxMessages int32) ([]s
 		MessageAttributeNames: []string{
 			"All",
 		},
+		MessageSystemAttributeNames: []types.MessageSystemAttributeName{
+			types.MessageSystemAttributeNameSentTimestamp,
+		},
 	})
 	if err != nil {
 		return nil, fmt.Errorf("error receiving SQS messages: %w", err)
@@ -219,6 +342,54 @@ func (s *SQSClient) ReceiveMessages(ctx context.Context, maxMessages int32) ([]s
 	return resp.Messages, nil
 }
 
+// SentAt devuelve cuándo se envió el mensaje a la cola, según su atributo SentTimestamp
+func SentAt(message types.Message) (time.Time, bool) {
+	millis, err := strconv.ParseInt(message.Attributes[string(types.MessageSystemAttributeNameSentTimestamp)], 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.UnixMilli(millis), true
+}
+
+// ReceiveMessagesWithVisibility recibe mensajes sin esperar a que lleguen nuevos y los
+// oculta durante visibility. Con visibility 0 SQS aplica la invisibilidad por defecto de
+// la cola; para liberarlos antes hay que usar ChangeMessageVisibility con 0.
+func (s *SQSClient) ReceiveMessagesWithVisibility(ctx context.Context, maxMessages int32, visibility time.Duration) ([]types.Message, error) {
+	resp, err := s.Client.ReceiveMessage(ctx, &sqs.ReceiveMessageInput{
+		QueueUrl:            aws.String(s.QueueURL),
+		MaxNumberOfMessages: maxMessages,
+		VisibilityTimeout:   int32(visibility.Seconds()),
+		WaitTimeSeconds:     1,
+		MessageAttributeNames: []string{
+			"All",
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error receiving SQS messages: %w", err)
+	}
+
+	return resp.Messages, nil
+}
+
+// SendRawMessage envía un cuerpo ya codificado con sus atributos, que no se entrega
+// hasta que pasa delay. SQS admite como máximo 15 minutos; un retardo mayor se recorta.
+func (s *SQSClient) SendRawMessage(ctx context.Context, body string, attributes map[string]types.MessageAttributeValue, delay time.Duration) error {
+	if delay > MaxDelay {
+		delay = MaxDelay
+	}
+
+	_, err := s.Client.SendMessage(ctx, &sqs.SendMessageInput{
+		QueueUrl:          aws.String(s.QueueURL),
+		MessageBody:       aws.String(body),
+		DelaySeconds:      int32(delay.Seconds()),
+		MessageAttributes: attributes,
+	})
+	if err != nil {
+		return fmt.Errorf("error sending SQS message: %w", err)
+	}
+	return nil
+}
+
 // DeleteMessage elimina un mensaje de la cola
 func (s *SQSClient) DeleteMessage(ctx context.Context, receiptHandle string) error {
 	_, err := s.Client.DeleteMessage(ctx, &sqs.DeleteMessageInput{
@@ -231,11 +402,24 @@ func (s *SQSClient) DeleteMessage(ctx context.Context, receiptHandle string) err
 	return nil
 }
 
+// ChangeMessageVisibility extiende el tiempo de invisibilidad de un mensaje en proceso
+func (s *SQSClient) ChangeMessageVisibility(ctx context.Context, receiptHandle string, timeoutSeconds int32) error {
+	_, err := s.Client.ChangeMessageVisibility(ctx, &sqs.ChangeMessageVisibilityInput{
+		QueueUrl:          aws.String(s.QueueURL),
+		ReceiptHandle:     aws.String(receiptHandle),
+		VisibilityTimeout: timeoutSeconds,
+	})
+	if err != nil {
+		return fmt.Errorf("error changing message visibility: %w", err)
+	}
+	return nil
+}
+
 // GetQueueAttributes obtiene atributos de la cola
 func (s *SQSClient) GetQueueAttributes(ctx context.Context) (*sqs.GetQueueAttributesOutput, error) {
 	resp, err := s.Client.GetQueueAttributes(ctx, &sqs.GetQueueAttributesInput{
 		QueueUrl: aws.String(s.QueueURL),
-		AttributeNames: []sqs.QueueAttributeName{
+		AttributeNames: []types.QueueAttributeName{
 			"ApproximateNumberOfMessages",
 			"ApproximateNumberOfMessagesNotVisible",
 			"ApproximateNumberOfMessagesDelayed",
@@ -258,4 +442,3 @@ func (s *SQSClient) PurgeQueue(ctx context.Context) error {
 	}
 	return nil
 }
-
@@ -0,0 +1,55 @@
+package realtime
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidToken indica que el token de conexión no es válido o expiró
+var ErrInvalidToken = errors.New("invalid stream token")
+
+// IssueToken genera un token firmado que autoriza a recibir en tiempo real las
+// notificaciones de un destinatario. Formato: base64url(destinatario).expiración.firma
+func IssueToken(secret, recipient string, ttl time.Duration) string {
+	payload := base64.RawURLEncoding.EncodeToString([]byte(recipient)) + "." +
+		strconv.FormatInt(time.Now().Add(ttl).Unix(), 10)
+	return payload + "." + signToken(secret, payload)
+}
+
+// VerifyToken valida la firma y la expiración del token y devuelve el destinatario
+func VerifyToken(secret, token string) (string, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return "", ErrInvalidToken
+	}
+
+	payload := parts[0] + "." + parts[1]
+	if !hmac.Equal([]byte(signToken(secret, payload)), []byte(parts[2])) {
+		return "", ErrInvalidToken
+	}
+
+	expires, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil || time.Now().Unix() > expires {
+		return "", ErrInvalidToken
+	}
+
+	recipient, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil || len(recipient) == 0 {
+		return "", ErrInvalidToken
+	}
+
+	return string(recipient), nil
+}
+
+// signToken firma el contenido del token con HMAC-SHA256
+func signToken(secret, payload string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
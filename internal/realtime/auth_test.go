@@ -0,0 +1,49 @@
+package realtime
+
+import (
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// signedToken construye un token con la expiración indicada, firmado con el secreto
+func signedToken(secret, recipient string, expires int64) string {
+	payload := base64.RawURLEncoding.EncodeToString([]byte(recipient)) + "." + strconv.FormatInt(expires, 10)
+	return payload + "." + signToken(secret, payload)
+}
+
+func TestVerifyToken(t *testing.T) {
+	const secret = "stream-secret"
+	valid := IssueToken(secret, "user@example.com", time.Minute)
+	future := time.Now().Add(time.Hour).Unix()
+
+	tests := []struct {
+		name  string
+		token string
+		want  string
+		err   error
+	}{
+		{"token válido", valid, "user@example.com", nil},
+		{"destinatario con puntos", IssueToken(secret, "a.b.c", time.Minute), "a.b.c", nil},
+		{"firma de otro secreto", IssueToken("otro-secreto", "user@example.com", time.Minute), "", ErrInvalidToken},
+		{"firma alterada", valid[:len(valid)-1] + "0", "", ErrInvalidToken},
+		{"expirado", signedToken(secret, "user@example.com", time.Now().Add(-time.Minute).Unix()), "", ErrInvalidToken},
+		{"expiración no numérica", "dXNlcg.mañana." + signToken(secret, "dXNlcg.mañana"), "", ErrInvalidToken},
+		{"destinatario vacío", signedToken(secret, "", future), "", ErrInvalidToken},
+		{"destinatario sin base64", "%%%." + strconv.FormatInt(future, 10) + "." + signToken(secret, "%%%."+strconv.FormatInt(future, 10)), "", ErrInvalidToken},
+		{"sin firma", "dXNlcg." + strconv.FormatInt(future, 10), "", ErrInvalidToken},
+		{"partes de más", valid + ".extra", "", ErrInvalidToken},
+		{"vacío", "", "", ErrInvalidToken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := VerifyToken(secret, tt.token)
+			if !errors.Is(err, tt.err) || got != tt.want {
+				t.Errorf("VerifyToken(%q) = %q, %v; se esperaba %q, %v", tt.token, got, err, tt.want, tt.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,253 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/google/uuid"
+	"github.com/jhonathanssegura/ticket-notification/internal/model"
+)
+
+// publishBuffer es cuántas notificaciones pueden esperar a publicarse en SNS; si se
+// llena, las nuevas solo se reparten en esta instancia
+const publishBuffer = 1000
+
+// envelope es el mensaje que se publica en el topic SNS compartido por las instancias
+type envelope struct {
+	InstanceID   string             `json:"instance_id"`
+	Notification model.Notification `json:"notification"`
+}
+
+// Broker publica las notificaciones guardadas en las conexiones de esta
+// instancia y, si hay un topic SNS configurado, en las de las demás. La publicación
+// en SNS se hace en segundo plano para no retrasar a quien guarda la notificación.
+// Cada instancia crea al arrancar una cola SQS propia suscrita al topic y la elimina
+// al apagarse.
+type Broker struct {
+	hub        *Hub
+	instanceID string
+	snsClient  *sns.Client
+	sqsClient  *sqs.Client
+	topicARN   string
+
+	outgoing        chan model.Notification
+	queueURL        string
+	subscriptionARN string
+	workers         sync.WaitGroup
+	done            chan struct{}
+}
+
+// NewBroker crea el broker. Con topicARN vacío solo se reparte dentro de la instancia.
+func NewBroker(hub *Hub, snsClient *sns.Client, sqsClient *sqs.Client, topicARN string) *Broker {
+	return &Broker{
+		hub:        hub,
+		instanceID: uuid.New().String(),
+		snsClient:  snsClient,
+		sqsClient:  sqsClient,
+		topicARN:   topicARN,
+		outgoing:   make(chan model.Notification, publishBuffer),
+		done:       make(chan struct{}),
+	}
+}
+
+// Publish reparte la notificación a las conexiones locales y la deja en cola para
+// publicarla al resto de instancias; no espera a SNS
+func (b *Broker) Publish(notification model.Notification) {
+	// Las suprimidas por las preferencias del usuario y las que aún no se enviaron no se le muestran
+	switch notification.Status {
+	case model.NotificationStatusSuppressed, model.NotificationStatusScheduled, model.NotificationStatusCancelled,
+		model.NotificationStatusPending, model.NotificationStatusSending:
+		return
+	}
+
+	b.hub.Publish(notification)
+
+	if b.topicARN == "" {
+		return
+	}
+
+	select {
+	case b.outgoing <- notification:
+	default:
+		log.Printf("⚠️  Cola de publicación en tiempo real llena: la notificación %s solo se reparte en esta instancia", notification.ID)
+	}
+}
+
+// publish publica en SNS las notificaciones en cola hasta que se cancele el contexto
+func (b *Broker) publish(ctx context.Context) {
+	defer b.workers.Done()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case notification := <-b.outgoing:
+			b.publishRemote(ctx, notification)
+		}
+	}
+}
+
+// publishRemote publica la notificación en el topic SNS para el resto de instancias
+func (b *Broker) publishRemote(ctx context.Context, notification model.Notification) {
+	body, err := json.Marshal(envelope{InstanceID: b.instanceID, Notification: notification})
+	if err != nil {
+		log.Printf("Error codificando notificación %s para tiempo real: %v", notification.ID, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if _, err := b.snsClient.Publish(ctx, &sns.PublishInput{
+		TopicArn: aws.String(b.topicARN),
+		Message:  aws.String(string(body)),
+	}); err != nil {
+		log.Printf("Error publicando notificación %s en SNS: %v", notification.ID, err)
+	}
+}
+
+// Start empieza a publicar en el topic y crea la cola de esta instancia, la suscribe al
+// topic y empieza a consumirla
+func (b *Broker) Start(ctx context.Context) error {
+	if b.topicARN == "" {
+		close(b.done)
+		return nil
+	}
+
+	// Aunque esta instancia no llegue a suscribirse, las demás reciben lo que publica
+	b.workers.Add(2)
+	go b.publish(ctx)
+	go func() {
+		b.workers.Wait()
+		close(b.done)
+	}()
+	consuming := false
+	defer func() {
+		if !consuming {
+			b.workers.Done()
+		}
+	}()
+
+	queue, err := b.sqsClient.CreateQueue(ctx, &sqs.CreateQueueInput{
+		QueueName: aws.String("notification-stream-" + b.instanceID),
+		Attributes: map[string]string{
+			"MessageRetentionPeriod": "300",
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("error creating stream queue: %w", err)
+	}
+	b.queueURL = aws.ToString(queue.QueueUrl)
+
+	attributes, err := b.sqsClient.GetQueueAttributes(ctx, &sqs.GetQueueAttributesInput{
+		QueueUrl:       queue.QueueUrl,
+		AttributeNames: []sqstypes.QueueAttributeName{sqstypes.QueueAttributeNameQueueArn},
+	})
+	if err != nil {
+		b.Close(ctx)
+		return fmt.Errorf("error getting stream queue ARN: %w", err)
+	}
+	queueARN := attributes.Attributes[string(sqstypes.QueueAttributeNameQueueArn)]
+
+	// Permitir que el topic escriba en la cola
+	policy := fmt.Sprintf(`{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":{"Service":"sns.amazonaws.com"},"Action":"sqs:SendMessage","Resource":%q,"Condition":{"ArnEquals":{"aws:SourceArn":%q}}}]}`,
+		queueARN, b.topicARN)
+	if _, err := b.sqsClient.SetQueueAttributes(ctx, &sqs.SetQueueAttributesInput{
+		QueueUrl:   queue.QueueUrl,
+		Attributes: map[string]string{"Policy": policy},
+	}); err != nil {
+		b.Close(ctx)
+		return fmt.Errorf("error setting stream queue policy: %w", err)
+	}
+
+	subscription, err := b.snsClient.Subscribe(ctx, &sns.SubscribeInput{
+		TopicArn:   aws.String(b.topicARN),
+		Protocol:   aws.String("sqs"),
+		Endpoint:   aws.String(queueARN),
+		Attributes: map[string]string{"RawMessageDelivery": "true"},
+	})
+	if err != nil {
+		b.Close(ctx)
+		return fmt.Errorf("error subscribing stream queue: %w", err)
+	}
+	b.subscriptionARN = aws.ToString(subscription.SubscriptionArn)
+
+	consuming = true
+	go b.consume(ctx)
+	return nil
+}
+
+// Close elimina la suscripción y la cola de esta instancia
+func (b *Broker) Close(ctx context.Context) {
+	if b.subscriptionARN != "" {
+		if _, err := b.snsClient.Unsubscribe(ctx, &sns.UnsubscribeInput{
+			SubscriptionArn: aws.String(b.subscriptionARN),
+		}); err != nil {
+			log.Printf("Error eliminando suscripción %s: %v", b.subscriptionARN, err)
+		}
+		b.subscriptionARN = ""
+	}
+
+	if b.queueURL != "" {
+		if _, err := b.sqsClient.DeleteQueue(ctx, &sqs.DeleteQueueInput{
+			QueueUrl: aws.String(b.queueURL),
+		}); err != nil {
+			log.Printf("Error eliminando cola %s: %v", b.queueURL, err)
+		}
+		b.queueURL = ""
+	}
+}
+
+// Done se cierra cuando el broker deja de consumir la cola y de publicar
+func (b *Broker) Done() <-chan struct{} {
+	return b.done
+}
+
+// consume recibe las notificaciones publicadas por otras instancias hasta que se cancele el contexto
+func (b *Broker) consume(ctx context.Context) {
+	defer b.workers.Done()
+
+	for ctx.Err() == nil {
+		result, err := b.sqsClient.ReceiveMessage(ctx, &sqs.ReceiveMessageInput{
+			QueueUrl:            aws.String(b.queueURL),
+			MaxNumberOfMessages: 10,
+			WaitTimeSeconds:     20,
+		})
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Printf("Error recibiendo notificaciones en tiempo real: %v", err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(5 * time.Second):
+			}
+			continue
+		}
+
+		for _, message := range result.Messages {
+			var msg envelope
+			if err := json.Unmarshal([]byte(aws.ToString(message.Body)), &msg); err != nil {
+				log.Printf("Mensaje de tiempo real inválido: %v", err)
+			} else if msg.InstanceID != b.instanceID {
+				// Las notificaciones propias ya se repartieron al publicarlas
+				b.hub.Publish(msg.Notification)
+			}
+
+			if _, err := b.sqsClient.DeleteMessage(context.WithoutCancel(ctx), &sqs.DeleteMessageInput{
+				QueueUrl:      aws.String(b.queueURL),
+				ReceiptHandle: message.ReceiptHandle,
+			}); err != nil {
+				log.Printf("Error eliminando mensaje de tiempo real: %v", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package realtime
+
+import (
+	"sync"
+
+	"github.com/jhonathanssegura/ticket-notification/internal/model"
+)
+
+// subscriberBuffer es la cantidad de notificaciones pendientes que admite una conexión
+const subscriberBuffer = 32
+
+// Subscriber es una conexión en tiempo real de un destinatario
+type Subscriber struct {
+	Recipient string
+	events    chan model.Notification
+	dropped   chan struct{}
+	dropOnce  sync.Once
+}
+
+// Events devuelve el canal por el que llegan las notificaciones nuevas
+func (s *Subscriber) Events() <-chan model.Notification {
+	return s.events
+}
+
+// Dropped se cierra cuando el hub desconecta al suscriptor, ya sea porque no
+// consume lo bastante rápido o porque el servicio se está apagando. El cliente
+// debe reconectar usando Last-Event-ID para recuperar lo que se perdió.
+func (s *Subscriber) Dropped() <-chan struct{} {
+	return s.dropped
+}
+
+func (s *Subscriber) drop() {
+	s.dropOnce.Do(func() { close(s.dropped) })
+}
+
+// Hub reparte las notificaciones a las conexiones abiertas en esta instancia
+type Hub struct {
+	mu          sync.RWMutex
+	subscribers map[string]map[*Subscriber]struct{}
+	closed      bool
+}
+
+// NewHub crea un hub vacío
+func NewHub() *Hub {
+	return &Hub{subscribers: make(map[string]map[*Subscriber]struct{})}
+}
+
+// Subscribe registra una conexión para las notificaciones de un destinatario
+func (h *Hub) Subscribe(recipient string) *Subscriber {
+	subscriber := &Subscriber{
+		Recipient: recipient,
+		events:    make(chan model.Notification, subscriberBuffer),
+		dropped:   make(chan struct{}),
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		subscriber.drop()
+		return subscriber
+	}
+	if h.subscribers[recipient] == nil {
+		h.subscribers[recipient] = make(map[*Subscriber]struct{})
+	}
+	h.subscribers[recipient][subscriber] = struct{}{}
+	return subscriber
+}
+
+// Unsubscribe elimina una conexión del hub
+func (h *Hub) Unsubscribe(subscriber *Subscriber) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if subscribers, ok := h.subscribers[subscriber.Recipient]; ok {
+		delete(subscribers, subscriber)
+		if len(subscribers) == 0 {
+			delete(h.subscribers, subscriber.Recipient)
+		}
+	}
+	subscriber.drop()
+}
+
+// Publish entrega la notificación a las conexiones de su destinatario sin
+// bloquear; las conexiones con el buffer lleno se desconectan
+func (h *Hub) Publish(notification model.Notification) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for subscriber := range h.subscribers[notification.Recipient] {
+		select {
+		case subscriber.events <- notification:
+		default:
+			subscriber.drop()
+		}
+	}
+}
+
+// Connections devuelve el número de conexiones abiertas
+func (h *Hub) Connections() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	total := 0
+	for _, subscribers := range h.subscribers {
+		total += len(subscribers)
+	}
+	return total
+}
+
+// Close desconecta todas las conexiones y rechaza las nuevas
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.closed = true
+	for _, subscribers := range h.subscribers {
+		for subscriber := range subscribers {
+			subscriber.drop()
+		}
+	}
+}
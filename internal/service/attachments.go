@@ -0,0 +1,100 @@
+package service
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jhonathanssegura/ticket-notification/internal/db"
+	"github.com/jhonathanssegura/ticket-notification/internal/email"
+	"github.com/jhonathanssegura/ticket-notification/internal/i18n"
+	"github.com/jhonathanssegura/ticket-notification/internal/model"
+	"github.com/jhonathanssegura/ticket-notification/internal/queue"
+)
+
+const (
+	// maxQueuedAttachmentSize limita el base64 que viaja dentro de un mensaje SQS (máx. 256 KB)
+	maxQueuedAttachmentSize = 200 * 1024
+	// calendarContentType es el tipo MIME de las invitaciones iCalendar
+	calendarContentType = "text/calendar; charset=UTF-8; method=PUBLISH"
+)
+
+// ErrAttachmentTooLarge indica que el base64 de los adjuntos no cabe en la notificación
+// guardada, que lo necesita para reenviarla si hay que retomar la entrega
+var ErrAttachmentTooLarge = errors.New("attachment content too large")
+
+// checkStoredAttachments comprueba que el base64 de los adjuntos se pueda guardar con la notificación
+func checkStoredAttachments(attachments []model.Attachment) error {
+	if size := db.AttachmentContentSize(attachments); size > db.MaxStoredAttachmentContent {
+		return fmt.Errorf("%w: %d bytes of base64 content, at most %d; pass the attachments by url",
+			ErrAttachmentTooLarge, size, db.MaxStoredAttachmentContent)
+	}
+	return nil
+}
+
+// ticketAttachments convierte el ticket de una reserva en adjuntos para la cola
+func ticketAttachments(ticket *model.Attachment) ([]queue.Attachment, error) {
+	if ticket == nil {
+		return nil, nil
+	}
+	if ticket.Content == "" && ticket.URL == "" {
+		return nil, fmt.Errorf("ticket attachment %s has neither content nor url", ticket.Filename)
+	}
+	if len(ticket.Content) > maxQueuedAttachmentSize {
+		return nil, fmt.Errorf("ticket attachment %s is too large to queue, pass it by url", ticket.Filename)
+	}
+
+	contentType := ticket.ContentType
+	if contentType == "" {
+		contentType = "application/pdf"
+	}
+
+	return []queue.Attachment{{
+		Filename:    ticket.Filename,
+		ContentType: contentType,
+		Content:     ticket.Content,
+		URL:         ticket.URL,
+	}}, nil
+}
+
+// reservationAttachments arma los adjuntos de una notificación de reserva: los que
+// vienen en el mensaje, el QR de la entrada y, para confirmaciones y tickets, la
+// invitación de calendario
+func reservationAttachments(msg queue.ReservationNotificationMessage, eventDate time.Time, formatter i18n.Formatter) []model.Attachment {
+	var attachments []model.Attachment
+	for _, attachment := range msg.Attachments {
+		attachments = append(attachments, model.Attachment{
+			Filename:    attachment.Filename,
+			ContentType: attachment.ContentType,
+			Content:     attachment.Content,
+			URL:         attachment.URL,
+		})
+	}
+
+	if msg.QRCodeURL != "" {
+		// El tipo MIME lo indica el servidor al descargarlo
+		attachments = append(attachments, model.Attachment{
+			Filename: fmt.Sprintf("entrada-%s-qr.png", msg.TicketCode),
+			URL:      msg.QRCodeURL,
+		})
+	}
+
+	switch model.NotificationType(msg.Type) {
+	case model.NotificationTypeReservationConfirmed, model.NotificationTypeTicketGenerated:
+		ics := email.BuildICS(email.CalendarEvent{
+			UID:         fmt.Sprintf("%s-%s@ticket-system.com", msg.EventID, msg.ReservationID),
+			Summary:     msg.EventName,
+			Location:    msg.Location,
+			Description: formatter.T("reservation_description", msg.ReservationID),
+			Start:       eventDate,
+		})
+		attachments = append(attachments, model.Attachment{
+			Filename:    email.ICSFilename(msg.EventName),
+			ContentType: calendarContentType,
+			Content:     base64.StdEncoding.EncodeToString(ics),
+		})
+	}
+
+	return attachments
+}
@@ -0,0 +1,196 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/jhonathanssegura/ticket-notification/internal/model"
+	"github.com/jhonathanssegura/ticket-notification/internal/queue"
+)
+
+// ErrNoDeadLetterQueue indica que el tipo de cola no tiene cola de mensajes fallidos configurada
+var ErrNoDeadLetterQueue = errors.New("dead-letter queue not configured")
+
+// deadLetterLease es el tiempo que quedan ocultos los mensajes de la DLQ mientras se
+// listan o se buscan los que hay que reenviar o descartar
+const deadLetterLease = time.Minute
+
+// deadLetterQueue obtiene la cola de mensajes fallidos de un tipo de cola
+func (s *NotificationService) deadLetterQueue(queueType string) (*queue.SQSClient, error) {
+	if _, err := s.queueClient(queueType); err != nil {
+		return nil, err
+	}
+	dlq := s.deadLetterQueues[queueType]
+	if dlq == nil {
+		return nil, fmt.Errorf("%w for %s", ErrNoDeadLetterQueue, queueType)
+	}
+	return dlq, nil
+}
+
+// ListDeadLetters muestra hasta limit mensajes (como máximo 10) de la cola de mensajes
+// fallidos sin retirarlos: los recibe y los vuelve a dejar visibles enseguida. SQS
+// devuelve una muestra, no necesariamente los más antiguos.
+func (s *NotificationService) ListDeadLetters(ctx context.Context, queueType string, limit int) ([]model.DeadLetterMessage, error) {
+	dlq, err := s.deadLetterQueue(queueType)
+	if err != nil {
+		return nil, err
+	}
+	if limit <= 0 || limit > queue.MaxBatchSize {
+		limit = queue.MaxBatchSize
+	}
+
+	messages, err := dlq.ReceiveMessagesWithVisibility(ctx, int32(limit), deadLetterLease)
+	if err != nil {
+		return nil, err
+	}
+
+	deadLetters := make([]model.DeadLetterMessage, 0, len(messages))
+	for _, message := range messages {
+		deadLetters = append(deadLetters, deadLetterMessage(queueType, message))
+		if err := dlq.ChangeMessageVisibility(ctx, aws.ToString(message.ReceiptHandle), 0); err != nil {
+			log.Printf("Error liberando mensaje de la cola de mensajes fallidos: %v", err)
+		}
+	}
+	return deadLetters, nil
+}
+
+// RedriveDeadLetters devuelve a su cola los mensajes fallidos indicados por ID, o todos
+// si no se indica ninguno, con el contador de reintentos a cero. Devuelve cuántos reenvió.
+func (s *NotificationService) RedriveDeadLetters(ctx context.Context, queueType string, messageIDs []string) (int, error) {
+	dlq, err := s.deadLetterQueue(queueType)
+	if err != nil {
+		return 0, err
+	}
+	client, err := s.queueClient(queueType)
+	if err != nil {
+		return 0, err
+	}
+
+	return s.takeDeadLetters(ctx, dlq, messageIDs, func(message sqstypes.Message) error {
+		body := aws.ToString(message.Body)
+		var envelope retryEnvelope
+		if err := json.Unmarshal([]byte(body), &envelope); err == nil && envelope.IdempotencyKey != "" {
+			if body, err = rewriteMessage(body, 0, envelope.IdempotencyKey); err != nil {
+				return err
+			}
+
+			// La notificación fallida vuelve a quedar pendiente para que el consumidor la retome
+			if _, err := s.dbClient.ReopenNotification(queueNotificationID(envelope.IdempotencyKey).String(), time.Now()); err != nil {
+				return err
+			}
+		}
+
+		return client.SendRawMessage(ctx, body, messageAttributes(message.MessageAttributes, nil), 0)
+	})
+}
+
+// RetryFailedNotifications devuelve a su cola todos los mensajes de la cola de mensajes
+// fallidos; devuelve cuántos reenvió
+func (s *NotificationService) RetryFailedNotifications(ctx context.Context, queueType string) (int, error) {
+	return s.RedriveDeadLetters(ctx, queueType, nil)
+}
+
+// DiscardDeadLetters elimina de la cola de mensajes fallidos los mensajes indicados por
+// ID, o todos si no se indica ninguno. Devuelve cuántos eliminó.
+func (s *NotificationService) DiscardDeadLetters(ctx context.Context, queueType string, messageIDs []string) (int, error) {
+	dlq, err := s.deadLetterQueue(queueType)
+	if err != nil {
+		return 0, err
+	}
+
+	return s.takeDeadLetters(ctx, dlq, messageIDs, func(message sqstypes.Message) error {
+		log.Printf("Descartando mensaje fallido %s de la cola %s", aws.ToString(message.MessageId), queueType)
+		return nil
+	})
+}
+
+// takeDeadLetters recibe los mensajes de la DLQ y aplica fn a los indicados por ID, o a
+// todos si no se indica ninguno, eliminándolos de la DLQ si fn no falla. Los demás
+// mensajes recibidos vuelven a quedar visibles al terminar. Los que fallaron después de
+// empezar no se toman, para no reenviar en bucle un mensaje que vuelve a fallar.
+func (s *NotificationService) takeDeadLetters(ctx context.Context, dlq *queue.SQSClient, messageIDs []string, fn func(sqstypes.Message) error) (int, error) {
+	started := time.Now().UTC().Truncate(time.Second)
+	wanted := make(map[string]bool, len(messageIDs))
+	for _, id := range messageIDs {
+		wanted[id] = true
+	}
+
+	var released []string
+	defer func() {
+		for _, receiptHandle := range released {
+			if err := dlq.ChangeMessageVisibility(ctx, receiptHandle, 0); err != nil {
+				log.Printf("Error liberando mensaje de la cola de mensajes fallidos: %v", err)
+			}
+		}
+	}()
+
+	taken := 0
+	for len(messageIDs) == 0 || len(wanted) > 0 {
+		messages, err := dlq.ReceiveMessagesWithVisibility(ctx, queue.MaxBatchSize, deadLetterLease)
+		if err != nil {
+			return taken, err
+		}
+		if len(messages) == 0 {
+			break
+		}
+
+		for i, message := range messages {
+			id := aws.ToString(message.MessageId)
+			failedAt, err := time.Parse(time.RFC3339, aws.ToString(message.MessageAttributes["FailedAt"].StringValue))
+			if (len(messageIDs) > 0 && !wanted[id]) || (err == nil && !failedAt.Before(started)) {
+				released = append(released, aws.ToString(message.ReceiptHandle))
+				continue
+			}
+
+			if err := fn(message); err != nil {
+				for _, pending := range messages[i:] {
+					released = append(released, aws.ToString(pending.ReceiptHandle))
+				}
+				return taken, fmt.Errorf("error processing dead-letter message %s: %w", id, err)
+			}
+			if err := dlq.DeleteMessage(ctx, aws.ToString(message.ReceiptHandle)); err != nil {
+				for _, pending := range messages[i+1:] {
+					released = append(released, aws.ToString(pending.ReceiptHandle))
+				}
+				return taken, err
+			}
+			delete(wanted, id)
+			taken++
+		}
+	}
+	return taken, nil
+}
+
+// deadLetterMessage describe un mensaje de la DLQ a partir de su cuerpo y sus atributos
+func deadLetterMessage(queueType string, message sqstypes.Message) model.DeadLetterMessage {
+	attribute := func(name string) string {
+		return aws.ToString(message.MessageAttributes[name].StringValue)
+	}
+
+	deadLetter := model.DeadLetterMessage{
+		MessageID:       aws.ToString(message.MessageId),
+		QueueType:       queueType,
+		SourceMessageID: attribute("SourceMessageID"),
+		FailureReason:   attribute("FailureReason"),
+		Body:            aws.ToString(message.Body),
+	}
+	if failedAt, err := time.Parse(time.RFC3339, attribute("FailedAt")); err == nil {
+		deadLetter.FailedAt = &failedAt
+	}
+
+	var body map[string]interface{}
+	if err := json.Unmarshal([]byte(aws.ToString(message.Body)), &body); err == nil {
+		deadLetter.Body = body
+		var envelope retryEnvelope
+		if err := json.Unmarshal([]byte(aws.ToString(message.Body)), &envelope); err == nil {
+			deadLetter.RetryCount = envelope.RetryCount
+		}
+	}
+	return deadLetter
+}
@@ -0,0 +1,264 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/jhonathanssegura/ticket-notification/internal/channel"
+	"github.com/jhonathanssegura/ticket-notification/internal/db"
+	"github.com/jhonathanssegura/ticket-notification/internal/model"
+	"github.com/jhonathanssegura/ticket-notification/internal/queue"
+)
+
+// newDeliveries arma las entregas de una notificación. Sin canales explícitos la
+// notificación se entrega por email al destinatario principal.
+func (s *NotificationService) newDeliveries(recipient string, channels []model.ChannelRecipient) ([]model.Delivery, error) {
+	if len(channels) == 0 {
+		if recipient == "" {
+			return nil, errors.New("recipient or channels are required")
+		}
+		channels = []model.ChannelRecipient{{Channel: model.ChannelEmail, Recipient: recipient}}
+	}
+
+	deliveries := make([]model.Delivery, 0, len(channels))
+	for _, target := range channels {
+		if err := s.channels.Validate(target.Channel, target.Recipient); err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, model.Delivery{
+			Channel:   target.Channel,
+			Recipient: target.Recipient,
+			Status:    model.NotificationStatusPending,
+		})
+	}
+
+	return deliveries, nil
+}
+
+// webhookDeliveries arma una entrega por cada suscripción de webhook activa
+// para el tipo de notificación. Un error al consultarlas no bloquea el resto de canales.
+func (s *NotificationService) webhookDeliveries(notificationType model.NotificationType) []model.Delivery {
+	if _, err := s.channels.Get(model.ChannelWebhook); err != nil {
+		return nil
+	}
+
+	isActive := true
+	subscriptions, err := s.dbClient.GetWebhookSubscriptions(string(notificationType), &isActive)
+	if err != nil {
+		log.Printf("Error obteniendo suscripciones de webhook para %s: %v", notificationType, err)
+		return nil
+	}
+
+	deliveries := make([]model.Delivery, 0, len(subscriptions))
+	for _, subscription := range subscriptions {
+		deliveries = append(deliveries, model.Delivery{
+			Channel:   model.ChannelWebhook,
+			Recipient: subscription.ID.String(),
+			Status:    model.NotificationStatusPending,
+		})
+	}
+	return deliveries
+}
+
+// pushDeliveries añade la entrega push al destinatario principal si el tipo de
+// notificación se envía por push automáticamente y no se pidió ya explícitamente
+func (s *NotificationService) pushDeliveries(notificationType model.NotificationType, deliveries []model.Delivery) []model.Delivery {
+	registered, err := s.channels.Get(model.ChannelPush)
+	if err != nil {
+		return nil
+	}
+	pushChannel, ok := registered.(*channel.PushChannel)
+	if !ok || !pushChannel.Subscribes(notificationType) {
+		return nil
+	}
+
+	recipient := deliveries[0].Recipient
+	for _, delivery := range deliveries {
+		if delivery.Channel == model.ChannelPush && delivery.Recipient == recipient {
+			return nil
+		}
+	}
+
+	return []model.Delivery{{
+		Channel:   model.ChannelPush,
+		Recipient: recipient,
+		Status:    model.NotificationStatusPending,
+	}}
+}
+
+// queueChannels valida los canales de una notificación y los convierte al formato de la cola
+func (s *NotificationService) queueChannels(recipient string, channels []model.ChannelRecipient) ([]queue.ChannelRecipient, error) {
+	if len(channels) == 0 {
+		return nil, nil
+	}
+
+	deliveries, err := s.newDeliveries(recipient, channels)
+	if err != nil {
+		return nil, err
+	}
+
+	queued := make([]queue.ChannelRecipient, len(deliveries))
+	for i, delivery := range deliveries {
+		queued[i] = queue.ChannelRecipient{
+			Channel:   string(delivery.Channel),
+			Recipient: delivery.Recipient,
+		}
+	}
+	return queued, nil
+}
+
+// channelsFromQueue convierte los canales de un mensaje de la cola al modelo
+func channelsFromQueue(channels []queue.ChannelRecipient) []model.ChannelRecipient {
+	converted := make([]model.ChannelRecipient, len(channels))
+	for i, target := range channels {
+		converted[i] = model.ChannelRecipient{
+			Channel:   model.ChannelType(target.Channel),
+			Recipient: target.Recipient,
+		}
+	}
+	return converted
+}
+
+// deliver entrega la notificación por cada uno de sus canales y registra el
+// resultado de cada entrega. Antes de enviar se consultan las preferencias del
+// destinatario y las entregas que no permiten quedan suprimidas con el motivo.
+// Las entregas ya enviadas o suprimidas no se repiten, de modo que un reintento
+// solo vuelve a intentar los canales que fallaron.
+func (s *NotificationService) deliver(ctx context.Context, notification *model.Notification) error {
+	preferences, err := s.loadPreferences(notification.Recipient)
+	if err != nil {
+		// Sin preferencias no se puede saber si el usuario aceptó el envío; se reintenta más tarde
+		failPendingDeliveries(notification, err)
+		return err
+	}
+
+	var failed []string
+	transient := false
+	now := time.Now()
+
+	for i := range notification.Deliveries {
+		delivery := &notification.Deliveries[i]
+		switch delivery.Status {
+		case model.NotificationStatusSent, model.NotificationStatusDelivered, model.NotificationStatusSuppressed:
+			continue
+		}
+
+		// Los webhooks son integraciones del sistema, no envíos al usuario
+		if delivery.Channel != model.ChannelWebhook {
+			if allowed, reason := preferences.Allows(notification.Type, delivery.Channel, notification.Priority, now); !allowed {
+				log.Printf("Entrega de notificación %s por %s a %s suprimida: %s", notification.ID, delivery.Channel, delivery.Recipient, reason)
+				delivery.Status = model.NotificationStatusSuppressed
+				delivery.SuppressedReason = reason
+				continue
+			}
+		}
+
+		delivery.Attempts++
+		var result channel.Result
+		deliveryChannel, err := s.channels.Get(delivery.Channel)
+		if err == nil {
+			result, err = deliveryChannel.Send(ctx, notification, delivery.Recipient)
+		}
+
+		var suppressed *channel.SuppressedError
+		if errors.As(err, &suppressed) {
+			delivery.Status = model.NotificationStatusSuppressed
+			delivery.SuppressedReason = suppressed.Reason
+			continue
+		}
+		if err != nil {
+			log.Printf("Error entregando notificación %s por %s a %s: %v", notification.ID, delivery.Channel, delivery.Recipient, err)
+			delivery.Status = model.NotificationStatusFailed
+			delivery.Error = err.Error()
+			failed = append(failed, fmt.Sprintf("%s: %v", delivery.Channel, err))
+			if retryable(err) {
+				transient = true
+			}
+			continue
+		}
+
+		sentAt := time.Now()
+		delivery.Status = model.NotificationStatusSent
+		delivery.ProviderMessageID = result.ProviderMessageID
+		delivery.Metadata = result.Metadata
+		delivery.Error = ""
+		delivery.SentAt = &sentAt
+	}
+
+	updateNotificationStatus(notification)
+
+	if len(failed) > 0 {
+		err := fmt.Errorf("delivery failed on %s", strings.Join(failed, "; "))
+		if !transient {
+			// Ningún canal que falló aceptaría la notificación en un reintento
+			return &channel.PermanentError{Err: err}
+		}
+		return err
+	}
+	return nil
+}
+
+// failPendingDeliveries marca como fallidas, con el error, las entregas que no se hicieron
+func failPendingDeliveries(notification *model.Notification, err error) {
+	for i := range notification.Deliveries {
+		if notification.Deliveries[i].Status == model.NotificationStatusPending {
+			notification.Deliveries[i].Status = model.NotificationStatusFailed
+			notification.Deliveries[i].Error = err.Error()
+		}
+	}
+	updateNotificationStatus(notification)
+}
+
+// updateNotificationStatus resume el estado de las entregas: la notificación
+// queda entregada o enviada si al menos un canal la entregó o envió, y suprimida
+// si las preferencias o la lista de supresión impidieron todas las entregas
+func updateNotificationStatus(notification *model.Notification) {
+	notification.UpdatedAt = time.Now()
+	notification.Status = model.NotificationStatusSuppressed
+
+	for _, delivery := range notification.Deliveries {
+		switch delivery.Status {
+		case model.NotificationStatusDelivered, model.NotificationStatusSent:
+			if notification.Status != model.NotificationStatusDelivered {
+				notification.Status = delivery.Status
+			}
+			if delivery.SentAt != nil && (notification.SentAt == nil || delivery.SentAt.Before(*notification.SentAt)) {
+				notification.SentAt = delivery.SentAt
+			}
+		case model.NotificationStatusSuppressed:
+		default:
+			if notification.Status == model.NotificationStatusSuppressed {
+				notification.Status = model.NotificationStatusFailed
+			}
+		}
+	}
+}
+
+// loadPreferences obtiene las preferencias del destinatario; nil si no configuró ninguna
+func (s *NotificationService) loadPreferences(recipient string) (*model.UserPreferences, error) {
+	preferences, err := s.dbClient.GetUserPreferences(recipient)
+	if err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error loading preferences of %s: %w", recipient, err)
+	}
+	return preferences, nil
+}
+
+// mergeDeliveries conserva las entregas ya realizadas en un intento anterior
+func mergeDeliveries(notification *model.Notification, previous []model.Delivery) {
+	for i := range notification.Deliveries {
+		delivery := &notification.Deliveries[i]
+		for _, old := range previous {
+			if old.Channel == delivery.Channel && old.Recipient == delivery.Recipient {
+				*delivery = old
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/jhonathanssegura/ticket-notification/internal/i18n"
+	"github.com/jhonathanssegura/ticket-notification/internal/model"
+	"github.com/jhonathanssegura/ticket-notification/internal/queue"
+)
+
+// ErrNoEventChanges indica que la actualización no cambia ni la fecha ni el lugar del evento
+var ErrNoEventChanges = errors.New("event date and location are unchanged")
+
+// EventUpdateResult resume a quién se notificó un cambio de evento. Notified cuenta los
+// asistentes indicados en la solicitud; al resto de la audiencia se le avisa con FanoutJob.
+type EventUpdateResult struct {
+	EventID        string           `json:"event_id"`
+	Changes        []string         `json:"changes"`
+	Notified       int              `json:"notified"`
+	RemindersMoved int              `json:"reminders_moved"`
+	FanoutJob      *model.FanoutJob `json:"fanout_job,omitempty"`
+}
+
+// NotifyEventUpdated avisa del cambio de fecha o lugar del evento y mueve sus
+// recordatorios programados a la nueva fecha. Los asistentes indicados se notifican al
+// momento y el resto de la audiencia del evento mediante un envío masivo.
+func (s *NotificationService) NotifyEventUpdated(ctx context.Context, eventID string, req model.EventUpdateNotification) (*EventUpdateResult, error) {
+	// El resumen de la respuesta va en el idioma por defecto; cada asistente recibe el suyo
+	changes := eventChanges(req.OldEventDate, req.NewEventDate, req.OldLocation, req.NewLocation, i18n.New("", nil))
+	if len(changes) == 0 {
+		return nil, ErrNoEventChanges
+	}
+
+	attendees := uniqueAttendees(req.Attendees)
+
+	priority := req.Priority
+	if priority == "" {
+		priority = model.NotificationPriorityHigh
+	}
+
+	job := model.FanoutJob{
+		EventID:           eventID,
+		Type:              model.NotificationTypeEventUpdated,
+		EventName:         req.EventName,
+		EventDate:         req.NewEventDate,
+		Location:          req.NewLocation,
+		PreviousEventDate: &req.OldEventDate,
+		PreviousLocation:  req.OldLocation,
+		Priority:          priority,
+	}
+
+	// Validar los canales de todos los asistentes antes de encolar ninguno
+	messages := make([]queue.EventNotificationMessage, len(attendees))
+	for i, attendee := range attendees {
+		channels, err := s.queueChannels(attendee.Recipient, attendee.Channels)
+		if err != nil {
+			return nil, err
+		}
+		messages[i] = fanoutMessage(job, attendee.Recipient, channels)
+		messages[i].IdempotencyKey = recipientIdempotencyKey(idempotencyKeyFrom(ctx), attendee.Recipient)
+		job.Exclude = append(job.Exclude, attendee.Recipient)
+	}
+
+	// Los recordatorios se mueven antes de avisar para que no salga uno con la fecha vieja
+	moved, err := s.RescheduleEventReminders(ctx, eventID, req.NewEventDate, req.NewLocation)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &EventUpdateResult{
+		EventID:        eventID,
+		Changes:        changes,
+		RemindersMoved: moved,
+	}
+	for _, msg := range messages {
+		if err := s.eventQueue.SendEventNotification(ctx, msg); err != nil {
+			return result, fmt.Errorf("error sending event update to queue for %s: %w", msg.Recipient, err)
+		}
+		result.Notified++
+	}
+
+	result.FanoutJob, err = s.startEventFanout(job)
+	if err != nil {
+		return result, err
+	}
+
+	log.Printf("📅 Cambio del evento %s notificado a %d asistentes", eventID, result.Notified)
+	return result, nil
+}
+
+// uniqueAttendees quita los destinatarios repetidos, conservando el primero
+func uniqueAttendees(requested []model.ReminderAttendee) []model.ReminderAttendee {
+	attendees := make([]model.ReminderAttendee, 0, len(requested))
+	seen := make(map[string]bool)
+	for _, attendee := range requested {
+		if !seen[attendee.Recipient] {
+			seen[attendee.Recipient] = true
+			attendees = append(attendees, attendee)
+		}
+	}
+	return attendees
+}
+
+// eventChanges describe, una línea por cambio y en el idioma del formateador, en qué cambió el evento
+func eventChanges(oldDate, newDate time.Time, oldLocation, newLocation string, formatter i18n.Formatter) []string {
+	var changes []string
+	if !oldDate.Equal(newDate) {
+		if formatter.Date(oldDate) == formatter.Date(newDate) {
+			changes = append(changes, formatter.T("time_change", formatter.Time(oldDate), formatter.Time(newDate)))
+		} else {
+			changes = append(changes, formatter.T("date_change", formatter.DateTime(oldDate), formatter.DateTime(newDate)))
+		}
+	}
+	if strings.TrimSpace(oldLocation) != strings.TrimSpace(newLocation) {
+		changes = append(changes, formatter.T("location_change", oldLocation, newLocation))
+	}
+	return changes
+}
+
+// addEventChanges agrega a los datos de la plantilla la fecha y el lugar anteriores y
+// el resumen de los cambios ({{changes}})
+func addEventChanges(data map[string]interface{}, msg queue.EventNotificationMessage, eventDate time.Time, formatter i18n.Formatter) error {
+	previousDate := eventDate
+	if msg.PreviousEventDate != "" {
+		parsed, err := time.Parse(time.RFC3339, msg.PreviousEventDate)
+		if err != nil {
+			return fmt.Errorf("invalid previous event date %q: %w", msg.PreviousEventDate, err)
+		}
+		previousDate = parsed
+	}
+	previousLocation := msg.PreviousLocation
+	if previousLocation == "" {
+		previousLocation = msg.Location
+	}
+
+	lines := eventChanges(previousDate, eventDate, previousLocation, msg.Location, formatter)
+	for i, line := range lines {
+		lines[i] = "• " + line
+	}
+
+	data["previous_event_date"] = formatter.DateTime(previousDate)
+	data["previous_location"] = previousLocation
+	data["changes"] = strings.Join(lines, "\n")
+	return nil
+}
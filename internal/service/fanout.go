@@ -0,0 +1,258 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jhonathanssegura/ticket-notification/internal/model"
+	"github.com/jhonathanssegura/ticket-notification/internal/queue"
+)
+
+const (
+	// fanoutLease es cuánto tiempo una instancia tiene tomado un envío masivo sin registrar avances
+	fanoutLease = 2 * time.Minute
+	// fanoutJobsBatch es cuántos envíos masivos en curso se leen por ejecución
+	fanoutJobsBatch = 20
+	// fanoutSendAttempts es cuántas veces se reintentan los mensajes que SQS rechaza en un lote
+	fanoutSendAttempts = 3
+)
+
+// registerAudienceMember agrega la reserva a la audiencia del evento
+func (s *NotificationService) registerAudienceMember(req model.ReservationNotification) error {
+	if err := s.dbClient.AddEventAudienceMember(req.EventID, req.Recipient, req.ReservationID, req.Channels, time.Now()); err != nil {
+		return fmt.Errorf("error registering reservation %s in event audience: %w", req.ReservationID, err)
+	}
+	return nil
+}
+
+// unregisterAudienceMember quita la reserva de la audiencia del evento
+func (s *NotificationService) unregisterAudienceMember(req model.ReservationNotification) error {
+	stillHolder, err := s.dbClient.RemoveEventAudienceMember(req.EventID, req.Recipient, req.ReservationID, time.Now())
+	if err != nil {
+		return fmt.Errorf("error removing reservation %s from event audience: %w", req.ReservationID, err)
+	}
+	if !stillHolder {
+		log.Printf("👥 %s ya no tiene reservas para el evento %s", req.Recipient, req.EventID)
+	}
+	return nil
+}
+
+// NotifyEventCancelledToAudience cancela los recordatorios del evento y crea un envío
+// masivo del aviso de cancelación para todos los que tienen reserva. Devuelve nil si el
+// evento no tiene asistentes registrados.
+func (s *NotificationService) NotifyEventCancelledToAudience(ctx context.Context, req model.EventNotification) (*model.FanoutJob, error) {
+	if _, err := s.CancelEventReminders(ctx, req.EventID, ""); err != nil {
+		return nil, err
+	}
+
+	priority := req.Priority
+	if priority == "" {
+		priority = model.NotificationPriorityHigh
+	}
+
+	return s.startEventFanout(model.FanoutJob{
+		EventID:   req.EventID,
+		Type:      model.NotificationTypeEventCancelled,
+		EventName: req.EventName,
+		EventDate: req.EventDate,
+		Location:  req.Location,
+		Priority:  priority,
+	})
+}
+
+// startEventFanout crea el envío masivo a la audiencia del evento; lo encola el
+// planificador de envíos masivos (RunFanoutJobs)
+func (s *NotificationService) startEventFanout(job model.FanoutJob) (*model.FanoutJob, error) {
+	total, err := s.dbClient.CountEventAudience(job.EventID)
+	if err != nil {
+		return nil, fmt.Errorf("error counting audience of event %s: %w", job.EventID, err)
+	}
+	if total == 0 {
+		return nil, nil
+	}
+
+	now := time.Now()
+	job.ID = uuid.New().String()
+	job.Status = model.FanoutJobStatusRunning
+	job.Total = total
+	job.CreatedAt = now
+	job.UpdatedAt = now
+	if err := s.dbClient.CreateFanoutJob(job); err != nil {
+		return nil, err
+	}
+
+	log.Printf("📣 Envío masivo %s (%s) creado para %d asistentes del evento %s", job.ID, job.Type, total, job.EventID)
+	return &job, nil
+}
+
+// RunFanoutJobs encola, lote por lote, los envíos masivos en curso. Cada envío registra
+// el último destinatario encolado, así que si se interrumpe otra ejecución lo retoma
+// desde ahí. Un lote encolado justo antes de una caída se vuelve a encolar, pero el
+// consumidor descarta los mensajes repetidos por su clave de idempotencia.
+func (s *NotificationService) RunFanoutJobs(ctx context.Context) error {
+	jobs, err := s.dbClient.GetFanoutJobsByStatus(model.FanoutJobStatusRunning, fanoutJobsBatch)
+	if err != nil {
+		return fmt.Errorf("error loading fanout jobs: %w", err)
+	}
+
+	for _, job := range jobs {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		leaseID := uuid.New().String()
+		now := time.Now()
+		claimed, err := s.dbClient.ClaimFanoutJob(job.ID, leaseID, now.Add(fanoutLease), now)
+		if err != nil {
+			return err
+		}
+		if !claimed {
+			continue
+		}
+
+		s.runFanoutJob(ctx, job, leaseID)
+	}
+
+	return nil
+}
+
+// runFanoutJob encola el envío masivo desde su cursor hasta terminar la audiencia, a
+// menos que se cancele ctx, en cuyo caso lo libera para retomarlo después
+func (s *NotificationService) runFanoutJob(ctx context.Context, job model.FanoutJob, leaseID string) {
+	excluded := make(map[string]bool, len(job.Exclude))
+	for _, recipient := range job.Exclude {
+		excluded[recipient] = true
+	}
+
+	cursor := job.Cursor
+	for {
+		if ctx.Err() != nil {
+			if err := s.dbClient.ReleaseFanoutJob(job.ID, leaseID); err != nil {
+				log.Printf("Error liberando envío masivo %s: %v", job.ID, err)
+			}
+			return
+		}
+
+		members, err := s.dbClient.GetEventAudience(job.EventID, cursor, queue.MaxBatchSize)
+		if err != nil {
+			s.failFanoutJob(job, leaseID, fmt.Errorf("error loading audience: %w", err))
+			return
+		}
+		if len(members) == 0 {
+			s.completeFanoutJob(job, leaseID)
+			return
+		}
+
+		messages := make([]queue.EventNotificationMessage, 0, len(members))
+		skipped := 0
+		for _, member := range members {
+			if excluded[member.Recipient] {
+				skipped++
+				continue
+			}
+			channels, err := s.queueChannels(member.Recipient, member.Channels)
+			if err != nil {
+				// Un asistente con canales inválidos no detiene el envío a los demás
+				log.Printf("⚠️  Envío masivo %s: se omite a %s: %v", job.ID, member.Recipient, err)
+				skipped++
+				continue
+			}
+			messages = append(messages, fanoutMessage(job, member.Recipient, channels))
+		}
+
+		if err := s.sendEventBatch(context.WithoutCancel(ctx), messages); err != nil {
+			s.failFanoutJob(job, leaseID, err)
+			return
+		}
+
+		cursor = members[len(members)-1].Recipient
+		now := time.Now()
+		owned, err := s.dbClient.UpdateFanoutJobProgress(job.ID, leaseID, cursor, len(messages), skipped, now.Add(fanoutLease), now)
+		if err != nil {
+			s.failFanoutJob(job, leaseID, err)
+			return
+		}
+		if !owned {
+			log.Printf("⚠️  Envío masivo %s tomado por otra instancia, se deja de procesar", job.ID)
+			return
+		}
+
+		if len(members) < queue.MaxBatchSize {
+			s.completeFanoutJob(job, leaseID)
+			return
+		}
+	}
+}
+
+// sendEventBatch encola un lote reintentando los mensajes que SQS rechaza
+func (s *NotificationService) sendEventBatch(ctx context.Context, messages []queue.EventNotificationMessage) error {
+	pending := messages
+	for attempt := 1; len(pending) > 0; attempt++ {
+		failed, err := s.eventQueue.SendEventNotificationBatch(ctx, pending)
+		if err != nil {
+			return err
+		}
+		if len(failed) > 0 && attempt == fanoutSendAttempts {
+			return fmt.Errorf("%d messages rejected by the queue after %d attempts", len(failed), attempt)
+		}
+
+		retry := make([]queue.EventNotificationMessage, 0, len(failed))
+		for _, index := range failed {
+			retry = append(retry, pending[index])
+		}
+		pending = retry
+	}
+	return nil
+}
+
+// completeFanoutJob marca el envío masivo como completado
+func (s *NotificationService) completeFanoutJob(job model.FanoutJob, leaseID string) {
+	if _, err := s.dbClient.FinishFanoutJob(job.ID, leaseID, model.FanoutJobStatusCompleted, "", time.Now()); err != nil {
+		log.Printf("Error completando envío masivo %s: %v", job.ID, err)
+		return
+	}
+	log.Printf("📣 Envío masivo %s del evento %s completado", job.ID, job.EventID)
+}
+
+// failFanoutJob marca el envío masivo como fallido; se puede reanudar desde su cursor
+func (s *NotificationService) failFanoutJob(job model.FanoutJob, leaseID string, cause error) {
+	log.Printf("❌ Envío masivo %s del evento %s fallido: %v", job.ID, job.EventID, cause)
+	if _, err := s.dbClient.FinishFanoutJob(job.ID, leaseID, model.FanoutJobStatusFailed, cause.Error(), time.Now()); err != nil {
+		log.Printf("Error marcando envío masivo %s como fallido: %v", job.ID, err)
+	}
+}
+
+// ResumeFanoutJob vuelve a poner en curso un envío masivo fallido
+func (s *NotificationService) ResumeFanoutJob(ctx context.Context, jobID string) (*model.FanoutJob, error) {
+	if _, err := s.dbClient.GetFanoutJob(jobID); err != nil {
+		return nil, err
+	}
+	return s.dbClient.ResumeFanoutJob(jobID, time.Now())
+}
+
+// fanoutMessage arma el mensaje de la cola de eventos de un asistente del envío masivo
+func fanoutMessage(job model.FanoutJob, recipient string, channels []queue.ChannelRecipient) queue.EventNotificationMessage {
+	msg := queue.EventNotificationMessage{
+		EventID:          job.EventID,
+		EventName:        job.EventName,
+		EventDate:        job.EventDate.Format(time.RFC3339),
+		Location:         job.Location,
+		Recipient:        recipient,
+		Type:             string(job.Type),
+		Priority:         string(job.Priority),
+		TemplateID:       defaultTemplateID(job.Type),
+		Channels:         channels,
+		PreviousLocation: job.PreviousLocation,
+	}
+	if job.PreviousEventDate != nil {
+		msg.PreviousEventDate = job.PreviousEventDate.Format(time.RFC3339)
+	}
+	// Un lote encolado de nuevo tras una caída lleva las mismas claves y el consumidor lo descarta
+	if job.ID != "" {
+		msg.IdempotencyKey = recipientIdempotencyKey("fanout:"+job.ID, recipient)
+	}
+	return msg
+}
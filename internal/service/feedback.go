@@ -0,0 +1,113 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jhonathanssegura/ticket-notification/internal/db"
+	"github.com/jhonathanssegura/ticket-notification/internal/feedback"
+	"github.com/jhonathanssegura/ticket-notification/internal/model"
+)
+
+// HandleEmailFeedback procesa un rebote, queja o entrega informado por SES: añade
+// a la lista de supresión las direcciones que rebotaron de forma permanente o se
+// quejaron y actualiza la entrega por email de la notificación que envió el mensaje
+func (s *NotificationService) HandleEmailFeedback(event *feedback.SESEvent) error {
+	switch event.Type() {
+	case feedback.SESEventBounce, feedback.SESEventComplaint, feedback.SESEventDelivery:
+	default:
+		log.Printf("Evento de SES %s ignorado para el mensaje %s", event.Type(), event.Mail.MessageID)
+		return nil
+	}
+
+	// Los mensajes enviados por otros sistemas con la misma identidad no tienen notificación
+	notificationID, err := s.dbClient.GetEmailMessageNotification(event.Mail.MessageID)
+	if err != nil && !errors.Is(err, db.ErrNotFound) {
+		return fmt.Errorf("error finding notification of SES message %s: %w", event.Mail.MessageID, err)
+	}
+
+	var suppressed []string
+	reason := model.SuppressionBounce
+	switch {
+	case event.IsPermanentBounce():
+		for _, recipient := range event.Bounce.BouncedRecipients {
+			suppressed = append(suppressed, recipient.EmailAddress)
+		}
+	// "not-spam" significa que el destinatario retiró la queja
+	case event.Complaint != nil && event.Complaint.ComplaintFeedbackType != "not-spam":
+		reason = model.SuppressionComplaint
+		for _, recipient := range event.Complaint.ComplainedRecipients {
+			suppressed = append(suppressed, recipient.EmailAddress)
+		}
+	}
+	for _, email := range suppressed {
+		if err := s.dbClient.SaveEmailSuppression(model.EmailSuppression{
+			Email:          email,
+			Reason:         reason,
+			Details:        event.Reason(),
+			NotificationID: notificationID,
+			CreatedAt:      time.Now(),
+		}); err != nil {
+			return fmt.Errorf("error suppressing %s: %w", email, err)
+		}
+		log.Printf("📛 Dirección %s añadida a la lista de supresión (%s)", email, reason)
+	}
+
+	if notificationID == "" {
+		log.Printf("Mensaje SES %s sin notificación asociada", event.Mail.MessageID)
+		return nil
+	}
+
+	notification, err := s.dbClient.GetNotificationByID(notificationID)
+	if err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			return nil
+		}
+		return fmt.Errorf("error loading notification %s: %w", notificationID, err)
+	}
+
+	updated := false
+	for i := range notification.Deliveries {
+		delivery := &notification.Deliveries[i]
+		if delivery.Channel != model.ChannelEmail || delivery.ProviderMessageID != event.Mail.MessageID {
+			continue
+		}
+
+		switch event.Type() {
+		case feedback.SESEventDelivery:
+			// Un rebote que llegó antes no se sobrescribe
+			if delivery.Status == model.NotificationStatusSent {
+				delivery.Status = model.NotificationStatusDelivered
+			}
+		case feedback.SESEventBounce:
+			delivery.Status = model.NotificationStatusFailed
+			delivery.Error = event.Reason()
+		case feedback.SESEventComplaint:
+			// La queja no cambia que el email se entregó; queda registrada en los metadatos
+			if delivery.Metadata == nil {
+				delivery.Metadata = make(map[string]string)
+			}
+			delivery.Metadata["complaint"] = event.Reason()
+		}
+		updated = true
+	}
+	if !updated {
+		return nil
+	}
+
+	status := notification.Status
+	updateNotificationStatus(notification)
+	// Una notificación ya leída en la bandeja conserva ese estado
+	if status == model.NotificationStatusRead {
+		notification.Status = status
+	}
+
+	if err := s.dbClient.SaveNotification(*notification); err != nil {
+		return fmt.Errorf("error saving notification %s: %w", notification.ID, err)
+	}
+
+	log.Printf("Notificación %s actualizada por evento SES %s: %s", notification.ID, event.Type(), notification.Status)
+	return nil
+}
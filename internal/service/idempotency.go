@@ -0,0 +1,39 @@
+package service
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+type idempotencyKeyContextKey struct{}
+
+// WithIdempotencyKey asocia a ctx la clave de idempotencia de la solicitud; los
+// mensajes que se encolen con ese contexto la llevan para descartar duplicados
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKeyContextKey{}, key)
+}
+
+// idempotencyKeyFrom devuelve la clave de idempotencia asociada a ctx, o vacío
+func idempotencyKeyFrom(ctx context.Context) string {
+	key, _ := ctx.Value(idempotencyKeyContextKey{}).(string)
+	return key
+}
+
+// recipientIdempotencyKey deriva la clave de un destinatario a partir de la del envío,
+// para las solicitudes que encolan un mensaje por destinatario
+func recipientIdempotencyKey(key, recipient string) string {
+	if key == "" {
+		return ""
+	}
+	return key + ":" + recipient
+}
+
+// notificationID devuelve el ID de una notificación nueva, derivado de la clave de
+// idempotencia de la solicitud si tiene una, para que un reintento no cree otra
+func notificationID(ctx context.Context) uuid.UUID {
+	if key := idempotencyKeyFrom(ctx); key != "" {
+		return uuid.NewSHA1(uuid.NameSpaceURL, []byte(key))
+	}
+	return uuid.New()
+}
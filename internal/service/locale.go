@@ -0,0 +1,92 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jhonathanssegura/ticket-notification/internal/i18n"
+	"github.com/jhonathanssegura/ticket-notification/internal/model"
+)
+
+// ErrInvalidLocale indica un idioma o una zona horaria inválidos en la solicitud
+var ErrInvalidLocale = errors.New("invalid locale")
+
+// normalizeLocale valida el idioma y la zona horaria de una solicitud y devuelve el
+// idioma en su forma canónica; ambos son opcionales
+func normalizeLocale(locale, timezone string) (string, error) {
+	normalized := i18n.Normalize(locale)
+	if locale != "" && normalized == "" {
+		return "", fmt.Errorf("%w: %q is not a language tag like es or pt-BR", ErrInvalidLocale, locale)
+	}
+	if timezone != "" {
+		if _, err := time.LoadLocation(timezone); err != nil {
+			return "", fmt.Errorf("%w: unknown timezone %q", ErrInvalidLocale, timezone)
+		}
+	}
+	return normalized, nil
+}
+
+// formatterFor arma el formateador de textos del destinatario con el idioma y la zona
+// horaria indicados o, si faltan, con los de sus preferencias. Sin zona horaria las
+// fechas se muestran en la zona con la que llegaron.
+func (s *NotificationService) formatterFor(recipient, locale, timezone string) (i18n.Formatter, error) {
+	locale, err := normalizeLocale(locale, timezone)
+	if err != nil {
+		return i18n.Formatter{}, err
+	}
+
+	if (locale == "" || timezone == "") && recipient != "" {
+		preferences, err := s.loadPreferences(recipient)
+		if err != nil {
+			return i18n.Formatter{}, err
+		}
+		if preferences != nil {
+			if locale == "" {
+				locale = preferences.Language
+			}
+			if timezone == "" {
+				timezone = preferences.Timezone
+			}
+		}
+	}
+
+	var location *time.Location
+	if timezone != "" {
+		// Las preferencias guardadas ya tienen la zona horaria validada
+		if loaded, err := time.LoadLocation(timezone); err == nil {
+			location = loaded
+		}
+	}
+
+	return i18n.New(locale, location), nil
+}
+
+// resolveLocale devuelve el idioma indicado o, si falta, el de las preferencias del
+// destinatario; vacío si no se conoce, en cuyo caso se usa el idioma por defecto
+func (s *NotificationService) resolveLocale(recipient, locale string) (string, error) {
+	locale, err := normalizeLocale(locale, "")
+	if err != nil {
+		return "", err
+	}
+
+	if locale == "" && recipient != "" {
+		preferences, err := s.loadPreferences(recipient)
+		if err != nil {
+			return "", err
+		}
+		if preferences != nil {
+			locale = i18n.Normalize(preferences.Language)
+		}
+	}
+
+	return locale, nil
+}
+
+// primaryRecipient devuelve el destinatario por el que se buscan sus preferencias
+func primaryRecipient(recipient string, channels []model.ChannelRecipient) string {
+	if recipient == "" && len(channels) > 0 {
+		return channels[0].Recipient
+	}
+	return recipient
+}
@@ -2,55 +2,116 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"strconv"
+	"sync"
 	"time"
 
-	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/service/ses"
-	"github.com/aws/aws-sdk-go/service/sqs"
-	"github.com/google/uuid"
+	"github.com/jhonathanssegura/ticket-notification/internal/channel"
+	"github.com/jhonathanssegura/ticket-notification/internal/db"
+	"github.com/jhonathanssegura/ticket-notification/internal/email"
 	"github.com/jhonathanssegura/ticket-notification/internal/model"
 	"github.com/jhonathanssegura/ticket-notification/internal/queue"
 )
 
 // NotificationService maneja el envío y gestión de notificaciones
 type NotificationService struct {
-	sesClient        *ses.Client
+	channels         *channel.Registry
 	eventQueue       *queue.SQSClient
 	reservationQueue *queue.SQSClient
 	reminderQueue    *queue.SQSClient
+	dbClient         *db.DynamoClient
+	renderer         *TemplateRenderer
+	retryPolicy      RetryPolicy
+	deadLetterQueues map[string]*queue.SQSClient
+	queueStats       map[string]*queueStats
+	startedAt        time.Time
+	// background cuenta las entregas en segundo plano en curso
+	background sync.WaitGroup
 }
 
 // NewNotificationService crea una nueva instancia del servicio de notificaciones
 func NewNotificationService(
-	sesClient *ses.Client,
+	channels *channel.Registry,
 	eventQueue *queue.SQSClient,
 	reservationQueue *queue.SQSClient,
 	reminderQueue *queue.SQSClient,
+	dbClient *db.DynamoClient,
 ) *NotificationService {
 	return &NotificationService{
-		sesClient:        sesClient,
+		channels:         channels,
 		eventQueue:       eventQueue,
 		reservationQueue: reservationQueue,
 		reminderQueue:    reminderQueue,
+		dbClient:         dbClient,
+		renderer:         NewTemplateRenderer(dbClient),
+		retryPolicy:      DefaultRetryPolicy(),
+		queueStats:       newQueueStats(),
+		startedAt:        time.Now(),
 	}
 }
 
-// SendNotification envía una notificación individual
+// SendNotification registra una notificación individual como pendiente y la entrega en
+// segundo plano; con send_at en el futuro la deja programada
 func (s *NotificationService) SendNotification(ctx context.Context, req model.CreateNotificationRequest) (*model.Notification, error) {
+	subject, content, htmlContent := req.Subject, req.Content, req.HTMLContent
+	templateVersion := 0
+	locale := ""
+
+	// Si se indica una plantilla, el asunto y el contenido salen de ella, en el idioma
+	// de la solicitud o de las preferencias del destinatario
+	if req.TemplateID != "" {
+		requested, err := s.resolveLocale(primaryRecipient(req.Recipient, req.Channels), req.Locale)
+		if err != nil {
+			return nil, err
+		}
+		rendered, err := s.renderer.Render(req.TemplateID, req.TemplateVersion, requested, req.Data)
+		if err != nil {
+			return nil, fmt.Errorf("error rendering template %s: %w", req.TemplateID, err)
+		}
+		subject, content, htmlContent = rendered.Subject, rendered.Content, rendered.HTMLContent
+		templateVersion = rendered.Version
+		locale = rendered.Locale
+	} else if htmlContent != "" {
+		htmlContent = email.InlineCSS(htmlContent)
+		if content == "" {
+			content = email.HTMLToText(htmlContent)
+		}
+	}
+
+	if subject == "" || content == "" {
+		return nil, errors.New("subject and content are required when no template is given")
+	}
+	if err := checkStoredAttachments(req.Attachments); err != nil {
+		return nil, err
+	}
+
+	deliveries, err := s.newDeliveries(req.Recipient, req.Channels)
+	if err != nil {
+		return nil, err
+	}
+	deliveries = append(deliveries, s.pushDeliveries(req.Type, deliveries)...)
+	deliveries = append(deliveries, s.webhookDeliveries(req.Type)...)
+
 	notification := &model.Notification{
-		ID:         uuid.New(),
-		Type:       req.Type,
-		Status:     model.NotificationStatusPending,
-		Priority:   req.Priority,
-		Recipient:  req.Recipient,
-		Subject:    req.Subject,
-		Content:    req.Content,
-		TemplateID: req.TemplateID,
-		Data:       req.Data,
-		CreatedAt:  time.Now(),
-		UpdatedAt:  time.Now(),
+		ID:              notificationID(ctx),
+		Type:            req.Type,
+		Status:          model.NotificationStatusPending,
+		Priority:        req.Priority,
+		Recipient:       deliveries[0].Recipient,
+		Deliveries:      deliveries,
+		Subject:         subject,
+		Content:         content,
+		HTMLContent:     htmlContent,
+		TemplateID:      req.TemplateID,
+		TemplateVersion: templateVersion,
+		Locale:          locale,
+		Data:            req.Data,
+		Attachments:     req.Attachments,
+		CreatedAt:       time.Now(),
+		UpdatedAt:       time.Now(),
 	}
 
 	// Si no se especifica prioridad, usar normal
@@ -58,17 +119,22 @@ func (s *NotificationService) SendNotification(ctx context.Context, req model.Cr
 		notification.Priority = model.NotificationPriorityNormal
 	}
 
-	// Enviar por email
-	if err := s.sendEmailNotification(ctx, notification); err != nil {
-		log.Printf("Error enviando email: %v", err)
-		notification.Status = model.NotificationStatusFailed
-	} else {
-		now := time.Now()
-		notification.Status = model.NotificationStatusSent
-		notification.SentAt = &now
+	// Con send_at en el futuro la notificación se guarda y la envía el despachador
+	if req.SendAt != "" {
+		sendAt, err := s.resolveSendAt(req.SendAt, req.Timezone, notification.Recipient)
+		if err != nil {
+			return nil, err
+		}
+		if sendAt.After(time.Now()) {
+			if err := s.scheduleNotification(notification, sendAt); err != nil {
+				return nil, err
+			}
+			return notification, nil
+		}
 	}
 
-	return notification, nil
+	// Se guarda antes de enviar para que la base de datos refleje siempre lo enviado
+	return s.acceptNotification(ctx, notification)
 }
 
 // SendBulkNotifications envía múltiples notificaciones
@@ -76,7 +142,13 @@ func (s *NotificationService) SendBulkNotifications(ctx context.Context, req mod
 	var notifications []*model.Notification
 	var errors []error
 
-	for _, notificationReq := range req.Notifications {
+	if req.SendAt != "" {
+		if err := validateSendAt(req.SendAt, req.Timezone); err != nil {
+			return nil, err
+		}
+	}
+
+	for i, notificationReq := range req.Notifications {
 		// Aplicar prioridad global si se especifica
 		if req.Priority != "" {
 			notificationReq.Priority = req.Priority
@@ -87,7 +159,21 @@ func (s *NotificationService) SendBulkNotifications(ctx context.Context, req mod
 			notificationReq.TemplateID = req.TemplateID
 		}
 
-		notification, err := s.SendNotification(ctx, notificationReq)
+		// Aplicar la hora de envío global a las que no indican la suya
+		if req.SendAt != "" && notificationReq.SendAt == "" {
+			notificationReq.SendAt = req.SendAt
+			if notificationReq.Timezone == "" {
+				notificationReq.Timezone = req.Timezone
+			}
+		}
+
+		// Cada notificación del lote tiene su propia clave de idempotencia
+		notificationCtx := ctx
+		if key := idempotencyKeyFrom(ctx); key != "" {
+			notificationCtx = WithIdempotencyKey(ctx, recipientIdempotencyKey(key, strconv.Itoa(i)))
+		}
+
+		notification, err := s.SendNotification(notificationCtx, notificationReq)
 		if err != nil {
 			errors = append(errors, fmt.Errorf("error sending notification to %s: %w", notificationReq.Recipient, err))
 		} else {
@@ -106,38 +192,33 @@ func (s *NotificationService) SendBulkNotifications(ctx context.Context, req mod
 func (s *NotificationService) NotifyEventCreated(ctx context.Context, req model.EventNotification) error {
 	// Crear mensaje para la cola de eventos
 	msg := queue.EventNotificationMessage{
-		EventID:    req.EventID,
-		EventName:  req.EventName,
-		EventDate:  req.EventDate.Format(time.RFC3339),
-		Location:   req.Location,
-		Recipient:  req.Recipient,
-		Type:       string(req.Type),
-		Priority:   string(req.Priority),
-		TemplateID: "event_created_template",
+		EventID:        req.EventID,
+		EventName:      req.EventName,
+		EventDate:      req.EventDate.Format(time.RFC3339),
+		Location:       req.Location,
+		Recipient:      req.Recipient,
+		Type:           string(req.Type),
+		Priority:       string(req.Priority),
+		TemplateID:     "event_created_template",
+		Timezone:       req.Timezone,
+		IdempotencyKey: idempotencyKeyFrom(ctx),
 	}
 
-	// Enviar a la cola de eventos
-	if err := s.eventQueue.SendEventNotification(ctx, msg); err != nil {
-		return fmt.Errorf("error sending event notification to queue: %w", err)
+	locale, err := normalizeLocale(req.Locale, req.Timezone)
+	if err != nil {
+		return err
 	}
+	msg.Locale = locale
 
-	// También enviar email inmediato si es alta prioridad
-	if req.Priority == model.NotificationPriorityHigh || req.Priority == model.NotificationPriorityUrgent {
-		notification := &model.Notification{
-			ID:        uuid.New(),
-			Type:      req.Type,
-			Status:    model.NotificationStatusPending,
-			Priority:  req.Priority,
-			Recipient: req.Recipient,
-			Subject:   fmt.Sprintf("Nuevo Evento: %s", req.EventName),
-			Content:   fmt.Sprintf("Se ha creado un nuevo evento: %s en %s el %s", req.EventName, req.Location, req.EventDate.Format("02/01/2006 15:04")),
-			CreatedAt: time.Now(),
-			UpdatedAt: time.Now(),
-		}
+	channels, err := s.queueChannels(req.Recipient, req.Channels)
+	if err != nil {
+		return err
+	}
+	msg.Channels = channels
 
-		if err := s.sendEmailNotification(ctx, notification); err != nil {
-			log.Printf("Error sending immediate event notification email: %v", err)
-		}
+	// Enviar a la cola de eventos
+	if err := s.eventQueue.SendEventNotification(ctx, msg); err != nil {
+		return fmt.Errorf("error sending event notification to queue: %w", err)
 	}
 
 	return nil
@@ -147,14 +228,28 @@ func (s *NotificationService) NotifyEventCreated(ctx context.Context, req model.
 func (s *NotificationService) SendEventReminder(ctx context.Context, req model.EventNotification) error {
 	// Crear mensaje para la cola de recordatorios
 	msg := queue.ReminderMessage{
-		EventID:      req.EventID,
-		EventName:    req.EventName,
-		EventDate:    req.EventDate.Format(time.RFC3339),
-		Location:     req.Location,
-		Recipient:    req.Recipient,
-		ReminderType: "event_reminder",
-		TemplateID:   "event_reminder_template",
+		EventID:        req.EventID,
+		EventName:      req.EventName,
+		EventDate:      req.EventDate.Format(time.RFC3339),
+		Location:       req.Location,
+		Recipient:      req.Recipient,
+		ReminderType:   "event_reminder",
+		TemplateID:     "event_reminder_template",
+		Timezone:       req.Timezone,
+		IdempotencyKey: idempotencyKeyFrom(ctx),
+	}
+
+	locale, err := normalizeLocale(req.Locale, req.Timezone)
+	if err != nil {
+		return err
+	}
+	msg.Locale = locale
+
+	channels, err := s.queueChannels(req.Recipient, req.Channels)
+	if err != nil {
+		return err
 	}
+	msg.Channels = channels
 
 	// Enviar a la cola de recordatorios
 	if err := s.reminderQueue.SendReminderMessage(ctx, msg); err != nil {
@@ -166,38 +261,40 @@ func (s *NotificationService) SendEventReminder(ctx context.Context, req model.E
 
 // NotifyEventCancelled notifica cuando se cancela un evento
 func (s *NotificationService) NotifyEventCancelled(ctx context.Context, req model.EventNotification) error {
+	// Los recordatorios pendientes del evento dejan de enviarse
+	if _, err := s.CancelEventReminders(ctx, req.EventID, ""); err != nil {
+		return err
+	}
+
 	// Crear mensaje para la cola de eventos
 	msg := queue.EventNotificationMessage{
-		EventID:    req.EventID,
-		EventName:  req.EventName,
-		EventDate:  req.EventDate.Format(time.RFC3339),
-		Location:   req.Location,
-		Recipient:  req.Recipient,
-		Type:       string(req.Type),
-		Priority:   string(req.Priority),
-		TemplateID: "event_cancelled_template",
+		EventID:        req.EventID,
+		EventName:      req.EventName,
+		EventDate:      req.EventDate.Format(time.RFC3339),
+		Location:       req.Location,
+		Recipient:      req.Recipient,
+		Type:           string(req.Type),
+		Priority:       string(req.Priority),
+		TemplateID:     "event_cancelled_template",
+		Timezone:       req.Timezone,
+		IdempotencyKey: idempotencyKeyFrom(ctx),
 	}
 
-	// Enviar a la cola de eventos
-	if err := s.eventQueue.SendEventNotification(ctx, msg); err != nil {
-		return fmt.Errorf("error sending event cancellation to queue: %w", err)
+	locale, err := normalizeLocale(req.Locale, req.Timezone)
+	if err != nil {
+		return err
 	}
+	msg.Locale = locale
 
-	// Enviar email inmediato para cancelaciones
-	notification := &model.Notification{
-		ID:        uuid.New(),
-		Type:      req.Type,
-		Status:    model.NotificationStatusPending,
-		Priority:  req.Priority,
-		Recipient: req.Recipient,
-		Subject:   fmt.Sprintf("Evento Cancelado: %s", req.EventName),
-		Content:   fmt.Sprintf("El evento '%s' programado para el %s en %s ha sido cancelado.", req.EventName, req.EventDate.Format("02/01/2006 15:04"), req.Location),
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+	channels, err := s.queueChannels(req.Recipient, req.Channels)
+	if err != nil {
+		return err
 	}
+	msg.Channels = channels
 
-	if err := s.sendEmailNotification(ctx, notification); err != nil {
-		log.Printf("Error sending event cancellation email: %v", err)
+	// Enviar a la cola de eventos
+	if err := s.eventQueue.SendEventNotification(ctx, msg); err != nil {
+		return fmt.Errorf("error sending event cancellation to queue: %w", err)
 	}
 
 	return nil
@@ -207,191 +304,142 @@ func (s *NotificationService) NotifyEventCancelled(ctx context.Context, req mode
 func (s *NotificationService) NotifyReservationCreated(ctx context.Context, req model.ReservationNotification) error {
 	// Crear mensaje para la cola de reservas
 	msg := queue.ReservationNotificationMessage{
-		ReservationID: req.ReservationID,
-		EventID:       req.EventID,
-		EventName:     req.EventName,
-		EventDate:     req.EventDate.Format(time.RFC3339),
-		Location:      req.Location,
-		Recipient:     req.Recipient,
-		Type:          string(req.Type),
-		Priority:      string(req.Priority),
-		TemplateID:    "reservation_created_template",
+		ReservationID:  req.ReservationID,
+		EventID:        req.EventID,
+		EventName:      req.EventName,
+		EventDate:      req.EventDate.Format(time.RFC3339),
+		Location:       req.Location,
+		Recipient:      req.Recipient,
+		Type:           string(req.Type),
+		Priority:       string(req.Priority),
+		TemplateID:     "reservation_created_template",
+		Timezone:       req.Timezone,
+		IdempotencyKey: idempotencyKeyFrom(ctx),
 	}
 
-	// Enviar a la cola de reservas
-	if err := s.reservationQueue.SendReservationNotification(ctx, msg); err != nil {
-		return fmt.Errorf("error sending reservation notification to queue: %w", err)
+	locale, err := normalizeLocale(req.Locale, req.Timezone)
+	if err != nil {
+		return err
 	}
+	msg.Locale = locale
 
-	// Enviar email de confirmación inmediata
-	notification := &model.Notification{
-		ID:        uuid.New(),
-		Type:      req.Type,
-		Status:    model.NotificationStatusPending,
-		Priority:  req.Priority,
-		Recipient: req.Recipient,
-		Subject:   fmt.Sprintf("Reserva Confirmada: %s", req.EventName),
-		Content:   fmt.Sprintf("Tu reserva para el evento '%s' el %s en %s ha sido confirmada. ID de reserva: %s", req.EventName, req.EventDate.Format("02/01/2006 15:04"), req.Location, req.ReservationID),
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+	attachments, err := ticketAttachments(req.Ticket)
+	if err != nil {
+		return err
 	}
+	msg.Attachments = attachments
 
-	if err := s.sendEmailNotification(ctx, notification); err != nil {
-		log.Printf("Error sending reservation confirmation email: %v", err)
+	channels, err := s.queueChannels(req.Recipient, req.Channels)
+	if err != nil {
+		return err
 	}
+	msg.Channels = channels
 
-	return nil
-}
-
-// NotifyReservationConfirmed notifica cuando se confirma una reserva
-func (s *NotificationService) NotifyReservationConfirmed(ctx context.Context, req model.ReservationNotification) error {
-	// Crear mensaje para la cola de reservas
-	msg := queue.ReservationNotificationMessage{
-		ReservationID: req.ReservationID,
-		EventID:       req.EventID,
-		EventName:     req.EventName,
-		EventDate:     req.EventDate.Format(time.RFC3339),
-		Location:      req.Location,
-		Recipient:     req.Recipient,
-		Type:          string(req.Type),
-		Priority:      string(req.Priority),
-		TemplateID:    "reservation_confirmed_template",
+	// Registrar al destinatario en la audiencia del evento
+	if err := s.registerAudienceMember(req); err != nil {
+		return err
 	}
 
 	// Enviar a la cola de reservas
 	if err := s.reservationQueue.SendReservationNotification(ctx, msg); err != nil {
-		return fmt.Errorf("error sending reservation confirmation to queue: %w", err)
+		return fmt.Errorf("error sending reservation notification to queue: %w", err)
 	}
 
 	return nil
 }
 
-// NotifyReservationCancelled notifica cuando se cancela una reserva
-func (s *NotificationService) NotifyReservationCancelled(ctx context.Context, req model.ReservationNotification) error {
+// NotifyReservationConfirmed notifica cuando se confirma una reserva
+func (s *NotificationService) NotifyReservationConfirmed(ctx context.Context, req model.ReservationNotification) error {
 	// Crear mensaje para la cola de reservas
 	msg := queue.ReservationNotificationMessage{
-		ReservationID: req.ReservationID,
-		EventID:       req.EventID,
-		EventName:     req.EventName,
-		EventDate:     req.EventDate.Format(time.RFC3339),
-		Location:      req.Location,
-		Recipient:     req.Recipient,
-		Type:          string(req.Type),
-		Priority:      string(req.Priority),
-		TemplateID:    "reservation_cancelled_template",
+		ReservationID:  req.ReservationID,
+		EventID:        req.EventID,
+		EventName:      req.EventName,
+		EventDate:      req.EventDate.Format(time.RFC3339),
+		Location:       req.Location,
+		Recipient:      req.Recipient,
+		Type:           string(req.Type),
+		Priority:       string(req.Priority),
+		TemplateID:     "reservation_confirmed_template",
+		Timezone:       req.Timezone,
+		IdempotencyKey: idempotencyKeyFrom(ctx),
 	}
 
-	// Enviar a la cola de reservas
-	if err := s.reservationQueue.SendReservationNotification(ctx, msg); err != nil {
-		return fmt.Errorf("error sending reservation cancellation to queue: %w", err)
+	locale, err := normalizeLocale(req.Locale, req.Timezone)
+	if err != nil {
+		return err
 	}
+	msg.Locale = locale
 
-	// Enviar email inmediato para cancelaciones de reserva
-	notification := &model.Notification{
-		ID:        uuid.New(),
-		Type:      req.Type,
-		Status:    model.NotificationStatusPending,
-		Priority:  req.Priority,
-		Recipient: req.Recipient,
-		Subject:   fmt.Sprintf("Reserva Cancelada: %s", req.EventName),
-		Content:   fmt.Sprintf("Tu reserva para el evento '%s' el %s en %s ha sido cancelada. ID de reserva: %s", req.EventName, req.EventDate.Format("02/01/2006 15:04"), req.Location, req.ReservationID),
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+	attachments, err := ticketAttachments(req.Ticket)
+	if err != nil {
+		return err
 	}
+	msg.Attachments = attachments
 
-	if err := s.sendEmailNotification(ctx, notification); err != nil {
-		log.Printf("Error sending reservation cancellation email: %v", err)
+	channels, err := s.queueChannels(req.Recipient, req.Channels)
+	if err != nil {
+		return err
 	}
+	msg.Channels = channels
 
-	return nil
-}
+	// Registrar al destinatario en la audiencia del evento
+	if err := s.registerAudienceMember(req); err != nil {
+		return err
+	}
 
-// sendEmailNotification envía una notificación por email usando SES
-func (s *NotificationService) sendEmailNotification(ctx context.Context, notification *model.Notification) error {
-	// Configurar el email
-	emailInput := &ses.SendEmailInput{
-		Source: aws.String("notifications@ticket-system.com"),
-		Destination: &ses.Destination{
-			ToAddresses: []string{notification.Recipient},
-		},
-		Message: &ses.Message{
-			Subject: &ses.Content{
-				Data:    aws.String(notification.Subject),
-				Charset: aws.String("UTF-8"),
-			},
-			Body: &ses.Body{
-				Text: &ses.Content{
-					Data:    aws.String(notification.Content),
-					Charset: aws.String("UTF-8"),
-				},
-			},
-		},
-	}
-
-	// Enviar el email
-	_, err := s.sesClient.SendEmail(ctx, emailInput)
-	if err != nil {
-		return fmt.Errorf("error sending email via SES: %w", err)
+	// Enviar a la cola de reservas
+	if err := s.reservationQueue.SendReservationNotification(ctx, msg); err != nil {
+		return fmt.Errorf("error sending reservation confirmation to queue: %w", err)
 	}
 
-	log.Printf("Email notification sent successfully to %s", notification.Recipient)
 	return nil
 }
 
-// ProcessNotificationQueue procesa la cola de notificaciones
-func (s *NotificationService) ProcessNotificationQueue(ctx context.Context, queueType string) error {
-	var client *queue.SQSClient
-
-	switch queueType {
-	case "events":
-		client = s.eventQueue
-	case "reservations":
-		client = s.reservationQueue
-	case "reminders":
-		client = s.reminderQueue
-	default:
-		return fmt.Errorf("invalid queue type: %s", queueType)
+// NotifyReservationCancelled notifica cuando se cancela una reserva
+func (s *NotificationService) NotifyReservationCancelled(ctx context.Context, req model.ReservationNotification) error {
+	// Crear mensaje para la cola de reservas
+	msg := queue.ReservationNotificationMessage{
+		ReservationID:  req.ReservationID,
+		EventID:        req.EventID,
+		EventName:      req.EventName,
+		EventDate:      req.EventDate.Format(time.RFC3339),
+		Location:       req.Location,
+		Recipient:      req.Recipient,
+		Type:           string(req.Type),
+		Priority:       string(req.Priority),
+		TemplateID:     "reservation_cancelled_template",
+		Timezone:       req.Timezone,
+		IdempotencyKey: idempotencyKeyFrom(ctx),
 	}
 
-	// Recibir mensajes de la cola
-	messages, err := client.ReceiveMessages(ctx, 10)
+	locale, err := normalizeLocale(req.Locale, req.Timezone)
 	if err != nil {
-		return fmt.Errorf("error receiving messages: %w", err)
+		return err
 	}
+	msg.Locale = locale
 
-	log.Printf("Processing %d messages from %s queue", len(messages), queueType)
-
-	for _, message := range messages {
-		// Procesar el mensaje según el tipo
-		if err := s.processMessage(ctx, message, queueType); err != nil {
-			log.Printf("Error processing message %s: %v", *message.MessageId, err)
-			continue
-		}
-
-		// Eliminar el mensaje procesado
-		if err := client.DeleteMessage(ctx, *message.ReceiptHandle); err != nil {
-			log.Printf("Error deleting message %s: %v", *message.MessageId, err)
-		}
+	attachments, err := ticketAttachments(req.Ticket)
+	if err != nil {
+		return err
 	}
+	msg.Attachments = attachments
 
-	return nil
-}
+	channels, err := s.queueChannels(req.Recipient, req.Channels)
+	if err != nil {
+		return err
+	}
+	msg.Channels = channels
 
-// processMessage procesa un mensaje individual de la cola
-func (s *NotificationService) processMessage(ctx context.Context, message sqs.Message, queueType string) error {
-	log.Printf("Processing message %s from %s queue", *message.MessageId, queueType)
+	// Quitar la reserva de la audiencia del evento
+	if err := s.unregisterAudienceMember(req); err != nil {
+		return err
+	}
 
-	// Aquí se implementaría la lógica específica para cada tipo de mensaje
-	// Por ahora, solo logueamos el procesamiento
-	switch queueType {
-	case "events":
-		log.Printf("Processing event notification: %s", *message.Body)
-	case "reservations":
-		log.Printf("Processing reservation notification: %s", *message.Body)
-	case "reminders":
-		log.Printf("Processing reminder: %s", *message.Body)
+	// Enviar a la cola de reservas
+	if err := s.reservationQueue.SendReservationNotification(ctx, msg); err != nil {
+		return fmt.Errorf("error sending reservation cancellation to queue: %w", err)
 	}
 
 	return nil
 }
-
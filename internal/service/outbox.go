@@ -0,0 +1,193 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jhonathanssegura/ticket-notification/internal/model"
+)
+
+// outboxHandoff es lo que espera el despachador del outbox antes de retomar una
+// notificación pendiente, para no competir con la entrega en segundo plano que se
+// lanza al aceptarla
+const outboxHandoff = 30 * time.Second
+
+// deliveryLease es el tiempo que una entrega retiene la notificación que está enviando;
+// si quien la envía se detiene, pasado ese tiempo otra entrega la puede tomar
+const deliveryLease = 5 * time.Minute
+
+// outboxBatch es cuántas notificaciones del outbox se leen por consulta
+const outboxBatch = 100
+
+// acceptNotification guarda la notificación como pendiente y la entrega en segundo
+// plano. Si ya existe una con el mismo ID, por un reintento de la misma solicitud,
+// devuelve esa sin volver a enviarla.
+func (s *NotificationService) acceptNotification(ctx context.Context, notification *model.Notification) (*model.Notification, error) {
+	nextAttemptAt := time.Now().Add(outboxHandoff)
+	notification.Status = model.NotificationStatusPending
+	notification.NextAttemptAt = &nextAttemptAt
+
+	created, err := s.dbClient.CreateNotification(*notification)
+	if err != nil {
+		return nil, fmt.Errorf("error saving notification %s: %w", notification.ID, err)
+	}
+	if !created {
+		return s.dbClient.GetNotificationByID(notification.ID.String())
+	}
+
+	// La respuesta no comparte las entregas con el envío, que las modifica
+	accepted := *notification
+	accepted.Deliveries = append([]model.Delivery(nil), notification.Deliveries...)
+
+	s.background.Add(1)
+	go func() {
+		defer s.background.Done()
+		s.deliverOutbox(context.WithoutCancel(ctx), notification)
+	}()
+
+	return &accepted, nil
+}
+
+// Wait espera a que terminen las entregas en segundo plano en curso
+func (s *NotificationService) Wait() {
+	s.background.Wait()
+}
+
+// deliverOutbox toma una notificación pendiente del outbox, la entrega y guarda el
+// resultado. Devuelve false si otra entrega la tomó antes.
+func (s *NotificationService) deliverOutbox(ctx context.Context, notification *model.Notification) bool {
+	lockedUntil, claimed := s.claimNotification(notification)
+	if !claimed {
+		return false
+	}
+
+	notification.Attempts++
+
+	// Al retomarla desde la base de datos, el base64 de los adjuntos se lee aparte
+	if missingAttachmentContent(notification) != "" {
+		if err := s.dbClient.LoadAttachmentContents(notification); err != nil {
+			log.Printf("Error cargando adjuntos de la notificación %s: %v", notification.ID, err)
+			failPendingDeliveries(notification, err)
+			s.retryOutbox(notification, err)
+			s.saveClaimedNotification(notification, lockedUntil)
+			return true
+		}
+	}
+	// Solo falta si superaba lo que se guarda con la notificación
+	if filename := missingAttachmentContent(notification); filename != "" {
+		failPendingDeliveries(notification, fmt.Errorf("content of attachment %s is no longer available", filename))
+		s.saveClaimedNotification(notification, lockedUntil)
+		return true
+	}
+
+	if err := s.deliver(ctx, notification); err != nil {
+		log.Printf("Error entregando notificación %s: %v", notification.ID, err)
+		s.retryOutbox(notification, err)
+	}
+	s.saveClaimedNotification(notification, lockedUntil)
+	return true
+}
+
+// missingAttachmentContent devuelve el nombre del primer adjunto sin contenido ni URL
+func missingAttachmentContent(notification *model.Notification) string {
+	for _, attachment := range notification.Attachments {
+		if attachment.URL == "" && attachment.Content == "" {
+			return attachment.Filename
+		}
+	}
+	return ""
+}
+
+// retryOutbox deja pendiente la notificación cuya entrega falló para que el despachador
+// del outbox la retome con un retardo creciente, si el error es transitorio y le quedan
+// reintentos según su prioridad; si no, la notificación queda fallida
+func (s *NotificationService) retryOutbox(notification *model.Notification, cause error) {
+	maxRetries := s.retryPolicy.maxRetries(string(notification.Priority))
+	if !retryable(cause) {
+		return
+	}
+	if notification.Attempts > maxRetries {
+		log.Printf("☠️  Notificación %s fallida tras %d intentos: %v", notification.ID, notification.Attempts, cause)
+		return
+	}
+
+	nextAttemptAt := time.Now().Add(s.retryPolicy.delay(notification.Attempts - 1))
+	notification.Status = model.NotificationStatusPending
+	notification.NextAttemptAt = &nextAttemptAt
+	log.Printf("🔁 Notificación %s pendiente del reintento %d de %d a las %s", notification.ID,
+		notification.Attempts, maxRetries, nextAttemptAt.Format(time.RFC3339))
+}
+
+// claimNotification pasa la notificación a enviando y devuelve hasta cuándo la retiene
+func (s *NotificationService) claimNotification(notification *model.Notification) (time.Time, bool) {
+	now := time.Now()
+	lockedUntil := now.Add(deliveryLease)
+	claimed, err := s.dbClient.ClaimNotification(*notification, lockedUntil, now)
+	if err != nil {
+		log.Printf("Error tomando notificación %s: %v", notification.ID, err)
+		return time.Time{}, false
+	}
+	if !claimed {
+		return time.Time{}, false
+	}
+
+	notification.Status = model.NotificationStatusSending
+	notification.LockedUntil = &lockedUntil
+	return lockedUntil, true
+}
+
+// saveClaimedNotification guarda el resultado de la entrega si la notificación sigue
+// retenida; deja de estar retenida y, salvo que quede pendiente de un reintento, sale
+// del outbox
+func (s *NotificationService) saveClaimedNotification(notification *model.Notification, lockedUntil time.Time) {
+	notification.LockedUntil = nil
+	if notification.Status != model.NotificationStatusPending {
+		notification.NextAttemptAt = nil
+	}
+
+	saved, err := s.dbClient.SaveClaimedNotification(*notification, lockedUntil)
+	if err != nil {
+		log.Printf("Error guardando notificación %s en DB: %v", notification.ID, err)
+		return
+	}
+	if !saved {
+		log.Printf("⚠️  La entrega de la notificación %s superó su retención y la tomó otra entrega", notification.ID)
+	}
+}
+
+// DispatchOutbox entrega las notificaciones que la entrega en segundo plano no llegó a
+// terminar: las pendientes que nadie tomó o cuyo reintento ya llegó y las que quedaron
+// enviando con la retención vencida porque la instancia que las enviaba se detuvo
+func (s *NotificationService) DispatchOutbox(ctx context.Context) error {
+	for _, status := range []model.NotificationStatus{model.NotificationStatusPending, model.NotificationStatusSending} {
+		for ctx.Err() == nil {
+			notifications, err := s.dbClient.GetOutboxNotifications(status, time.Now(), outboxBatch)
+			if err != nil {
+				return fmt.Errorf("error loading %s outbox notifications: %w", status, err)
+			}
+
+			dispatched := 0
+			for i := range notifications {
+				if ctx.Err() != nil {
+					break
+				}
+				// Ya tomada, la entrega no se interrumpe aunque el servicio se esté deteniendo
+				if s.deliverOutbox(context.WithoutCancel(ctx), &notifications[i]) {
+					dispatched++
+				}
+			}
+
+			if dispatched > 0 {
+				log.Printf("📤 %d notificaciones retomadas del outbox", dispatched)
+			}
+			// Sin avances, las restantes las tomó otra instancia
+			if len(notifications) < outboxBatch || dispatched == 0 {
+				break
+			}
+		}
+	}
+
+	return ctx.Err()
+}
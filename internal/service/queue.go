@@ -0,0 +1,367 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/google/uuid"
+	"github.com/jhonathanssegura/ticket-notification/internal/db"
+	"github.com/jhonathanssegura/ticket-notification/internal/model"
+	"github.com/jhonathanssegura/ticket-notification/internal/queue"
+)
+
+// ProcessNotificationQueue procesa la cola de notificaciones
+func (s *NotificationService) ProcessNotificationQueue(ctx context.Context, queueType string) error {
+	client, err := s.queueClient(queueType)
+	if err != nil {
+		return err
+	}
+
+	// Recibir mensajes de la cola
+	messages, err := client.ReceiveMessages(ctx, 10)
+	if err != nil {
+		return fmt.Errorf("error receiving messages: %w", err)
+	}
+
+	log.Printf("Processing %d messages from %s queue", len(messages), queueType)
+
+	for _, message := range messages {
+		// Procesar el mensaje según el tipo
+		if err := s.processMessage(ctx, message, queueType); err != nil {
+			log.Printf("Error processing message %s: %v", *message.MessageId, err)
+			continue
+		}
+
+		// Eliminar el mensaje solo cuando la entrega fue exitosa
+		if err := client.DeleteMessage(ctx, *message.ReceiptHandle); err != nil {
+			log.Printf("Error deleting message %s: %v", *message.MessageId, err)
+		}
+	}
+
+	return nil
+}
+
+// queueClient obtiene el cliente SQS para un tipo de cola
+func (s *NotificationService) queueClient(queueType string) (*queue.SQSClient, error) {
+	switch queueType {
+	case "events":
+		return s.eventQueue, nil
+	case "reservations":
+		return s.reservationQueue, nil
+	case "reminders":
+		return s.reminderQueue, nil
+	default:
+		return nil, fmt.Errorf("invalid queue type: %s", queueType)
+	}
+}
+
+// ProcessMessage procesa un mensaje recibido por un worker externo. No elimina
+// el mensaje de la cola; eso queda a cargo de quien lo recibió.
+func (s *NotificationService) ProcessMessage(ctx context.Context, message sqstypes.Message, queueType string) error {
+	return s.processMessage(ctx, message, queueType)
+}
+
+// processMessage procesa un mensaje individual de la cola. Si la entrega falla por un
+// error transitorio el mensaje se reencola con un retardo creciente hasta agotar los
+// reintentos de su prioridad; si el error es permanente o ya no quedan reintentos pasa
+// a la cola de mensajes fallidos. Devuelve error si el mensaje no debe eliminarse de la cola.
+func (s *NotificationService) processMessage(ctx context.Context, message sqstypes.Message, queueType string) error {
+	log.Printf("Processing message %s from %s queue", *message.MessageId, queueType)
+	started := time.Now()
+
+	var envelope retryEnvelope
+	err := json.Unmarshal([]byte(*message.Body), &envelope)
+	if err != nil {
+		err = fmt.Errorf("%w: %v", errMalformedMessage, err)
+	} else {
+		err = s.handleMessage(ctx, message, queueType, envelope.RetryCount >= s.retryPolicy.maxRetries(envelope.Priority))
+	}
+
+	outcome := outcomeCompleted
+	if err != nil {
+		outcome, err = s.retryMessage(ctx, message, queueType, envelope, err)
+	}
+	s.recordMessage(queueType, outcome, message, started)
+	return err
+}
+
+// handleMessage entrega la notificación de un mensaje. En el último intento, o si el
+// error es permanente, la notificación queda fallida en lugar de pendiente.
+func (s *NotificationService) handleMessage(ctx context.Context, message sqstypes.Message, queueType string, lastAttempt bool) error {
+	var notification *model.Notification
+	var scheduledReminder *queue.ReminderMessage
+	var idempotencyKey string
+	var err error
+
+	switch queueType {
+	case "events":
+		var msg queue.EventNotificationMessage
+		if err := json.Unmarshal([]byte(*message.Body), &msg); err != nil {
+			return fmt.Errorf("%w: error decoding event notification message: %v", errMalformedMessage, err)
+		}
+		idempotencyKey = msg.IdempotencyKey
+		notification, err = s.buildEventNotification(msg)
+	case "reservations":
+		var msg queue.ReservationNotificationMessage
+		if err := json.Unmarshal([]byte(*message.Body), &msg); err != nil {
+			return fmt.Errorf("%w: error decoding reservation notification message: %v", errMalformedMessage, err)
+		}
+		idempotencyKey = msg.IdempotencyKey
+		notification, err = s.buildReservationNotification(msg)
+	case "reminders":
+		var msg queue.ReminderMessage
+		if err := json.Unmarshal([]byte(*message.Body), &msg); err != nil {
+			return fmt.Errorf("%w: error decoding reminder message: %v", errMalformedMessage, err)
+		}
+		if msg.ReminderID != "" {
+			current, err := s.currentScheduledReminder(msg)
+			if err != nil && !errors.Is(err, db.ErrNotFound) {
+				return fmt.Errorf("error checking scheduled reminder %s: %w", msg.ReminderID, err)
+			}
+			if !current {
+				log.Printf("Recordatorio %s del evento %s cancelado o movido, se descarta el mensaje", msg.ReminderID, msg.EventID)
+				return nil
+			}
+			scheduledReminder = &msg
+		}
+		idempotencyKey = msg.IdempotencyKey
+		notification, err = s.buildReminderNotification(msg)
+	default:
+		return fmt.Errorf("invalid queue type: %s", queueType)
+	}
+	if err != nil {
+		return err
+	}
+
+	// Sin clave de idempotencia se usa el ID del mensaje SQS, que cubre las entregas
+	// repetidas de un mismo mensaje pero no los mensajes encolados dos veces
+	if idempotencyKey == "" {
+		idempotencyKey = *message.MessageId
+	}
+
+	// El ID se deriva de la clave para que los reintentos actualicen el mismo registro
+	notification.ID = queueNotificationID(idempotencyKey)
+
+	// La notificación se guarda pendiente antes de enviarla; si ya existe, el mensaje es
+	// un duplicado o un reintento
+	created, err := s.dbClient.CreateNotification(*notification)
+	if err != nil {
+		return fmt.Errorf("error saving notification %s: %w", notification.ID, err)
+	}
+	if !created {
+		previous, err := s.dbClient.GetNotificationByID(notification.ID.String())
+		if err != nil {
+			return fmt.Errorf("error loading notification %s: %w", notification.ID, err)
+		}
+		if previous.Status != model.NotificationStatusPending && previous.Status != model.NotificationStatusSending {
+			log.Printf("Mensaje duplicado en la cola %s: la notificación %s ya terminó (%s), se descarta", queueType, notification.ID, previous.Status)
+			return nil
+		}
+
+		// En un reintento no se repiten los canales que ya entregaron la notificación
+		notification.CreatedAt = previous.CreatedAt
+		notification.ReadAt = previous.ReadAt
+		mergeDeliveries(notification, previous.Deliveries)
+	}
+
+	lockedUntil, claimed := s.claimNotification(notification)
+	if !claimed {
+		return fmt.Errorf("%w: %s", errDeliveryInProgress, notification.ID)
+	}
+
+	sendErr := s.deliver(ctx, notification)
+	if sendErr != nil && !lastAttempt && retryable(sendErr) {
+		// Vuelve a quedar pendiente para que el reintento del mensaje la retome
+		notification.Status = model.NotificationStatusPending
+	}
+	s.saveClaimedNotification(notification, lockedUntil)
+
+	if sendErr != nil {
+		return fmt.Errorf("error delivering notification %s: %w", notification.ID, sendErr)
+	}
+
+	if scheduledReminder != nil {
+		if _, err := s.dbClient.UpdateReminderStatus(scheduledReminder.EventID, scheduledReminder.ReminderID,
+			[]model.ReminderStatus{model.ReminderStatusEnqueued}, model.ReminderStatusSent, time.Now()); err != nil {
+			log.Printf("Error marcando recordatorio %s como enviado: %v", scheduledReminder.ReminderID, err)
+		}
+	}
+
+	return nil
+}
+
+// queueNotificationID deriva el ID de la notificación de un mensaje de la clave que lo identifica
+func queueNotificationID(key string) uuid.UUID {
+	return uuid.NewSHA1(uuid.NameSpaceURL, []byte(key))
+}
+
+// buildEventNotification construye la notificación para un mensaje de evento
+func (s *NotificationService) buildEventNotification(msg queue.EventNotificationMessage) (*model.Notification, error) {
+	eventDate, err := time.Parse(time.RFC3339, msg.EventDate)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid event date %q: %v", errMalformedMessage, msg.EventDate, err)
+	}
+
+	notificationType := model.NotificationType(msg.Type)
+	templateID := msg.TemplateID
+	if templateID == "" {
+		templateID = defaultTemplateID(notificationType)
+	}
+
+	formatter, err := s.formatterFor(primaryRecipient(msg.Recipient, channelsFromQueue(msg.Channels)), msg.Locale, msg.Timezone)
+	if err != nil {
+		return nil, err
+	}
+
+	data := map[string]interface{}{
+		"event_id":   msg.EventID,
+		"event_name": msg.EventName,
+		"event_date": formatter.DateTime(eventDate),
+		"location":   msg.Location,
+	}
+	if notificationType == model.NotificationTypeEventUpdated {
+		if err := addEventChanges(data, msg, eventDate, formatter); err != nil {
+			return nil, err
+		}
+	}
+
+	notification, err := s.newQueuedNotification(notificationType, msg.Priority, msg.Recipient, msg.Channels, templateID, formatter.Locale, data)
+	if err != nil {
+		return nil, err
+	}
+	notification.Source = msg
+
+	return notification, nil
+}
+
+// buildReservationNotification construye la notificación para un mensaje de reserva
+func (s *NotificationService) buildReservationNotification(msg queue.ReservationNotificationMessage) (*model.Notification, error) {
+	eventDate, err := time.Parse(time.RFC3339, msg.EventDate)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid event date %q: %v", errMalformedMessage, msg.EventDate, err)
+	}
+
+	notificationType := model.NotificationType(msg.Type)
+	templateID := msg.TemplateID
+	if templateID == "" {
+		templateID = defaultTemplateID(notificationType)
+	}
+
+	formatter, err := s.formatterFor(primaryRecipient(msg.Recipient, channelsFromQueue(msg.Channels)), msg.Locale, msg.Timezone)
+	if err != nil {
+		return nil, err
+	}
+
+	data := map[string]interface{}{
+		"reservation_id": msg.ReservationID,
+		"event_id":       msg.EventID,
+		"event_name":     msg.EventName,
+		"event_date":     formatter.DateTime(eventDate),
+		"location":       msg.Location,
+	}
+	if msg.TicketCode != "" {
+		data["ticket_code"] = msg.TicketCode
+	}
+	if msg.QRCodeURL != "" {
+		data["qr_code_url"] = msg.QRCodeURL
+	}
+
+	notification, err := s.newQueuedNotification(notificationType, msg.Priority, msg.Recipient, msg.Channels, templateID, formatter.Locale, data)
+	if err != nil {
+		return nil, err
+	}
+	notification.Attachments = reservationAttachments(msg, eventDate, formatter)
+	notification.Source = msg
+
+	return notification, nil
+}
+
+// buildReminderNotification construye la notificación para un mensaje de recordatorio
+func (s *NotificationService) buildReminderNotification(msg queue.ReminderMessage) (*model.Notification, error) {
+	eventDate, err := time.Parse(time.RFC3339, msg.EventDate)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid event date %q: %v", errMalformedMessage, msg.EventDate, err)
+	}
+
+	templateID := msg.TemplateID
+	if templateID == "" {
+		templateID = defaultTemplateID(model.NotificationTypeEventReminder)
+	}
+
+	formatter, err := s.formatterFor(primaryRecipient(msg.Recipient, channelsFromQueue(msg.Channels)), msg.Locale, msg.Timezone)
+	if err != nil {
+		return nil, err
+	}
+
+	// Los recordatorios sueltos (SendEventReminder) no tienen anticipación
+	timeUntil := ""
+	if offset, ok := model.ReminderType(msg.ReminderType).Offset(); ok {
+		timeUntil = formatter.T("time_until", formatter.Duration(offset))
+	}
+
+	data := map[string]interface{}{
+		"event_id":      msg.EventID,
+		"event_name":    msg.EventName,
+		"event_date":    formatter.DateTime(eventDate),
+		"location":      msg.Location,
+		"reminder_type": msg.ReminderType,
+		"time_until":    timeUntil,
+	}
+
+	notification, err := s.newQueuedNotification(model.NotificationTypeEventReminder, "", msg.Recipient, msg.Channels, templateID, formatter.Locale, data)
+	if err != nil {
+		return nil, err
+	}
+	notification.Source = msg
+
+	return notification, nil
+}
+
+// newQueuedNotification crea la notificación renderizando la plantilla referenciada, en el idioma indicado, con los datos del mensaje
+func (s *NotificationService) newQueuedNotification(notificationType model.NotificationType, priority, recipient string, channels []queue.ChannelRecipient, templateID, locale string, data map[string]interface{}) (*model.Notification, error) {
+	deliveries, err := s.newDeliveries(recipient, channelsFromQueue(channels))
+	if err != nil {
+		return nil, err
+	}
+	deliveries = append(deliveries, s.pushDeliveries(notificationType, deliveries)...)
+	deliveries = append(deliveries, s.webhookDeliveries(notificationType)...)
+
+	rendered, err := s.renderer.Render(templateID, 0, locale, data)
+	if err != nil {
+		return nil, fmt.Errorf("error rendering template %s: %w", templateID, err)
+	}
+
+	notification := &model.Notification{
+		Type:            notificationType,
+		Status:          model.NotificationStatusPending,
+		Priority:        model.NotificationPriority(priority),
+		Recipient:       deliveries[0].Recipient,
+		Deliveries:      deliveries,
+		Subject:         rendered.Subject,
+		Content:         rendered.Content,
+		HTMLContent:     rendered.HTMLContent,
+		TemplateID:      rendered.TemplateID,
+		TemplateVersion: rendered.Version,
+		Locale:          rendered.Locale,
+		Data:            data,
+		CreatedAt:       time.Now(),
+		UpdatedAt:       time.Now(),
+	}
+
+	// Si no se especifica prioridad, usar normal
+	if notification.Priority == "" {
+		notification.Priority = model.NotificationPriorityNormal
+	}
+
+	return notification, nil
+}
+
+// defaultTemplateID obtiene la plantilla por defecto para un tipo de notificación
+func defaultTemplateID(notificationType model.NotificationType) string {
+	return string(notificationType) + "_template"
+}
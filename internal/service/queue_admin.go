@@ -0,0 +1,248 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/jhonathanssegura/ticket-notification/internal/model"
+	"github.com/jhonathanssegura/ticket-notification/internal/queue"
+)
+
+// ErrPurgeInProgress indica que la cola ya se purgó hace menos de 60 segundos; SQS no
+// admite otra purga hasta entonces
+var ErrPurgeInProgress = errors.New("queue purge already in progress")
+
+// messageOutcome indica cómo terminó el procesamiento de un mensaje de la cola
+type messageOutcome int
+
+const (
+	outcomeCompleted messageOutcome = iota
+	outcomeRetried
+	outcomeDeadLettered
+	outcomeFailed
+)
+
+// queueStats acumula lo que procesaron los consumidores de una cola
+type queueStats struct {
+	mu            sync.Mutex
+	outcomes      [outcomeFailed + 1]int64
+	busy          time.Duration
+	lastMessageAt time.Time
+	lastFailureAt time.Time
+	// recent cuenta los mensajes terminados en cada uno de los últimos 60 segundos;
+	// recentAt indica a qué segundo corresponde cada posición y recentOldest el envío
+	// más antiguo (unix ms, según SentTimestamp) de los mensajes recibidos en ese segundo
+	recent       [60]int64
+	recentAt     [60]int64
+	recentOldest [60]int64
+}
+
+// newQueueStats crea los contadores de cada tipo de cola
+func newQueueStats() map[string]*queueStats {
+	return map[string]*queueStats{
+		"events":       {},
+		"reservations": {},
+		"reminders":    {},
+	}
+}
+
+// recordMessage registra cómo terminó un mensaje de la cola que empezó a procesarse en started
+func (s *NotificationService) recordMessage(queueType string, outcome messageOutcome, message sqstypes.Message, started time.Time) {
+	stats := s.queueStats[queueType]
+	if stats == nil {
+		return
+	}
+	now := time.Now()
+
+	stats.mu.Lock()
+	defer stats.mu.Unlock()
+
+	stats.outcomes[outcome]++
+	stats.busy += now.Sub(started)
+	stats.lastMessageAt = now
+	if outcome != outcomeCompleted {
+		stats.lastFailureAt = now
+	}
+
+	second := now.Unix()
+	slot := second % int64(len(stats.recent))
+	if stats.recentAt[slot] != second {
+		stats.recentAt[slot] = second
+		stats.recent[slot] = 0
+		stats.recentOldest[slot] = 0
+	}
+	stats.recent[slot]++
+	if sentAt, ok := queue.SentAt(message); ok {
+		if sent := sentAt.UnixMilli(); stats.recentOldest[slot] == 0 || sent < stats.recentOldest[slot] {
+			stats.recentOldest[slot] = sent
+		}
+	}
+}
+
+// oldestMessageAge devuelve la edad del mensaje más antiguo que recibieron los
+// consumidores de la cola en el último minuto; false si no recibieron ninguno
+func (stats *queueStats) oldestMessageAge(now time.Time) (time.Duration, bool) {
+	stats.mu.Lock()
+	defer stats.mu.Unlock()
+
+	var oldest int64
+	for i, second := range stats.recentAt {
+		if now.Unix()-second >= int64(len(stats.recent)) || stats.recentOldest[i] == 0 {
+			continue
+		}
+		if oldest == 0 || stats.recentOldest[i] < oldest {
+			oldest = stats.recentOldest[i]
+		}
+	}
+	if oldest == 0 {
+		return 0, false
+	}
+	return now.Sub(time.UnixMilli(oldest)), true
+}
+
+// GetQueueStatus obtiene el estado de una cola a partir de sus atributos, sin recibir
+// mensajes: visibles, en proceso, con retardo y los de su cola de mensajes fallidos. La
+// edad del mensaje más antiguo sale del SentTimestamp de los mensajes que recibieron los
+// consumidores de esta instancia en el último minuto.
+func (s *NotificationService) GetQueueStatus(ctx context.Context, queueType string) (*model.QueueStatus, error) {
+	client, err := s.queueClient(queueType)
+	if err != nil {
+		return nil, err
+	}
+
+	attributes, err := client.GetQueueAttributes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &model.QueueStatus{
+		QueueType: queueType,
+		Visible:   queueAttribute(attributes.Attributes, "ApproximateNumberOfMessages"),
+		InFlight:  queueAttribute(attributes.Attributes, "ApproximateNumberOfMessagesNotVisible"),
+		Delayed:   queueAttribute(attributes.Attributes, "ApproximateNumberOfMessagesDelayed"),
+	}
+
+	if stats := s.queueStats[queueType]; stats != nil && status.Visible+status.InFlight > 0 {
+		if age, ok := stats.oldestMessageAge(time.Now()); ok {
+			seconds := int64(age.Seconds())
+			status.OldestMessageAgeSeconds = &seconds
+		}
+	}
+
+	if dlq := s.deadLetterQueues[queueType]; dlq != nil {
+		dlqAttributes, err := dlq.GetQueueAttributes(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("error getting dead-letter queue attributes: %w", err)
+		}
+		deadLetters := queueAttribute(dlqAttributes.Attributes, "ApproximateNumberOfMessages") +
+			queueAttribute(dlqAttributes.Attributes, "ApproximateNumberOfMessagesNotVisible")
+		status.DeadLetters = &deadLetters
+	}
+
+	return status, nil
+}
+
+// GetEventQueueStatus obtiene el estado de la cola de eventos
+func (s *NotificationService) GetEventQueueStatus(ctx context.Context) (*model.QueueStatus, error) {
+	return s.GetQueueStatus(ctx, "events")
+}
+
+// GetReservationQueueStatus obtiene el estado de la cola de reservas
+func (s *NotificationService) GetReservationQueueStatus(ctx context.Context) (*model.QueueStatus, error) {
+	return s.GetQueueStatus(ctx, "reservations")
+}
+
+// GetReminderQueueStatus obtiene el estado de la cola de recordatorios
+func (s *NotificationService) GetReminderQueueStatus(ctx context.Context) (*model.QueueStatus, error) {
+	return s.GetQueueStatus(ctx, "reminders")
+}
+
+// queueAttribute lee un contador de los atributos de una cola; 0 si falta
+func queueAttribute(attributes map[string]string, name string) int64 {
+	value, err := strconv.ParseInt(attributes[name], 10, 64)
+	if err != nil {
+		return 0
+	}
+	return value
+}
+
+// GetQueueMetrics obtiene lo que procesaron los consumidores de una cola desde que
+// arrancó el servicio: mensajes por resultado, rendimiento del último minuto y tiempo medio
+func (s *NotificationService) GetQueueMetrics(ctx context.Context, queueType string) (*model.QueueMetrics, error) {
+	stats := s.queueStats[queueType]
+	if stats == nil {
+		return nil, fmt.Errorf("invalid queue type: %s", queueType)
+	}
+	now := time.Now()
+
+	stats.mu.Lock()
+	defer stats.mu.Unlock()
+
+	metrics := &model.QueueMetrics{
+		QueueType:    queueType,
+		Since:        s.startedAt,
+		Completed:    stats.outcomes[outcomeCompleted],
+		Retried:      stats.outcomes[outcomeRetried],
+		DeadLettered: stats.outcomes[outcomeDeadLettered],
+		Failed:       stats.outcomes[outcomeFailed],
+	}
+	metrics.Processed = metrics.Completed + metrics.Retried + metrics.DeadLettered + metrics.Failed
+
+	if metrics.Processed > 0 {
+		metrics.FailureRate = float64(metrics.Processed-metrics.Completed) / float64(metrics.Processed)
+		metrics.AverageProcessingMs = float64(stats.busy.Milliseconds()) / float64(metrics.Processed)
+	}
+	for i, second := range stats.recentAt {
+		if now.Unix()-second < int64(len(stats.recent)) {
+			metrics.ThroughputPerMinute += stats.recent[i]
+		}
+	}
+	if !stats.lastMessageAt.IsZero() {
+		lastMessageAt := stats.lastMessageAt
+		metrics.LastMessageAt = &lastMessageAt
+	}
+	if !stats.lastFailureAt.IsZero() {
+		lastFailureAt := stats.lastFailureAt
+		metrics.LastFailureAt = &lastFailureAt
+	}
+
+	return metrics, nil
+}
+
+// GetEventQueueMetrics obtiene las métricas de la cola de eventos
+func (s *NotificationService) GetEventQueueMetrics(ctx context.Context) (*model.QueueMetrics, error) {
+	return s.GetQueueMetrics(ctx, "events")
+}
+
+// GetReservationQueueMetrics obtiene las métricas de la cola de reservas
+func (s *NotificationService) GetReservationQueueMetrics(ctx context.Context) (*model.QueueMetrics, error) {
+	return s.GetQueueMetrics(ctx, "reservations")
+}
+
+// GetReminderQueueMetrics obtiene las métricas de la cola de recordatorios
+func (s *NotificationService) GetReminderQueueMetrics(ctx context.Context) (*model.QueueMetrics, error) {
+	return s.GetQueueMetrics(ctx, "reminders")
+}
+
+// PurgeQueue elimina todos los mensajes de una cola. SQS admite una purga cada 60
+// segundos por cola y puede tardar ese tiempo en completarla.
+func (s *NotificationService) PurgeQueue(ctx context.Context, queueType string) error {
+	client, err := s.queueClient(queueType)
+	if err != nil {
+		return err
+	}
+
+	if err := client.PurgeQueue(ctx); err != nil {
+		var apiErr interface{ ErrorCode() string }
+		if errors.As(err, &apiErr) && (apiErr.ErrorCode() == "PurgeQueueInProgress" || apiErr.ErrorCode() == "AWS.SimpleQueueService.PurgeQueueInProgress") {
+			return fmt.Errorf("%w: %s", ErrPurgeInProgress, queueType)
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,292 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jhonathanssegura/ticket-notification/internal/model"
+	"github.com/jhonathanssegura/ticket-notification/internal/queue"
+)
+
+// ErrInvalidReminderType indica un tipo de recordatorio desconocido
+var ErrInvalidReminderType = errors.New("invalid reminder type")
+
+// dueReminderBatch es cuántos recordatorios vencidos se leen por consulta
+const dueReminderBatch = 100
+
+// ScheduleEventReminders programa los recordatorios del evento para cada asistente.
+// Los que ya deberían haberse enviado no se programan. Si el evento ya tenía
+// recordatorios con otra fecha o lugar, primero se mueven todos a los nuevos.
+func (s *NotificationService) ScheduleEventReminders(ctx context.Context, eventID string, req model.ScheduleRemindersRequest) ([]model.ScheduledReminder, error) {
+	reminderTypes := req.ReminderTypes
+	if len(reminderTypes) == 0 {
+		reminderTypes = model.DefaultReminderTypes
+	}
+	for _, reminderType := range reminderTypes {
+		if _, ok := reminderType.Offset(); !ok {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidReminderType, reminderType)
+		}
+	}
+
+	// Validar todos los asistentes antes de guardar ninguno
+	attendeeChannels := make([][]model.ChannelRecipient, len(req.Attendees))
+	for i, attendee := range req.Attendees {
+		channels, err := s.queueChannels(attendee.Recipient, attendee.Channels)
+		if err != nil {
+			return nil, err
+		}
+		attendeeChannels[i] = channelsFromQueue(channels)
+	}
+
+	existing, err := s.dbClient.GetEventReminders(eventID)
+	if err != nil {
+		return nil, fmt.Errorf("error loading reminders of event %s: %w", eventID, err)
+	}
+	for _, reminder := range existing {
+		if reminder.Status != model.ReminderStatusCancelled &&
+			(!reminder.EventDate.Equal(req.EventDate) || reminder.Location != req.Location) {
+			if _, err := s.RescheduleEventReminders(ctx, eventID, req.EventDate, req.Location); err != nil {
+				return nil, err
+			}
+			break
+		}
+	}
+
+	now := time.Now()
+	var scheduled []model.ScheduledReminder
+	for i, attendee := range req.Attendees {
+		for _, reminderType := range reminderTypes {
+			offset, _ := reminderType.Offset()
+			fireAt := req.EventDate.Add(-offset)
+			if !fireAt.After(now) {
+				continue
+			}
+
+			reminder := model.ScheduledReminder{
+				EventID:      eventID,
+				ID:           model.ReminderID(reminderType, attendee.Recipient),
+				EventName:    req.EventName,
+				EventDate:    req.EventDate,
+				Location:     req.Location,
+				Recipient:    attendee.Recipient,
+				Channels:     attendeeChannels[i],
+				ReminderType: reminderType,
+				FireAt:       fireAt,
+				Status:       model.ReminderStatusPending,
+				CreatedAt:    now,
+				UpdatedAt:    now,
+			}
+			saved, err := s.dbClient.SaveScheduledReminder(reminder)
+			if err != nil {
+				return scheduled, fmt.Errorf("error saving reminder %s: %w", reminder.ID, err)
+			}
+			if saved {
+				scheduled = append(scheduled, reminder)
+			}
+		}
+	}
+
+	log.Printf("⏰ %d recordatorios programados para el evento %s", len(scheduled), eventID)
+	return scheduled, nil
+}
+
+// RescheduleEventReminders mueve los recordatorios del evento a la nueva fecha y, si
+// se indica, al nuevo lugar. Los que quedan en el pasado se omiten y los ya enviados
+// cuya nueva hora todavía no llegó se vuelven a programar. Devuelve cuántos se movieron.
+func (s *NotificationService) RescheduleEventReminders(ctx context.Context, eventID string, eventDate time.Time, location string) (int, error) {
+	reminders, err := s.dbClient.GetEventReminders(eventID)
+	if err != nil {
+		return 0, fmt.Errorf("error loading reminders of event %s: %w", eventID, err)
+	}
+
+	now := time.Now()
+	moved := 0
+	for _, reminder := range reminders {
+		if reminder.Status == model.ReminderStatusCancelled {
+			continue
+		}
+		offset, ok := reminder.ReminderType.Offset()
+		if !ok {
+			continue
+		}
+
+		reminder.EventDate = eventDate
+		if location != "" {
+			reminder.Location = location
+		}
+		reminder.FireAt = eventDate.Add(-offset)
+		switch {
+		case reminder.FireAt.After(now):
+			// Un mensaje que ya estaba en la cola se descarta al procesarse porque la fecha o el lugar no coinciden
+			reminder.Status = model.ReminderStatusPending
+		case reminder.Status == model.ReminderStatusSent:
+			// Ya se envió y la nueva hora también pasó: no se repite
+		default:
+			reminder.Status = model.ReminderStatusSkipped
+		}
+
+		updated, err := s.dbClient.MoveReminder(reminder, now)
+		if err != nil {
+			return moved, fmt.Errorf("error moving reminder %s: %w", reminder.ID, err)
+		}
+		if updated {
+			moved++
+		}
+	}
+
+	log.Printf("⏰ %d recordatorios del evento %s movidos al %s", moved, eventID, eventDate.Format(time.RFC3339))
+	return moved, nil
+}
+
+// CancelEventReminders cancela los recordatorios pendientes del evento, o solo los de
+// recipient si no está vacío. Devuelve cuántos se cancelaron.
+func (s *NotificationService) CancelEventReminders(ctx context.Context, eventID, recipient string) (int, error) {
+	reminders, err := s.dbClient.GetEventReminders(eventID)
+	if err != nil {
+		return 0, fmt.Errorf("error loading reminders of event %s: %w", eventID, err)
+	}
+
+	now := time.Now()
+	cancelled := 0
+	for _, reminder := range reminders {
+		if recipient != "" && reminder.Recipient != recipient {
+			continue
+		}
+		updated, err := s.dbClient.UpdateReminderStatus(reminder.EventID, reminder.ID,
+			[]model.ReminderStatus{model.ReminderStatusPending, model.ReminderStatusEnqueued}, model.ReminderStatusCancelled, now)
+		if err != nil {
+			return cancelled, fmt.Errorf("error cancelling reminder %s: %w", reminder.ID, err)
+		}
+		if updated {
+			cancelled++
+		}
+	}
+
+	if cancelled > 0 {
+		log.Printf("⏰ %d recordatorios del evento %s cancelados", cancelled, eventID)
+	}
+	return cancelled, nil
+}
+
+// DispatchDueReminders pasa a la cola de recordatorios los que deben enviarse dentro de
+// lookahead, con el retardo que les falta. Como SQS no admite retardos de más de 15
+// minutos, lookahead no debe superarlos; el resto espera en DynamoDB.
+func (s *NotificationService) DispatchDueReminders(ctx context.Context, lookahead time.Duration) error {
+	if lookahead > queue.MaxDelay {
+		lookahead = queue.MaxDelay
+	}
+
+	for ctx.Err() == nil {
+		now := time.Now()
+		reminders, err := s.dbClient.GetDueReminders(now.Add(lookahead), dueReminderBatch)
+		if err != nil {
+			return fmt.Errorf("error loading due reminders: %w", err)
+		}
+
+		dispatched := 0
+		for _, reminder := range reminders {
+			if ctx.Err() != nil {
+				break
+			}
+
+			// Si el servicio estuvo detenido y el evento ya empezó, el recordatorio no tiene sentido
+			if !reminder.EventDate.After(now) {
+				if _, err := s.dbClient.UpdateReminderStatus(reminder.EventID, reminder.ID,
+					[]model.ReminderStatus{model.ReminderStatusPending}, model.ReminderStatusSkipped, now); err != nil {
+					return err
+				}
+				dispatched++
+				continue
+			}
+
+			claimed, err := s.dbClient.ClaimDueReminder(reminder, now)
+			if err != nil {
+				return err
+			}
+			if !claimed {
+				continue
+			}
+
+			delay := time.Until(reminder.FireAt)
+			if delay < 0 {
+				delay = 0
+			}
+			// Ya tomado, el envío no se interrumpe aunque el servicio se esté deteniendo
+			if err := s.reminderQueue.SendDelayedReminderMessage(context.WithoutCancel(ctx), reminderMessage(reminder), delay); err != nil {
+				// Vuelve a quedar pendiente para el próximo ciclo
+				if _, revertErr := s.dbClient.UpdateReminderStatus(reminder.EventID, reminder.ID,
+					[]model.ReminderStatus{model.ReminderStatusEnqueued}, model.ReminderStatusPending, time.Now()); revertErr != nil {
+					log.Printf("Error devolviendo el recordatorio %s a pendiente: %v", reminder.ID, revertErr)
+				}
+				return fmt.Errorf("error sending reminder %s to queue: %w", reminder.ID, err)
+			}
+			dispatched++
+		}
+
+		if dispatched > 0 {
+			log.Printf("⏰ %d recordatorios enviados a la cola", dispatched)
+		}
+		// Sin avances, los restantes los tomó otra instancia o se movieron
+		if len(reminders) < dueReminderBatch || dispatched == 0 {
+			return nil
+		}
+	}
+
+	return ctx.Err()
+}
+
+// currentScheduledReminder comprueba que el recordatorio programado de un mensaje de la
+// cola siga vigente: que no se haya cancelado ni movido desde que se encoló
+func (s *NotificationService) currentScheduledReminder(msg queue.ReminderMessage) (bool, error) {
+	reminder, err := s.dbClient.GetScheduledReminder(msg.EventID, msg.ReminderID)
+	if err != nil {
+		return false, err
+	}
+
+	eventDate, err := time.Parse(time.RFC3339, msg.EventDate)
+	if err != nil {
+		return false, fmt.Errorf("invalid event date %q: %w", msg.EventDate, err)
+	}
+
+	return reminder.Status == model.ReminderStatusEnqueued &&
+		reminder.EventDate.Equal(eventDate) &&
+		reminder.Location == msg.Location, nil
+}
+
+// reminderMessage construye el mensaje de la cola para un recordatorio programado
+func reminderMessage(reminder model.ScheduledReminder) queue.ReminderMessage {
+	channels := make([]queue.ChannelRecipient, len(reminder.Channels))
+	for i, target := range reminder.Channels {
+		channels[i] = queue.ChannelRecipient{
+			Channel:   string(target.Channel),
+			Recipient: target.Recipient,
+		}
+	}
+
+	return queue.ReminderMessage{
+		EventID:      reminder.EventID,
+		EventName:    reminder.EventName,
+		EventDate:    reminder.EventDate.Format(time.RFC3339),
+		Location:     reminder.Location,
+		Recipient:    reminder.Recipient,
+		ReminderType: string(reminder.ReminderType),
+		TemplateID:   defaultTemplateID(model.NotificationTypeEventReminder),
+		Channels:     channels,
+		ReminderID:   reminder.ID,
+		// Un recordatorio movido a otra fecha o lugar se vuelve a enviar con otra clave
+		IdempotencyKey: reminderIdempotencyKey(reminder),
+	}
+}
+
+// reminderIdempotencyKey construye la clave de un recordatorio a partir de su fecha y de
+// un resumen del lugar, que puede ser largo y contener cualquier carácter
+func reminderIdempotencyKey(reminder model.ScheduledReminder) string {
+	location := sha256.Sum256([]byte(reminder.Location))
+	return "reminder:" + reminder.EventID + ":" + reminder.ID + ":" +
+		reminder.EventDate.Format(time.RFC3339) + ":" + hex.EncodeToString(location[:8])
+}
@@ -0,0 +1,279 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"net/http"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/jhonathanssegura/ticket-notification/internal/channel"
+	"github.com/jhonathanssegura/ticket-notification/internal/db"
+	"github.com/jhonathanssegura/ticket-notification/internal/model"
+	"github.com/jhonathanssegura/ticket-notification/internal/queue"
+)
+
+var (
+	// errMalformedMessage indica un mensaje de la cola que no se puede interpretar
+	errMalformedMessage = errors.New("malformed queue message")
+	// errDeliveryInProgress indica que otro consumidor está entregando la notificación
+	errDeliveryInProgress = errors.New("notification is already being delivered")
+)
+
+// RetryPolicy define cuántas veces se reintenta un mensaje de la cola según la
+// prioridad de la notificación y cuánto se espera antes de cada reintento
+type RetryPolicy struct {
+	// BaseDelay es la espera antes del primer reintento; se duplica en cada uno hasta queue.MaxDelay
+	BaseDelay  time.Duration
+	MaxRetries map[model.NotificationPriority]int
+}
+
+// DefaultRetryPolicy reintenta más veces las notificaciones más prioritarias
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		BaseDelay: 30 * time.Second,
+		MaxRetries: map[model.NotificationPriority]int{
+			model.NotificationPriorityUrgent: 8,
+			model.NotificationPriorityHigh:   6,
+			model.NotificationPriorityNormal: 4,
+			model.NotificationPriorityLow:    2,
+		},
+	}
+}
+
+// maxRetries devuelve los reintentos permitidos para la prioridad; sin prioridad se usa la normal
+func (p RetryPolicy) maxRetries(priority string) int {
+	if retries, ok := p.MaxRetries[model.NotificationPriority(priority)]; ok {
+		return retries
+	}
+	return p.MaxRetries[model.NotificationPriorityNormal]
+}
+
+// delay calcula la espera antes del reintento indicado (0 el primero), con un 20% de
+// variación aleatoria para que los mensajes que fallaron juntos no vuelvan juntos
+func (p RetryPolicy) delay(retry int) time.Duration {
+	delay := queue.MaxDelay
+	if retry < 16 && p.BaseDelay<<retry < queue.MaxDelay {
+		delay = p.BaseDelay << retry
+	}
+	delay += time.Duration(rand.Int63n(int64(delay)/5 + 1))
+	if delay > queue.MaxDelay {
+		delay = queue.MaxDelay
+	}
+	return delay
+}
+
+// ConfigureRetries define la política de reintentos de los mensajes de las colas y la
+// cola de mensajes fallidos (DLQ) de cada tipo de cola. Sin DLQ, los mensajes que no
+// pueden reintentarse no se eliminan de su cola.
+func (s *NotificationService) ConfigureRetries(policy RetryPolicy, deadLetterQueues map[string]*queue.SQSClient) {
+	s.retryPolicy = policy
+	s.deadLetterQueues = deadLetterQueues
+}
+
+// retryEnvelope tiene los campos comunes a los mensajes de todas las colas que usa la
+// política de reintentos
+type retryEnvelope struct {
+	Priority       string `json:"priority"`
+	RetryCount     int    `json:"retry_count"`
+	IdempotencyKey string `json:"idempotency_key"`
+}
+
+// awsErrorCodes clasifica los códigos de error de SES, SNS, SQS y DynamoDB: true si el
+// error es transitorio y vale la pena reintentar
+var awsErrorCodes = map[string]bool{
+	"Throttling":                              true,
+	"ThrottlingException":                     true,
+	"Throttled":                               true,
+	"RequestThrottled":                        true,
+	"TooManyRequestsException":                true,
+	"ProvisionedThroughputExceededException":  true,
+	"RequestLimitExceeded":                    true,
+	"LimitExceededException":                  true,
+	"ServiceUnavailable":                      true,
+	"InternalFailure":                         true,
+	"InternalError":                           true,
+	"RequestTimeout":                          true,
+	"RequestTimeoutException":                 true,
+	"MessageRejected":                         false,
+	"MailFromDomainNotVerifiedException":      false,
+	"ConfigurationSetDoesNotExistException":   false,
+	"AccountSendingPausedException":           false,
+	"InvalidParameterValue":                   false,
+	"InvalidParameter":                        false,
+	"ValidationError":                         false,
+	"ValidationException":                     false,
+	"AccessDenied":                            false,
+	"AccessDeniedException":                   false,
+	"AuthorizationError":                      false,
+	"EndpointDisabled":                        false,
+	"InvalidMessageContents":                  false,
+	"QueueDoesNotExist":                       false,
+	"AWS.SimpleQueueService.NonExistentQueue": false,
+}
+
+// retryable indica si un error de entrega puede resolverse reintentando más tarde. Los
+// errores sin clasificar (de red, plazos vencidos...) se consideran transitorios.
+func retryable(err error) bool {
+	var permanent *channel.PermanentError
+	if errors.As(err, &permanent) {
+		return false
+	}
+	if errors.Is(err, errMalformedMessage) || errors.Is(err, ErrInvalidLocale) || errors.Is(err, ErrMissingTemplateVariable) ||
+		errors.Is(err, ErrTemplateInactive) || errors.Is(err, channel.ErrInvalidRecipient) || errors.Is(err, channel.ErrUnknownChannel) {
+		return false
+	}
+
+	// Errores de los proveedores de push
+	var provider interface{ Retryable() bool }
+	if errors.As(err, &provider) {
+		return provider.Retryable()
+	}
+
+	// Errores de las APIs de AWS, por código y, si no se conoce, por estado HTTP
+	var apiErr interface{ ErrorCode() string }
+	if errors.As(err, &apiErr) {
+		if transient, ok := awsErrorCodes[apiErr.ErrorCode()]; ok {
+			return transient
+		}
+	}
+	var response interface{ HTTPStatusCode() int }
+	if errors.As(err, &response) {
+		status := response.HTTPStatusCode()
+		return status == http.StatusTooManyRequests || status == http.StatusRequestTimeout || status >= 500
+	}
+
+	// Una plantilla o un recurso inexistente no aparecerá por reintentar
+	return !errors.Is(err, db.ErrNotFound)
+}
+
+// retryMessage decide qué hacer con un mensaje cuya entrega falló: lo reencola con
+// un retardo creciente si el error es transitorio y le quedan reintentos, o lo mueve a
+// la cola de mensajes fallidos. Devuelve error si el mensaje debe quedar en su cola.
+func (s *NotificationService) retryMessage(ctx context.Context, message sqstypes.Message, queueType string, envelope retryEnvelope, cause error) (messageOutcome, error) {
+	if errors.Is(cause, errDeliveryInProgress) {
+		// SQS vuelve a entregar el mensaje cuando vence su invisibilidad
+		return outcomeFailed, cause
+	}
+
+	maxRetries := s.retryPolicy.maxRetries(envelope.Priority)
+	if !retryable(cause) {
+		return s.deadLetter(ctx, message, queueType, cause)
+	}
+	if envelope.RetryCount >= maxRetries {
+		return s.deadLetter(ctx, message, queueType, fmt.Errorf("retries exhausted after %d attempts: %w", envelope.RetryCount+1, cause))
+	}
+
+	client, err := s.queueClient(queueType)
+	if err != nil {
+		return outcomeFailed, err
+	}
+	body, err := rewriteMessage(*message.Body, envelope.RetryCount+1, messageKey(envelope, message))
+	if err != nil {
+		return outcomeFailed, fmt.Errorf("error rewriting message %s: %w", aws.ToString(message.MessageId), err)
+	}
+
+	delay := s.retryPolicy.delay(envelope.RetryCount)
+	if err := client.SendRawMessage(ctx, body, messageAttributes(message.MessageAttributes, nil), delay); err != nil {
+		return outcomeFailed, fmt.Errorf("error requeuing message %s after %v: %w", aws.ToString(message.MessageId), cause, err)
+	}
+
+	log.Printf("🔁 Mensaje %s de la cola %s reencolado para el reintento %d de %d en %s: %v",
+		aws.ToString(message.MessageId), queueType, envelope.RetryCount+1, maxRetries, delay.Round(time.Second), cause)
+	return outcomeRetried, nil
+}
+
+// deadLetter mueve el mensaje a la cola de mensajes fallidos de su cola, con el motivo del fallo
+func (s *NotificationService) deadLetter(ctx context.Context, message sqstypes.Message, queueType string, cause error) (messageOutcome, error) {
+	dlq := s.deadLetterQueues[queueType]
+	if dlq == nil {
+		log.Printf("⚠️  Mensaje %s de la cola %s sin reintentos y sin cola de mensajes fallidos: %v", aws.ToString(message.MessageId), queueType, cause)
+		return outcomeFailed, cause
+	}
+
+	// Se fija la clave de idempotencia para que al reenviarlo actualice la misma notificación
+	body := aws.ToString(message.Body)
+	var envelope retryEnvelope
+	if err := json.Unmarshal([]byte(body), &envelope); err == nil {
+		if rewritten, err := rewriteMessage(body, envelope.RetryCount, messageKey(envelope, message)); err == nil {
+			body = rewritten
+		}
+	}
+
+	reason := cause.Error()
+	if len(reason) > 1000 {
+		reason = reason[:1000]
+	}
+	attributes := messageAttributes(message.MessageAttributes, map[string]string{
+		"FailureReason":   reason,
+		"FailedAt":        time.Now().UTC().Format(time.RFC3339),
+		"SourceMessageID": aws.ToString(message.MessageId),
+	})
+	if err := dlq.SendRawMessage(ctx, body, attributes, 0); err != nil {
+		return outcomeFailed, fmt.Errorf("error moving message %s to the dead-letter queue after %v: %w", aws.ToString(message.MessageId), cause, err)
+	}
+
+	log.Printf("☠️  Mensaje %s de la cola %s movido a la cola de mensajes fallidos: %v", aws.ToString(message.MessageId), queueType, cause)
+	return outcomeDeadLettered, nil
+}
+
+// messageKey devuelve la clave que identifica la notificación del mensaje: su clave de
+// idempotencia o, si no tiene, el ID del mensaje SQS
+func messageKey(envelope retryEnvelope, message sqstypes.Message) string {
+	if envelope.IdempotencyKey != "" {
+		return envelope.IdempotencyKey
+	}
+	return aws.ToString(message.MessageId)
+}
+
+// rewriteMessage devuelve el cuerpo del mensaje con el contador de reintentos y la clave
+// de idempotencia indicados, conservando el resto de campos
+func rewriteMessage(body string, retryCount int, idempotencyKey string) (string, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(body), &fields); err != nil {
+		return "", err
+	}
+
+	delete(fields, "retry_count")
+	if retryCount > 0 {
+		fields["retry_count"] = json.RawMessage(fmt.Sprint(retryCount))
+	}
+	key, err := json.Marshal(idempotencyKey)
+	if err != nil {
+		return "", err
+	}
+	fields["idempotency_key"] = key
+
+	rewritten, err := json.Marshal(fields)
+	if err != nil {
+		return "", err
+	}
+	return string(rewritten), nil
+}
+
+// deadLetterAttributes son los atributos que describen el fallo de un mensaje en la DLQ
+var deadLetterAttributes = []string{"FailureReason", "FailedAt", "SourceMessageID"}
+
+// messageAttributes copia los atributos de un mensaje recibido, sin los del fallo, y
+// añade los indicados
+func messageAttributes(received map[string]sqstypes.MessageAttributeValue, extra map[string]string) map[string]sqstypes.MessageAttributeValue {
+	attributes := make(map[string]sqstypes.MessageAttributeValue, len(received)+len(extra))
+	for name, value := range received {
+		attributes[name] = value
+	}
+	for _, name := range deadLetterAttributes {
+		delete(attributes, name)
+	}
+	for name, value := range extra {
+		attributes[name] = sqstypes.MessageAttributeValue{
+			DataType:    aws.String("String"),
+			StringValue: aws.String(value),
+		}
+	}
+	return attributes
+}
@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/jhonathanssegura/ticket-notification/internal/channel"
+	"github.com/jhonathanssegura/ticket-notification/internal/db"
+	"github.com/jhonathanssegura/ticket-notification/internal/push"
+	"github.com/jhonathanssegura/ticket-notification/internal/queue"
+)
+
+// apiError imita los errores de las APIs de AWS, que exponen su código y el estado HTTP
+type apiError struct {
+	code   string
+	status int
+}
+
+func (e *apiError) Error() string       { return fmt.Sprintf("api error %s (%d)", e.code, e.status) }
+func (e *apiError) ErrorCode() string   { return e.code }
+func (e *apiError) HTTPStatusCode() int { return e.status }
+
+func TestRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"error de red", errors.New("dial tcp: connection refused"), true},
+		{"plazo vencido", context.DeadlineExceeded, true},
+		{"throttling de SES", &apiError{code: "Throttling", status: http.StatusBadRequest}, true},
+		{"capacidad de DynamoDB", fmt.Errorf("error saving notification: %w", &apiError{code: "ProvisionedThroughputExceededException", status: http.StatusBadRequest}), true},
+		{"mensaje rechazado por SES", &apiError{code: "MessageRejected", status: http.StatusBadRequest}, false},
+		{"código desconocido con 503", &apiError{code: "SomethingNew", status: http.StatusServiceUnavailable}, true},
+		{"código desconocido con 429", &apiError{code: "SomethingNew", status: http.StatusTooManyRequests}, true},
+		{"código desconocido con 400", &apiError{code: "SomethingNew", status: http.StatusBadRequest}, false},
+		{"push con rate limiting", &push.ProviderError{StatusCode: http.StatusTooManyRequests, Reason: "TooManyRequests"}, true},
+		{"push con token inválido", &push.ProviderError{StatusCode: http.StatusGone, Reason: "Unregistered"}, false},
+		{"error permanente de un canal", &channel.PermanentError{Err: errors.New("webhook returned 404")}, false},
+		{"error permanente envuelto", fmt.Errorf("delivery failed: %w", &channel.PermanentError{Err: context.DeadlineExceeded}), false},
+		{"mensaje mal formado", fmt.Errorf("%w: unexpected end of JSON input", errMalformedMessage), false},
+		{"destinatario inválido", fmt.Errorf("sms: %w", channel.ErrInvalidRecipient), false},
+		{"canal desconocido", channel.ErrUnknownChannel, false},
+		{"falta una variable", fmt.Errorf("%w: event_name", ErrMissingTemplateVariable), false},
+		{"plantilla desactivada", fmt.Errorf("%w: welcome_template", ErrTemplateInactive), false},
+		{"plantilla inexistente", fmt.Errorf("error rendering template x: %w", fmt.Errorf("template x %w", db.ErrNotFound)), false},
+		{"texto con not found sin sentinel", errors.New("upstream: route not found, retry later"), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := retryable(tt.err); got != tt.want {
+				t.Errorf("retryable(%v) = %v, se esperaba %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryPolicyDelay(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: 30 * time.Second}
+
+	tests := []struct {
+		retry int
+		min   time.Duration
+		max   time.Duration
+	}{
+		{0, 30 * time.Second, 36 * time.Second},
+		{1, time.Minute, 72 * time.Second},
+		{3, 4 * time.Minute, 288 * time.Second},
+		{5, queue.MaxDelay, queue.MaxDelay},
+		{40, queue.MaxDelay, queue.MaxDelay},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("reintento %d", tt.retry), func(t *testing.T) {
+			// La variación es aleatoria: se comprueba que siempre quede dentro del margen
+			for i := 0; i < 100; i++ {
+				got := policy.delay(tt.retry)
+				if got < tt.min || got > tt.max {
+					t.Fatalf("delay(%d) = %v, se esperaba entre %v y %v", tt.retry, got, tt.min, tt.max)
+				}
+			}
+		})
+	}
+}
+
+func TestRetryPolicyMaxRetries(t *testing.T) {
+	policy := DefaultRetryPolicy()
+
+	tests := []struct {
+		priority string
+		want     int
+	}{
+		{"urgent", 8},
+		{"high", 6},
+		{"normal", 4},
+		{"low", 2},
+		{"", 4},
+		{"unknown", 4},
+	}
+
+	for _, tt := range tests {
+		if got := policy.maxRetries(tt.priority); got != tt.want {
+			t.Errorf("maxRetries(%q) = %d, se esperaba %d", tt.priority, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jhonathanssegura/ticket-notification/internal/db"
+	"github.com/jhonathanssegura/ticket-notification/internal/model"
+)
+
+// ErrInvalidSendAt indica una hora de envío o zona horaria inválida
+var ErrInvalidSendAt = errors.New("invalid send_at")
+
+// scheduledBatch es cuántas notificaciones programadas vencidas se leen por consulta
+const scheduledBatch = 100
+
+// localSendAtLayouts son los formatos aceptados para send_at sin desplazamiento horario
+var localSendAtLayouts = []string{
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+}
+
+// resolveSendAt interpreta send_at. Si no incluye desplazamiento horario se usa
+// timezone o, si está vacío, la zona horaria de las preferencias del destinatario (UTC
+// si no tiene).
+func (s *NotificationService) resolveSendAt(sendAt, timezone, recipient string) (time.Time, error) {
+	if parsed, err := time.Parse(time.RFC3339, sendAt); err == nil {
+		return parsed, nil
+	}
+
+	if timezone == "" && recipient != "" {
+		preferences, err := s.loadPreferences(recipient)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if preferences != nil {
+			timezone = preferences.Timezone
+		}
+	}
+
+	location := time.UTC
+	if timezone != "" {
+		loaded, err := time.LoadLocation(timezone)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("%w: unknown timezone %q", ErrInvalidSendAt, timezone)
+		}
+		location = loaded
+	}
+
+	for _, layout := range localSendAtLayouts {
+		if parsed, err := time.ParseInLocation(layout, sendAt, location); err == nil {
+			return parsed, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("%w: %q is not a date like 2026-03-01T09:00 or RFC 3339", ErrInvalidSendAt, sendAt)
+}
+
+// validateSendAt comprueba el formato de send_at y timezone sin consultar preferencias
+func validateSendAt(sendAt, timezone string) error {
+	if timezone != "" {
+		if _, err := time.LoadLocation(timezone); err != nil {
+			return fmt.Errorf("%w: unknown timezone %q", ErrInvalidSendAt, timezone)
+		}
+	}
+	if _, err := time.Parse(time.RFC3339, sendAt); err == nil {
+		return nil
+	}
+	for _, layout := range localSendAtLayouts {
+		if _, err := time.Parse(layout, sendAt); err == nil {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %q is not a date like 2026-03-01T09:00 or RFC 3339", ErrInvalidSendAt, sendAt)
+}
+
+// scheduleNotification guarda la notificación como programada para enviarla en sendAt
+func (s *NotificationService) scheduleNotification(notification *model.Notification, sendAt time.Time) error {
+	notification.Status = model.NotificationStatusScheduled
+	notification.SendAt = &sendAt
+	created, err := s.dbClient.CreateNotification(*notification)
+	if err != nil {
+		return fmt.Errorf("error saving scheduled notification %s: %w", notification.ID, err)
+	}
+	// Un reintento de la misma solicitud devuelve la notificación que ya se programó
+	if !created {
+		existing, err := s.dbClient.GetNotificationByID(notification.ID.String())
+		if err != nil {
+			return err
+		}
+		*notification = *existing
+		return nil
+	}
+
+	log.Printf("🕒 Notificación %s programada para %s", notification.ID, sendAt.Format(time.RFC3339))
+	return nil
+}
+
+// RescheduleNotification cambia la hora de envío de una notificación programada
+func (s *NotificationService) RescheduleNotification(ctx context.Context, notificationID string, req model.RescheduleNotificationRequest) (*model.Notification, error) {
+	notification, err := s.dbClient.GetNotificationByID(notificationID)
+	if err != nil {
+		return nil, err
+	}
+	if notification.Status != model.NotificationStatusScheduled {
+		return nil, db.ErrNotScheduled
+	}
+
+	sendAt, err := s.resolveSendAt(req.SendAt, req.Timezone, notification.Recipient)
+	if err != nil {
+		return nil, err
+	}
+	if !sendAt.After(time.Now()) {
+		return nil, fmt.Errorf("%w: %s is in the past", ErrInvalidSendAt, sendAt.Format(time.RFC3339))
+	}
+
+	return s.dbClient.RescheduleNotification(notificationID, sendAt, time.Now())
+}
+
+// CancelScheduledNotification cancela una notificación programada antes de que se envíe
+func (s *NotificationService) CancelScheduledNotification(ctx context.Context, notificationID string) (*model.Notification, error) {
+	if _, err := s.dbClient.GetNotificationByID(notificationID); err != nil {
+		return nil, err
+	}
+	return s.dbClient.CancelScheduledNotification(notificationID, time.Now())
+}
+
+// DispatchScheduledNotifications envía las notificaciones programadas cuya hora de envío
+// ya llegó. Al tomarlas pasan al outbox, que las retoma si la entrega no termina.
+func (s *NotificationService) DispatchScheduledNotifications(ctx context.Context) error {
+	for ctx.Err() == nil {
+		now := time.Now()
+		notifications, err := s.dbClient.GetScheduledNotifications("", &now, scheduledBatch)
+		if err != nil {
+			return fmt.Errorf("error loading scheduled notifications: %w", err)
+		}
+
+		dispatched := 0
+		for i := range notifications {
+			if ctx.Err() != nil {
+				break
+			}
+			notification := &notifications[i]
+
+			nextAttemptAt := now.Add(outboxHandoff)
+			claimed, err := s.dbClient.ClaimScheduledNotification(*notification, nextAttemptAt, now)
+			if err != nil {
+				return err
+			}
+			if !claimed {
+				continue
+			}
+			dispatched++
+
+			notification.Status = model.NotificationStatusPending
+			notification.NextAttemptAt = &nextAttemptAt
+			// Ya tomada, la entrega no se interrumpe aunque el servicio se esté deteniendo
+			s.deliverOutbox(context.WithoutCancel(ctx), notification)
+		}
+
+		if dispatched > 0 {
+			log.Printf("🕒 %d notificaciones programadas enviadas", dispatched)
+		}
+		// Sin avances, las restantes las tomó otra instancia o se reprogramaron
+		if len(notifications) < scheduledBatch || dispatched == 0 {
+			return nil
+		}
+	}
+
+	return ctx.Err()
+}
@@ -0,0 +1,482 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jhonathanssegura/ticket-notification/internal/db"
+	"github.com/jhonathanssegura/ticket-notification/internal/email"
+	"github.com/jhonathanssegura/ticket-notification/internal/i18n"
+	"github.com/jhonathanssegura/ticket-notification/internal/model"
+)
+
+var (
+	// ErrMissingTemplateVariable indica que faltan datos para renderizar una plantilla
+	ErrMissingTemplateVariable = errors.New("missing template variable")
+	// ErrTemplateInactive indica que la plantilla existe pero está desactivada
+	ErrTemplateInactive = errors.New("template is not active")
+)
+
+// placeholderPattern reconoce los marcadores {{variable}} de las plantillas
+var placeholderPattern = regexp.MustCompile(`{{\s*([a-zA-Z0-9_]+)\s*}}`)
+
+// RenderedTemplate representa el resultado de renderizar una plantilla
+type RenderedTemplate struct {
+	TemplateID  string `json:"template_id"`
+	Version     int    `json:"version"`
+	Locale      string `json:"locale"`
+	Subject     string `json:"subject"`
+	Content     string `json:"content"`
+	HTMLContent string `json:"html_content,omitempty"`
+}
+
+// TemplateRenderer carga plantillas de la tabla notification_templates y las renderiza
+type TemplateRenderer struct {
+	dbClient *db.DynamoClient
+}
+
+// NewTemplateRenderer crea una nueva instancia del renderizador de plantillas
+func NewTemplateRenderer(dbClient *db.DynamoClient) *TemplateRenderer {
+	return &TemplateRenderer{
+		dbClient: dbClient,
+	}
+}
+
+// Load obtiene una plantilla activa por ID o por nombre. Solo si no existe en la base
+// de datos se usa la plantilla por defecto con ese nombre, si la hay; una plantilla
+// desactivada devuelve ErrTemplateInactive.
+func (r *TemplateRenderer) Load(ref string) (*model.NotificationTemplate, error) {
+	var template *model.NotificationTemplate
+	var err error
+
+	if _, parseErr := uuid.Parse(ref); parseErr == nil {
+		template, err = r.dbClient.GetNotificationTemplate(ref)
+	} else {
+		template, err = r.dbClient.GetNotificationTemplateByName(ref)
+	}
+
+	if err == nil {
+		if !template.IsActive {
+			return nil, fmt.Errorf("%w: %s", ErrTemplateInactive, ref)
+		}
+		return template, nil
+	}
+	if !errors.Is(err, db.ErrNotFound) {
+		return nil, fmt.Errorf("error loading template %s: %w", ref, err)
+	}
+
+	if defaultTemplate, ok := defaultTemplates[ref]; ok {
+		return &defaultTemplate, nil
+	}
+	return nil, fmt.Errorf("template %s %w", ref, db.ErrNotFound)
+}
+
+// Render carga una plantilla por ID o nombre y la renderiza con los datos indicados, en
+// la variante más cercana a locale. Si version es mayor que cero se usa esa versión en
+// lugar de la vigente.
+func (r *TemplateRenderer) Render(ref string, version int, locale string, data map[string]interface{}) (*RenderedTemplate, error) {
+	template, err := r.Load(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	if version > 0 && version != template.Version {
+		pinned, err := r.dbClient.GetNotificationTemplateVersion(template.ID.String(), version)
+		if err != nil {
+			return nil, fmt.Errorf("template %s version %d: %w", ref, version, err)
+		}
+		template = TemplateFromVersion(template, pinned)
+	}
+
+	rendered, err := RenderTemplate(LocalizeTemplate(template, locale), data)
+	if err != nil {
+		return nil, err
+	}
+	rendered.TemplateID = ref
+
+	return rendered, nil
+}
+
+// RenderTemplate sustituye los marcadores {{variable}} del asunto y el contenido.
+// Falla si falta alguna variable declarada o usada por la plantilla. El cuerpo HTML
+// se renderiza con los valores escapados y con el CSS aplicado inline; si la plantilla
+// no tiene texto plano, se genera a partir del HTML.
+func RenderTemplate(template *model.NotificationTemplate, data map[string]interface{}) (*RenderedTemplate, error) {
+	var missing []string
+	seen := make(map[string]bool)
+
+	check := func(name string) {
+		name = strings.TrimSpace(name)
+		if name == "" || seen[name] {
+			return
+		}
+		seen[name] = true
+		if value, ok := data[name]; !ok || value == nil {
+			missing = append(missing, name)
+		}
+	}
+
+	for _, variable := range template.Variables {
+		check(variable)
+	}
+	for _, text := range []string{template.Subject, template.Content, template.HTMLContent} {
+		for _, match := range placeholderPattern.FindAllStringSubmatch(text, -1) {
+			check(match[1])
+		}
+	}
+
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("%w: %s", ErrMissingTemplateVariable, strings.Join(missing, ", "))
+	}
+
+	rendered := &RenderedTemplate{
+		TemplateID: template.ID.String(),
+		Version:    template.Version,
+		Locale:     templateLocale(template),
+		Subject:    renderTemplateText(template.Subject, data),
+		Content:    renderTemplateText(template.Content, data),
+	}
+
+	if template.HTMLContent != "" {
+		rendered.HTMLContent = email.InlineCSS(renderTemplateHTML(template.HTMLContent, data))
+		if strings.TrimSpace(rendered.Content) == "" {
+			rendered.Content = email.HTMLToText(rendered.HTMLContent)
+		}
+	}
+
+	return rendered, nil
+}
+
+// LocalizeTemplate devuelve la plantilla con el asunto y el contenido de la variante
+// más cercana a locale, recorriendo la cadena de respaldo (pt-BR → pt → es). Si ningún
+// idioma de la cadena tiene variante se usa el idioma propio de la plantilla.
+func LocalizeTemplate(template *model.NotificationTemplate, locale string) *model.NotificationTemplate {
+	base := templateLocale(template)
+	for _, tag := range i18n.Chain(locale) {
+		if tag == base {
+			break
+		}
+		variant, ok := template.Locales[tag]
+		if !ok {
+			continue
+		}
+
+		localized := *template
+		localized.Locale = tag
+		localized.Subject = variant.Subject
+		localized.Content = variant.Content
+		localized.HTMLContent = variant.HTMLContent
+		return &localized
+	}
+	return template
+}
+
+// NormalizeTemplateLocales valida el idioma y las traducciones de la plantilla y los
+// deja en su forma canónica. Una plantilla sin idioma queda en el idioma por defecto.
+func NormalizeTemplateLocales(template *model.NotificationTemplate) error {
+	base := i18n.Normalize(template.Locale)
+	if template.Locale == "" {
+		base = i18n.DefaultLocale
+	}
+	if base == "" {
+		return fmt.Errorf("%w: %q is not a language tag like es or pt-BR", ErrInvalidLocale, template.Locale)
+	}
+	template.Locale = base
+
+	if len(template.Locales) == 0 {
+		template.Locales = nil
+		return nil
+	}
+
+	locales := make(map[string]model.TemplateLocale, len(template.Locales))
+	for tag, variant := range template.Locales {
+		normalized := i18n.Normalize(tag)
+		switch {
+		case normalized == "":
+			return fmt.Errorf("%w: %q is not a language tag like es or pt-BR", ErrInvalidLocale, tag)
+		case normalized == base:
+			return fmt.Errorf("%w: %s is the template's own locale, set subject and content instead", ErrInvalidLocale, tag)
+		case variant.Subject == "":
+			return fmt.Errorf("%w: %s needs a subject", ErrInvalidLocale, tag)
+		case variant.Content == "" && variant.HTMLContent == "":
+			return fmt.Errorf("%w: %s needs content, html_content or both", ErrInvalidLocale, tag)
+		}
+		if _, duplicated := locales[normalized]; duplicated {
+			return fmt.Errorf("%w: %s appears more than once", ErrInvalidLocale, normalized)
+		}
+		locales[normalized] = variant
+	}
+	template.Locales = locales
+
+	return nil
+}
+
+// templateLocale devuelve el idioma propio de la plantilla; las plantillas anteriores
+// a las traducciones no lo tienen y están en el idioma por defecto
+func templateLocale(template *model.NotificationTemplate) string {
+	if template.Locale == "" {
+		return i18n.DefaultLocale
+	}
+	return template.Locale
+}
+
+// TemplateFromVersion construye la plantilla tal como era en la versión indicada
+func TemplateFromVersion(current *model.NotificationTemplate, version *model.TemplateVersion) *model.NotificationTemplate {
+	template := *current
+	template.Name = version.Name
+	template.Type = version.Type
+	template.Subject = version.Subject
+	template.Content = version.Content
+	template.HTMLContent = version.HTMLContent
+	template.Locale = version.Locale
+	template.Locales = version.Locales
+	template.Variables = version.Variables
+	template.Version = version.Version
+	return &template
+}
+
+// renderTemplateText reemplaza los marcadores {{variable}} por los valores de data
+func renderTemplateText(text string, data map[string]interface{}) string {
+	return placeholderPattern.ReplaceAllStringFunc(text, func(placeholder string) string {
+		name := placeholderPattern.FindStringSubmatch(placeholder)[1]
+		return fmt.Sprintf("%v", data[name])
+	})
+}
+
+// renderTemplateHTML reemplaza los marcadores escapando los valores para HTML
+func renderTemplateHTML(text string, data map[string]interface{}) string {
+	return placeholderPattern.ReplaceAllStringFunc(text, func(placeholder string) string {
+		name := placeholderPattern.FindStringSubmatch(placeholder)[1]
+		return html.EscapeString(fmt.Sprintf("%v", data[name]))
+	})
+}
+
+// defaultTemplates contiene las plantillas usadas cuando no existen en la base de datos
+var defaultTemplates = map[string]model.NotificationTemplate{
+	"event_created_template": {
+		Name:      "event_created_template",
+		Type:      model.NotificationTypeEventCreated,
+		Subject:   "Nuevo Evento: {{event_name}}",
+		Content:   "Se ha creado un nuevo evento: {{event_name}} en {{location}} el {{event_date}}",
+		Locale:    "es",
+		Variables: []string{"event_name", "event_date", "location"},
+		IsActive:  true,
+		Locales: map[string]model.TemplateLocale{
+			"en": {
+				Subject: "New Event: {{event_name}}",
+				Content: "A new event has been created: {{event_name}} at {{location}} on {{event_date}}",
+			},
+			"pt": {
+				Subject: "Novo Evento: {{event_name}}",
+				Content: "Foi criado um novo evento: {{event_name}} em {{location}} no dia {{event_date}}",
+			},
+		},
+	},
+	"event_cancelled_template": {
+		Name:      "event_cancelled_template",
+		Type:      model.NotificationTypeEventCancelled,
+		Subject:   "Evento Cancelado: {{event_name}}",
+		Content:   "El evento '{{event_name}}' programado para el {{event_date}} en {{location}} ha sido cancelado.",
+		Locale:    "es",
+		Variables: []string{"event_name", "event_date", "location"},
+		IsActive:  true,
+		Locales: map[string]model.TemplateLocale{
+			"en": {
+				Subject: "Event Cancelled: {{event_name}}",
+				Content: "The event '{{event_name}}' scheduled for {{event_date}} at {{location}} has been cancelled.",
+			},
+			"pt": {
+				Subject: "Evento Cancelado: {{event_name}}",
+				Content: "O evento '{{event_name}}' programado para {{event_date}} em {{location}} foi cancelado.",
+			},
+		},
+	},
+	"event_updated_template": {
+		Name:      "event_updated_template",
+		Type:      model.NotificationTypeEventUpdated,
+		Subject:   "Evento Actualizado: {{event_name}}",
+		Content:   "El evento '{{event_name}}' ha cambiado:\n{{changes}}\n\nTe esperamos el {{event_date}} en {{location}}. Tu reserva sigue siendo válida.",
+		Locale:    "es",
+		Variables: []string{"event_name", "event_date", "location", "changes"},
+		IsActive:  true,
+		Locales: map[string]model.TemplateLocale{
+			"en": {
+				Subject: "Event Updated: {{event_name}}",
+				Content: "The event '{{event_name}}' has changed:\n{{changes}}\n\nSee you on {{event_date}} at {{location}}. Your reservation is still valid.",
+			},
+			"pt": {
+				Subject: "Evento Atualizado: {{event_name}}",
+				Content: "O evento '{{event_name}}' mudou:\n{{changes}}\n\nEsperamos você em {{event_date}} em {{location}}. Sua reserva continua válida.",
+			},
+		},
+	},
+	"event_reminder_template": {
+		Name:      "event_reminder_template",
+		Type:      model.NotificationTypeEventReminder,
+		Subject:   "Recordatorio: {{event_name}}",
+		Content:   "Te recordamos que el evento '{{event_name}}' será el {{event_date}} en {{location}}.",
+		Locale:    "es",
+		Variables: []string{"event_name", "event_date", "location"},
+		IsActive:  true,
+		Locales: map[string]model.TemplateLocale{
+			"en": {
+				Subject: "Reminder: {{event_name}}",
+				Content: "This is a reminder that the event '{{event_name}}' takes place on {{event_date}} at {{location}}.",
+			},
+			"pt": {
+				Subject: "Lembrete: {{event_name}}",
+				Content: "Lembramos que o evento '{{event_name}}' será em {{event_date}} em {{location}}.",
+			},
+		},
+	},
+	"reservation_created_template": {
+		Name:      "reservation_created_template",
+		Type:      model.NotificationTypeReservationCreated,
+		Subject:   "Reserva Confirmada: {{event_name}}",
+		Content:   "Tu reserva para el evento '{{event_name}}' el {{event_date}} en {{location}} ha sido confirmada. ID de reserva: {{reservation_id}}",
+		Locale:    "es",
+		Variables: []string{"event_name", "event_date", "location", "reservation_id"},
+		IsActive:  true,
+		Locales:   reservationConfirmedLocales,
+	},
+	"reservation_confirmed_template": {
+		Name:      "reservation_confirmed_template",
+		Type:      model.NotificationTypeReservationConfirmed,
+		Subject:   "Reserva Confirmada: {{event_name}}",
+		Content:   "Tu reserva para el evento '{{event_name}}' el {{event_date}} en {{location}} ha sido confirmada. ID de reserva: {{reservation_id}}",
+		Locale:    "es",
+		Variables: []string{"event_name", "event_date", "location", "reservation_id"},
+		IsActive:  true,
+		Locales:   reservationConfirmedLocales,
+	},
+	"reservation_cancelled_template": {
+		Name:      "reservation_cancelled_template",
+		Type:      model.NotificationTypeReservationCancelled,
+		Subject:   "Reserva Cancelada: {{event_name}}",
+		Content:   "Tu reserva para el evento '{{event_name}}' el {{event_date}} en {{location}} ha sido cancelada. ID de reserva: {{reservation_id}}",
+		Locale:    "es",
+		Variables: []string{"event_name", "event_date", "location", "reservation_id"},
+		IsActive:  true,
+		Locales: map[string]model.TemplateLocale{
+			"en": {
+				Subject: "Reservation Cancelled: {{event_name}}",
+				Content: "Your reservation for the event '{{event_name}}' on {{event_date}} at {{location}} has been cancelled. Reservation ID: {{reservation_id}}",
+			},
+			"pt": {
+				Subject: "Reserva Cancelada: {{event_name}}",
+				Content: "Sua reserva para o evento '{{event_name}}' em {{event_date}} em {{location}} foi cancelada. ID da reserva: {{reservation_id}}",
+			},
+		},
+	},
+	"ticket_generated_template": {
+		Name:      "ticket_generated_template",
+		Type:      model.NotificationTypeTicketGenerated,
+		Subject:   "Tu entrada para {{event_name}}",
+		Content:   "Tu entrada para el evento '{{event_name}}' el {{event_date}} en {{location}} está lista. Código de entrada: {{ticket_code}}. Preséntalo en el acceso al evento.",
+		Locale:    "es",
+		Variables: []string{"event_name", "event_date", "location", "ticket_code"},
+		IsActive:  true,
+		Locales: map[string]model.TemplateLocale{
+			"en": {
+				Subject: "Your ticket for {{event_name}}",
+				Content: "Your ticket for the event '{{event_name}}' on {{event_date}} at {{location}} is ready. Ticket code: {{ticket_code}}. Show it at the entrance.",
+			},
+			"pt": {
+				Subject: "Seu ingresso para {{event_name}}",
+				Content: "Seu ingresso para o evento '{{event_name}}' em {{event_date}} em {{location}} está pronto. Código do ingresso: {{ticket_code}}. Apresente-o na entrada do evento.",
+			},
+		},
+	},
+	"payment_received_template": {
+		Name:      "payment_received_template",
+		Type:      model.NotificationTypePaymentReceived,
+		Subject:   "Pago Recibido: {{amount}}",
+		Content:   "Hemos recibido tu pago de {{amount}}. Número de recibo: {{receipt_number}}",
+		Locale:    "es",
+		Variables: []string{"amount", "receipt_number"},
+		IsActive:  true,
+		Locales: map[string]model.TemplateLocale{
+			"en": {
+				Subject: "Payment Received: {{amount}}",
+				Content: "We have received your payment of {{amount}}. Receipt number: {{receipt_number}}",
+			},
+			"pt": {
+				Subject: "Pagamento Recebido: {{amount}}",
+				Content: "Recebemos seu pagamento de {{amount}}. Número do recibo: {{receipt_number}}",
+			},
+		},
+	},
+	"payment_failed_template": {
+		Name:      "payment_failed_template",
+		Type:      model.NotificationTypePaymentFailed,
+		Subject:   "Pago Rechazado: {{amount}}",
+		Content:   "No pudimos procesar tu pago de {{amount}}: {{reason}}. {{next_steps}}",
+		Locale:    "es",
+		Variables: []string{"amount", "reason", "next_steps"},
+		IsActive:  true,
+		Locales: map[string]model.TemplateLocale{
+			"en": {
+				Subject: "Payment Declined: {{amount}}",
+				Content: "We could not process your payment of {{amount}}: {{reason}}. {{next_steps}}",
+			},
+			"pt": {
+				Subject: "Pagamento Recusado: {{amount}}",
+				Content: "Não foi possível processar seu pagamento de {{amount}}: {{reason}}. {{next_steps}}",
+			},
+		},
+	},
+	"welcome_template": {
+		Name:      "welcome_template",
+		Type:      model.NotificationTypeWelcome,
+		Subject:   "¡Bienvenido, {{user_name}}!",
+		Content:   "Hola {{user_name}}, gracias por registrarte. Desde ahora te avisaremos aquí de tus reservas, entradas y eventos.",
+		Locale:    "es",
+		Variables: []string{"user_name"},
+		IsActive:  true,
+		Locales: map[string]model.TemplateLocale{
+			"en": {
+				Subject: "Welcome, {{user_name}}!",
+				Content: "Hi {{user_name}}, thanks for signing up. From now on we will keep you posted here about your reservations, tickets and events.",
+			},
+			"pt": {
+				Subject: "Boas-vindas, {{user_name}}!",
+				Content: "Olá {{user_name}}, obrigado por se cadastrar. A partir de agora avisaremos você aqui sobre suas reservas, ingressos e eventos.",
+			},
+		},
+	},
+	"password_reset_template": {
+		Name:      "password_reset_template",
+		Type:      model.NotificationTypePasswordReset,
+		Subject:   "Restablecer Contraseña",
+		Content:   "Recibimos una solicitud para restablecer tu contraseña. Usa este enlace: {{reset_url}}\n\nEl enlace vence el {{expires_at}} (en {{expires_in}}). Si no la solicitaste, ignora este mensaje.",
+		Locale:    "es",
+		Variables: []string{"reset_url", "expires_at", "expires_in"},
+		IsActive:  true,
+		Locales: map[string]model.TemplateLocale{
+			"en": {
+				Subject: "Reset Your Password",
+				Content: "We received a request to reset your password. Use this link: {{reset_url}}\n\nThe link expires on {{expires_at}} (in {{expires_in}}). If you did not request it, ignore this message.",
+			},
+			"pt": {
+				Subject: "Redefinir Senha",
+				Content: "Recebemos uma solicitação para redefinir sua senha. Use este link: {{reset_url}}\n\nO link expira em {{expires_at}} (em {{expires_in}}). Se você não a solicitou, ignore esta mensagem.",
+			},
+		},
+	},
+}
+
+// reservationConfirmedLocales son las traducciones del aviso de reserva confirmada
+var reservationConfirmedLocales = map[string]model.TemplateLocale{
+	"en": {
+		Subject: "Reservation Confirmed: {{event_name}}",
+		Content: "Your reservation for the event '{{event_name}}' on {{event_date}} at {{location}} has been confirmed. Reservation ID: {{reservation_id}}",
+	},
+	"pt": {
+		Subject: "Reserva Confirmada: {{event_name}}",
+		Content: "Sua reserva para o evento '{{event_name}}' em {{event_date}} em {{location}} foi confirmada. ID da reserva: {{reservation_id}}",
+	},
+}
@@ -0,0 +1,167 @@
+package service
+
+import (
+	"strings"
+
+	"github.com/jhonathanssegura/ticket-notification/internal/model"
+)
+
+// DiffLine representa una línea del diff de contenido
+type DiffLine struct {
+	Op   string `json:"op"` // "equal", "added", "removed"
+	Text string `json:"text"`
+}
+
+// FieldChange representa el cambio de un campo simple entre dos versiones
+type FieldChange struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// TemplateDiff representa las diferencias entre dos versiones de una plantilla
+type TemplateDiff struct {
+	FromVersion      int                   `json:"from_version"`
+	ToVersion        int                   `json:"to_version"`
+	Name             *FieldChange          `json:"name,omitempty"`
+	Type             *FieldChange          `json:"type,omitempty"`
+	Subject          *FieldChange          `json:"subject,omitempty"`
+	Content          []DiffLine            `json:"content"`
+	HTMLContent      []DiffLine            `json:"html_content"`
+	Locale           *FieldChange          `json:"locale,omitempty"`
+	Locales          map[string]LocaleDiff `json:"locales,omitempty"`
+	VariablesAdded   []string              `json:"variables_added"`
+	VariablesRemoved []string              `json:"variables_removed"`
+}
+
+// LocaleDiff representa los cambios de la traducción de una plantilla a un idioma
+type LocaleDiff struct {
+	Status      string       `json:"status"` // "added", "removed", "changed"
+	Subject     *FieldChange `json:"subject,omitempty"`
+	Content     []DiffLine   `json:"content"`
+	HTMLContent []DiffLine   `json:"html_content"`
+}
+
+// DiffTemplateVersions compara dos versiones de una plantilla
+func DiffTemplateVersions(from, to *model.TemplateVersion) TemplateDiff {
+	diff := TemplateDiff{
+		FromVersion:      from.Version,
+		ToVersion:        to.Version,
+		Name:             fieldChange(from.Name, to.Name),
+		Type:             fieldChange(string(from.Type), string(to.Type)),
+		Subject:          fieldChange(from.Subject, to.Subject),
+		Content:          diffLines(strings.Split(from.Content, "\n"), strings.Split(to.Content, "\n")),
+		HTMLContent:      diffLines(strings.Split(from.HTMLContent, "\n"), strings.Split(to.HTMLContent, "\n")),
+		Locale:           fieldChange(from.Locale, to.Locale),
+		Locales:          diffLocales(from.Locales, to.Locales),
+		VariablesAdded:   []string{},
+		VariablesRemoved: []string{},
+	}
+
+	fromVariables := make(map[string]bool, len(from.Variables))
+	for _, variable := range from.Variables {
+		fromVariables[variable] = true
+	}
+	toVariables := make(map[string]bool, len(to.Variables))
+	for _, variable := range to.Variables {
+		toVariables[variable] = true
+		if !fromVariables[variable] {
+			diff.VariablesAdded = append(diff.VariablesAdded, variable)
+		}
+	}
+	for _, variable := range from.Variables {
+		if !toVariables[variable] {
+			diff.VariablesRemoved = append(diff.VariablesRemoved, variable)
+		}
+	}
+
+	return diff
+}
+
+// diffLocales compara las traducciones de dos versiones; solo incluye los idiomas que cambiaron
+func diffLocales(from, to map[string]model.TemplateLocale) map[string]LocaleDiff {
+	diffs := make(map[string]LocaleDiff)
+	for tag, toLocale := range to {
+		fromLocale, existed := from[tag]
+		if existed && fromLocale == toLocale {
+			continue
+		}
+		status := "changed"
+		if !existed {
+			status = "added"
+		}
+		diffs[tag] = localeDiff(status, fromLocale, toLocale)
+	}
+	for tag, fromLocale := range from {
+		if _, ok := to[tag]; !ok {
+			diffs[tag] = localeDiff("removed", fromLocale, model.TemplateLocale{})
+		}
+	}
+
+	if len(diffs) == 0 {
+		return nil
+	}
+	return diffs
+}
+
+// localeDiff compara dos traducciones a un mismo idioma
+func localeDiff(status string, from, to model.TemplateLocale) LocaleDiff {
+	return LocaleDiff{
+		Status:      status,
+		Subject:     fieldChange(from.Subject, to.Subject),
+		Content:     diffLines(strings.Split(from.Content, "\n"), strings.Split(to.Content, "\n")),
+		HTMLContent: diffLines(strings.Split(from.HTMLContent, "\n"), strings.Split(to.HTMLContent, "\n")),
+	}
+}
+
+// fieldChange devuelve el cambio de un campo, o nil si no cambió
+func fieldChange(from, to string) *FieldChange {
+	if from == to {
+		return nil
+	}
+	return &FieldChange{From: from, To: to}
+}
+
+// diffLines calcula un diff de líneas basado en la subsecuencia común más larga
+func diffLines(a, b []string) []DiffLine {
+	// lcs[i][j] es la longitud de la subsecuencia común más larga de a[i:] y b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var lines []DiffLine
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			lines = append(lines, DiffLine{Op: "equal", Text: a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			lines = append(lines, DiffLine{Op: "removed", Text: a[i]})
+			i++
+		default:
+			lines = append(lines, DiffLine{Op: "added", Text: b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		lines = append(lines, DiffLine{Op: "removed", Text: a[i]})
+	}
+	for ; j < len(b); j++ {
+		lines = append(lines, DiffLine{Op: "added", Text: b[j]})
+	}
+
+	return lines
+}
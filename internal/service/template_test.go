@@ -0,0 +1,76 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/jhonathanssegura/ticket-notification/internal/model"
+)
+
+func TestLocalizeTemplate(t *testing.T) {
+	template := &model.NotificationTemplate{
+		Locale:  "es",
+		Subject: "Nuevo evento",
+		Content: "Se creó el evento",
+		Locales: map[string]model.TemplateLocale{
+			"en":    {Subject: "New event", Content: "The event was created"},
+			"pt":    {Subject: "Novo evento", Content: "Foi criado o evento"},
+			"pt-BR": {Subject: "Novo evento!", Content: "O evento foi criado"},
+		},
+	}
+
+	tests := []struct {
+		name        string
+		locale      string
+		wantLocale  string
+		wantSubject string
+	}{
+		{"variante exacta", "pt-BR", "pt-BR", "Novo evento!"},
+		{"región sin variante usa el idioma", "pt-PT", "pt", "Novo evento"},
+		{"etiqueta sin normalizar", "pt_br", "pt-BR", "Novo evento!"},
+		{"región de un idioma con variante", "en-GB", "en", "New event"},
+		{"idioma sin variante usa el de la plantilla", "fr", "es", "Nuevo evento"},
+		{"idioma propio de la plantilla", "es-AR", "es", "Nuevo evento"},
+		{"sin idioma", "", "es", "Nuevo evento"},
+		{"etiqueta inválida", "???", "es", "Nuevo evento"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := LocalizeTemplate(template, tt.locale)
+			if templateLocale(got) != tt.wantLocale || got.Subject != tt.wantSubject {
+				t.Errorf("LocalizeTemplate(%q) = %s %q, se esperaba %s %q",
+					tt.locale, templateLocale(got), got.Subject, tt.wantLocale, tt.wantSubject)
+			}
+		})
+	}
+
+	if template.Subject != "Nuevo evento" {
+		t.Errorf("LocalizeTemplate modificó la plantilla original: %q", template.Subject)
+	}
+}
+
+func TestLocalizeTemplateOtherBaseLocale(t *testing.T) {
+	// Una plantilla escrita en inglés cae en el español por defecto antes que en su idioma
+	template := &model.NotificationTemplate{
+		Locale:  "en",
+		Subject: "Welcome",
+		Locales: map[string]model.TemplateLocale{
+			"es": {Subject: "Bienvenido"},
+		},
+	}
+
+	tests := []struct {
+		locale string
+		want   string
+	}{
+		{"en-US", "Welcome"},
+		{"de", "Bienvenido"},
+		{"es-MX", "Bienvenido"},
+	}
+
+	for _, tt := range tests {
+		if got := LocalizeTemplate(template, tt.locale); got.Subject != tt.want {
+			t.Errorf("LocalizeTemplate(%q) = %q, se esperaba %q", tt.locale, got.Subject, tt.want)
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jhonathanssegura/ticket-notification/internal/i18n"
+	"github.com/jhonathanssegura/ticket-notification/internal/model"
+	"github.com/jhonathanssegura/ticket-notification/internal/queue"
+)
+
+// ErrResetLinkExpired indica que el enlace para restablecer la contraseña ya venció
+var ErrResetLinkExpired = errors.New("password reset link has already expired")
+
+// NotifyTicketGenerated notifica la entrada emitida para una reserva. Se encola en la
+// cola de reservas y lleva adjuntos el ticket, el QR y la invitación de calendario.
+func (s *NotificationService) NotifyTicketGenerated(ctx context.Context, req model.TicketNotification) error {
+	msg := queue.ReservationNotificationMessage{
+		ReservationID:  req.ReservationID,
+		EventID:        req.EventID,
+		EventName:      req.EventName,
+		EventDate:      req.EventDate.Format(time.RFC3339),
+		Location:       req.Location,
+		Recipient:      req.Recipient,
+		Type:           string(model.NotificationTypeTicketGenerated),
+		Priority:       string(req.Priority),
+		TemplateID:     defaultTemplateID(model.NotificationTypeTicketGenerated),
+		TicketCode:     req.TicketCode,
+		QRCodeURL:      req.QRCodeURL,
+		Timezone:       req.Timezone,
+		IdempotencyKey: idempotencyKeyFrom(ctx),
+	}
+
+	locale, err := normalizeLocale(req.Locale, req.Timezone)
+	if err != nil {
+		return err
+	}
+	msg.Locale = locale
+
+	attachments, err := ticketAttachments(req.Ticket)
+	if err != nil {
+		return err
+	}
+	msg.Attachments = attachments
+
+	channels, err := s.queueChannels(req.Recipient, req.Channels)
+	if err != nil {
+		return err
+	}
+	msg.Channels = channels
+
+	// Quien tiene entrada forma parte de la audiencia del evento
+	if err := s.registerAudienceMember(model.ReservationNotification{
+		ReservationID: req.ReservationID,
+		EventID:       req.EventID,
+		Recipient:     req.Recipient,
+		Channels:      req.Channels,
+	}); err != nil {
+		return err
+	}
+
+	if err := s.reservationQueue.SendReservationNotification(ctx, msg); err != nil {
+		return fmt.Errorf("error sending ticket notification to queue: %w", err)
+	}
+
+	return nil
+}
+
+// NotifyPaymentReceived envía el comprobante de un pago recibido
+func (s *NotificationService) NotifyPaymentReceived(ctx context.Context, req model.PaymentNotification) (*model.Notification, error) {
+	locale, err := s.resolveLocale(primaryRecipient(req.Recipient, req.Channels), req.Locale)
+	if err != nil {
+		return nil, err
+	}
+	formatter := i18n.New(locale, nil)
+
+	receiptNumber := req.ReceiptNumber
+	if receiptNumber == "" {
+		receiptNumber = req.PaymentID
+	}
+
+	data := paymentData(req, formatter)
+	data["receipt_number"] = receiptNumber
+
+	var attachments []model.Attachment
+	if req.Receipt != nil {
+		attachments = append(attachments, *req.Receipt)
+	}
+
+	return s.sendTransactional(ctx, model.NotificationTypePaymentReceived, req.Priority, model.NotificationPriorityNormal,
+		req.Recipient, req.Channels, formatter.Locale, data, attachments)
+}
+
+// NotifyPaymentFailed avisa que un pago fue rechazado y cómo reintentarlo
+func (s *NotificationService) NotifyPaymentFailed(ctx context.Context, req model.PaymentNotification) (*model.Notification, error) {
+	locale, err := s.resolveLocale(primaryRecipient(req.Recipient, req.Channels), req.Locale)
+	if err != nil {
+		return nil, err
+	}
+	formatter := i18n.New(locale, nil)
+
+	reason := strings.TrimSpace(req.FailureReason)
+	if reason == "" {
+		reason = formatter.T("payment_failed_reason")
+	}
+	nextSteps := formatter.T("payment_failed_steps")
+	if req.RetryURL != "" {
+		nextSteps = formatter.T("payment_failed_retry", req.RetryURL)
+	}
+
+	data := paymentData(req, formatter)
+	data["reason"] = reason
+	data["next_steps"] = nextSteps
+	if req.RetryURL != "" {
+		data["retry_url"] = req.RetryURL
+	}
+
+	return s.sendTransactional(ctx, model.NotificationTypePaymentFailed, req.Priority, model.NotificationPriorityHigh,
+		req.Recipient, req.Channels, formatter.Locale, data, nil)
+}
+
+// NotifyWelcome da la bienvenida a un usuario recién registrado
+func (s *NotificationService) NotifyWelcome(ctx context.Context, req model.WelcomeNotification) (*model.Notification, error) {
+	data := map[string]interface{}{
+		"user_name": req.UserName,
+	}
+
+	return s.sendTransactional(ctx, model.NotificationTypeWelcome, req.Priority, model.NotificationPriorityNormal,
+		req.Recipient, req.Channels, req.Locale, data, nil)
+}
+
+// NotifyPasswordReset envía el enlace para restablecer la contraseña y cuándo vence
+func (s *NotificationService) NotifyPasswordReset(ctx context.Context, req model.PasswordResetNotification) (*model.Notification, error) {
+	expiresIn := time.Until(req.ExpiresAt)
+	if expiresIn <= 0 {
+		return nil, ErrResetLinkExpired
+	}
+
+	formatter, err := s.formatterFor(primaryRecipient(req.Recipient, req.Channels), req.Locale, req.Timezone)
+	if err != nil {
+		return nil, err
+	}
+
+	data := map[string]interface{}{
+		"reset_url":  req.ResetURL,
+		"expires_at": formatter.DateTime(req.ExpiresAt),
+		"expires_in": formatter.Duration(expiresIn),
+	}
+
+	return s.sendTransactional(ctx, model.NotificationTypePasswordReset, req.Priority, model.NotificationPriorityHigh,
+		req.Recipient, req.Channels, formatter.Locale, data, nil)
+}
+
+// sendTransactional envía al momento una notificación con la plantilla por defecto del tipo
+func (s *NotificationService) sendTransactional(ctx context.Context, notificationType model.NotificationType, priority, defaultPriority model.NotificationPriority,
+	recipient string, channels []model.ChannelRecipient, locale string, data map[string]interface{}, attachments []model.Attachment) (*model.Notification, error) {
+	if priority == "" {
+		priority = defaultPriority
+	}
+
+	return s.SendNotification(ctx, model.CreateNotificationRequest{
+		Type:        notificationType,
+		Priority:    priority,
+		Recipient:   recipient,
+		Channels:    channels,
+		TemplateID:  defaultTemplateID(notificationType),
+		Locale:      locale,
+		Data:        data,
+		Attachments: attachments,
+	})
+}
+
+// paymentData arma los datos de plantilla comunes a las notificaciones de pago. amount
+// es el monto con el formato y el símbolo de moneda del idioma ("1.234,50 €").
+func paymentData(req model.PaymentNotification, formatter i18n.Formatter) map[string]interface{} {
+	data := map[string]interface{}{
+		"payment_id": req.PaymentID,
+		"amount":     formatter.Money(req.Amount, req.Currency),
+		"currency":   strings.ToUpper(req.Currency),
+	}
+	if req.ReservationID != "" {
+		data["reservation_id"] = req.ReservationID
+	}
+	return data
+}
@@ -0,0 +1,189 @@
+package sms
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Encoding define la codificación con la que viaja un SMS
+type Encoding string
+
+const (
+	EncodingGSM7 Encoding = "GSM-7"
+	EncodingUCS2 Encoding = "UCS-2"
+)
+
+const (
+	// gsm7Basic es el alfabeto básico GSM 03.38; cada carácter ocupa un septeto
+	gsm7Basic = "@£$¥èéùìòÇ\nØø\rÅåΔ_ΦΓΛΩΠΨΣΘΞÆæßÉ !\"#¤%&'()*+,-./0123456789:;<=>?" +
+		"¡ABCDEFGHIJKLMNOPQRSTUVWXYZÄÖÑÜ§¿abcdefghijklmnopqrstuvwxyzäöñüà"
+	// gsm7Extension son los caracteres de la tabla de extensión; ocupan dos septetos
+	gsm7Extension = "^{}\\[~]|€\f"
+
+	// ellipsis marca el contenido truncado; se usa "..." porque "…" no es GSM-7
+	ellipsis = "..."
+)
+
+// Límites por segmento, en septetos (GSM-7) o unidades UTF-16 (UCS-2). Los
+// mensajes de varios segmentos pierden espacio en la cabecera de concatenación.
+const (
+	gsm7SingleSegment = 160
+	gsm7MultiSegment  = 153
+	ucs2SingleSegment = 70
+	ucs2MultiSegment  = 67
+)
+
+var e164Pattern = regexp.MustCompile(`^\+[1-9][0-9]{1,14}$`)
+
+// ErrInvalidPhoneNumber indica que el número no está en formato E.164
+var ErrInvalidPhoneNumber = errors.New("phone number must be in E.164 format, e.g. +5491112345678")
+
+// Info resume cómo se codifica y cuántos segmentos ocupa un texto
+type Info struct {
+	Encoding Encoding `json:"encoding"`
+	Units    int      `json:"units"`
+	Segments int      `json:"segments"`
+}
+
+// ValidateE164 comprueba que el número de teléfono esté en formato E.164
+func ValidateE164(phoneNumber string) error {
+	if !e164Pattern.MatchString(phoneNumber) {
+		return ErrInvalidPhoneNumber
+	}
+	return nil
+}
+
+// DetectEncoding devuelve GSM-7 si todos los caracteres pertenecen al alfabeto
+// GSM, o UCS-2 en caso contrario
+func DetectEncoding(text string) Encoding {
+	for _, r := range text {
+		if !strings.ContainsRune(gsm7Basic, r) && !strings.ContainsRune(gsm7Extension, r) {
+			return EncodingUCS2
+		}
+	}
+	return EncodingGSM7
+}
+
+// Analyze calcula la codificación, la longitud y los segmentos de un texto
+func Analyze(text string) Info {
+	encoding := DetectEncoding(text)
+
+	units := 0
+	for _, r := range text {
+		units += runeUnits(r, encoding)
+	}
+
+	info := Info{Encoding: encoding, Units: units}
+	switch {
+	case units == 0:
+		info.Segments = 0
+	case units <= Capacity(encoding, 1):
+		info.Segments = 1
+	default:
+		perSegment := gsm7MultiSegment
+		if encoding == EncodingUCS2 {
+			perSegment = ucs2MultiSegment
+		}
+		info.Segments = (units + perSegment - 1) / perSegment
+	}
+
+	return info
+}
+
+// Capacity devuelve cuántas unidades caben en un mensaje de hasta maxSegments segmentos
+func Capacity(encoding Encoding, maxSegments int) int {
+	if maxSegments < 1 {
+		maxSegments = 1
+	}
+
+	if encoding == EncodingUCS2 {
+		if maxSegments == 1 {
+			return ucs2SingleSegment
+		}
+		return ucs2MultiSegment * maxSegments
+	}
+
+	if maxSegments == 1 {
+		return gsm7SingleSegment
+	}
+	return gsm7MultiSegment * maxSegments
+}
+
+// Truncate recorta el texto para que ocupe como máximo maxSegments segmentos,
+// sin partir caracteres y marcando el corte con "..."
+func Truncate(text string, maxSegments int) string {
+	encoding := DetectEncoding(text)
+	capacity := Capacity(encoding, maxSegments)
+	if Analyze(text).Units <= capacity {
+		return text
+	}
+
+	limit := capacity - len(ellipsis)
+	units := 0
+	var builder strings.Builder
+	for _, r := range text {
+		size := runeUnits(r, encoding)
+		if units+size > limit {
+			break
+		}
+		builder.WriteRune(r)
+		units += size
+	}
+
+	return strings.TrimRightFunc(builder.String(), unicode.IsSpace) + ellipsis
+}
+
+// Split divide el texto en mensajes de como máximo maxSegments segmentos cada
+// uno, cortando preferentemente en espacios y nunca dentro de un carácter
+func Split(text string, maxSegments int) []string {
+	encoding := DetectEncoding(text)
+	capacity := Capacity(encoding, maxSegments)
+	runes := []rune(strings.TrimSpace(text))
+
+	var parts []string
+	for len(runes) > 0 {
+		units, end, lastSpace := 0, 0, -1
+		for end < len(runes) {
+			size := runeUnits(runes[end], encoding)
+			if units+size > capacity {
+				break
+			}
+			if unicode.IsSpace(runes[end]) {
+				lastSpace = end
+			}
+			units += size
+			end++
+		}
+		// Si el texto no cabe entero, cortar en el último espacio para no partir palabras
+		if end < len(runes) && lastSpace > 0 {
+			end = lastSpace
+		}
+
+		if part := strings.TrimSpace(string(runes[:end])); part != "" {
+			parts = append(parts, part)
+		}
+		runes = runes[end:]
+		for len(runes) > 0 && unicode.IsSpace(runes[0]) {
+			runes = runes[1:]
+		}
+	}
+
+	return parts
+}
+
+// runeUnits devuelve cuántas unidades ocupa un carácter en la codificación dada
+func runeUnits(r rune, encoding Encoding) int {
+	if encoding == EncodingUCS2 {
+		// Los caracteres fuera del plano básico ocupan un par sustituto UTF-16
+		if r > 0xFFFF {
+			return 2
+		}
+		return 1
+	}
+	if strings.ContainsRune(gsm7Extension, r) {
+		return 2
+	}
+	return 1
+}
@@ -0,0 +1,148 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/jhonathanssegura/ticket-notification/internal/queue"
+)
+
+// MessageHandler procesa un mensaje de la cola; si devuelve error el mensaje no se elimina
+type MessageHandler func(ctx context.Context, message sqstypes.Message, queueType string) error
+
+// QueueConfig define cómo se consume una cola
+type QueueConfig struct {
+	QueueType         string
+	Queue             *queue.SQSClient
+	Concurrency       int
+	VisibilityTimeout time.Duration
+}
+
+// Pool consume continuamente las colas SQS configuradas
+type Pool struct {
+	handler MessageHandler
+	configs []QueueConfig
+	wg      sync.WaitGroup
+}
+
+// NewPool crea un pool de workers para las colas indicadas
+func NewPool(handler MessageHandler, configs ...QueueConfig) *Pool {
+	for i := range configs {
+		if configs[i].Concurrency <= 0 {
+			configs[i].Concurrency = 1
+		}
+		if configs[i].VisibilityTimeout <= 0 {
+			configs[i].VisibilityTimeout = 30 * time.Second
+		}
+	}
+
+	return &Pool{
+		handler: handler,
+		configs: configs,
+	}
+}
+
+// Start inicia los workers. Dejan de recibir mensajes cuando se cancela ctx,
+// pero terminan de procesar los que ya tienen en curso.
+func (p *Pool) Start(ctx context.Context) {
+	for _, cfg := range p.configs {
+		messages := make(chan sqstypes.Message)
+
+		p.wg.Add(1)
+		go p.poll(ctx, cfg, messages)
+
+		for i := 0; i < cfg.Concurrency; i++ {
+			p.wg.Add(1)
+			go p.work(ctx, cfg, messages)
+		}
+
+		log.Printf("Worker de cola %s iniciado con concurrencia %d", cfg.QueueType, cfg.Concurrency)
+	}
+}
+
+// Wait bloquea hasta que todos los workers hayan terminado
+func (p *Pool) Wait() {
+	p.wg.Wait()
+}
+
+// poll hace long polling de la cola y reparte los mensajes a los workers
+func (p *Pool) poll(ctx context.Context, cfg QueueConfig, messages chan<- sqstypes.Message) {
+	defer p.wg.Done()
+	defer close(messages)
+
+	batchSize := int32(cfg.Concurrency)
+	if batchSize > 10 {
+		batchSize = 10
+	}
+
+	for ctx.Err() == nil {
+		received, err := cfg.Queue.ReceiveMessages(ctx, batchSize)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Printf("Error recibiendo mensajes de la cola %s: %v", cfg.QueueType, err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(5 * time.Second):
+			}
+			continue
+		}
+
+		// Los mensajes ya recibidos se entregan aunque se esté apagando el servicio
+		for _, message := range received {
+			messages <- message
+		}
+	}
+}
+
+// work procesa mensajes hasta que el canal se cierra
+func (p *Pool) work(ctx context.Context, cfg QueueConfig, messages <-chan sqstypes.Message) {
+	defer p.wg.Done()
+
+	// El procesamiento no se corta al cancelar ctx para no dejar entregas a medias
+	processCtx := context.WithoutCancel(ctx)
+
+	for message := range messages {
+		p.handle(processCtx, cfg, message)
+	}
+}
+
+// handle procesa un mensaje extendiendo su visibilidad mientras dura el trabajo
+func (p *Pool) handle(ctx context.Context, cfg QueueConfig, message sqstypes.Message) {
+	done := make(chan struct{})
+	go p.keepInvisible(ctx, cfg, *message.ReceiptHandle, done)
+
+	err := p.handler(ctx, message, cfg.QueueType)
+	close(done)
+
+	if err != nil {
+		log.Printf("Error processing message %s: %v", *message.MessageId, err)
+		return
+	}
+
+	if err := cfg.Queue.DeleteMessage(ctx, *message.ReceiptHandle); err != nil {
+		log.Printf("Error deleting message %s: %v", *message.MessageId, err)
+	}
+}
+
+// keepInvisible renueva la visibilidad del mensaje hasta que se cierra done
+func (p *Pool) keepInvisible(ctx context.Context, cfg QueueConfig, receiptHandle string, done <-chan struct{}) {
+	ticker := time.NewTicker(cfg.VisibilityTimeout / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			if err := cfg.Queue.ChangeMessageVisibility(ctx, receiptHandle, int32(cfg.VisibilityTimeout.Seconds())); err != nil {
+				log.Printf("Error extendiendo visibilidad en la cola %s: %v", cfg.QueueType, err)
+			}
+		}
+	}
+}
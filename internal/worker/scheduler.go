@@ -0,0 +1,61 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// Scheduler ejecuta una tarea periódicamente, por ejemplo para pasar a la cola los
+// envíos programados que están por vencer
+type Scheduler struct {
+	name     string
+	interval time.Duration
+	task     func(ctx context.Context) error
+	wg       sync.WaitGroup
+}
+
+// NewScheduler crea un planificador que ejecuta task cada interval
+func NewScheduler(name string, interval time.Duration, task func(ctx context.Context) error) *Scheduler {
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+
+	return &Scheduler{
+		name:     name,
+		interval: interval,
+		task:     task,
+	}
+}
+
+// Start ejecuta la tarea de inmediato y luego cada intervalo hasta que se cancela ctx.
+// La tarea recibe ctx: al cancelarse debe terminar lo que tiene en curso y volver.
+func (s *Scheduler) Start(ctx context.Context) {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for {
+			if err := s.task(ctx); err != nil && ctx.Err() == nil {
+				log.Printf("Error en la tarea programada %s: %v", s.name, err)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	log.Printf("Tarea programada %s iniciada cada %s", s.name, s.interval)
+}
+
+// Wait bloquea hasta que la tarea haya terminado
+func (s *Scheduler) Wait() {
+	s.wg.Wait()
+}
@@ -40,6 +40,54 @@ create_dynamodb_table() {
     echo "✅ Tabla $table_name creada exitosamente"
 }
 
+# Función para crear tabla DynamoDB con clave de ordenamiento (numérica por defecto)
+create_dynamodb_table_with_sort_key() {
+    local table_name=$1
+    local partition_key=$2
+    local sort_key=$3
+    local sort_key_type=${4:-N}
+    
+    echo "📊 Creando tabla DynamoDB: $table_name"
+    
+    aws --endpoint-url=http://localhost:4566 dynamodb create-table \
+        --table-name "$table_name" \
+        --attribute-definitions AttributeName="$partition_key",AttributeType=S AttributeName="$sort_key",AttributeType="$sort_key_type" \
+        --key-schema AttributeName="$partition_key",KeyType=HASH AttributeName="$sort_key",KeyType=RANGE \
+        --billing-mode PAY_PER_REQUEST \
+        --region us-east-1
+    
+    echo "✅ Tabla $table_name creada exitosamente"
+}
+
+# Función para crear un índice secundario global con claves de tipo texto
+create_dynamodb_gsi() {
+    local table_name=$1
+    local index_name=$2
+    local partition_key=$3
+    local sort_key=$4
+    
+    echo "📊 Creando índice $index_name en la tabla $table_name"
+    
+    aws --endpoint-url=http://localhost:4566 dynamodb update-table \
+        --table-name "$table_name" \
+        --attribute-definitions AttributeName="$partition_key",AttributeType=S AttributeName="$sort_key",AttributeType=S \
+        --global-secondary-index-updates "[{\"Create\":{\"IndexName\":\"$index_name\",\"KeySchema\":[{\"AttributeName\":\"$partition_key\",\"KeyType\":\"HASH\"},{\"AttributeName\":\"$sort_key\",\"KeyType\":\"RANGE\"}],\"Projection\":{\"ProjectionType\":\"ALL\"}}}]" \
+        --region us-east-1
+    
+    echo "✅ Índice $index_name creado exitosamente"
+}
+
+# Función para verificar si un índice secundario global existe
+gsi_exists() {
+    local table_name=$1
+    local index_name=$2
+    
+    aws --endpoint-url=http://localhost:4566 dynamodb describe-table \
+        --table-name "$table_name" \
+        --query "Table.GlobalSecondaryIndexes[?IndexName=='$index_name'].IndexName" \
+        --output text --region us-east-1 2>/dev/null | grep -q "$index_name"
+}
+
 # Función para crear cola SQS
 create_sqs_queue() {
     local queue_name=$1
@@ -87,12 +135,136 @@ else
     echo "ℹ️  Tabla 'notifications' ya existe"
 fi
 
+if ! gsi_exists "notifications" "recipient-created_at-index"; then
+    create_dynamodb_gsi "notifications" "recipient-created_at-index" "recipient" "created_at"
+else
+    echo "ℹ️  Índice 'recipient-created_at-index' ya existe"
+fi
+
+if ! gsi_exists "notifications" "status-send_at-index"; then
+    create_dynamodb_gsi "notifications" "status-send_at-index" "status" "send_at"
+else
+    echo "ℹ️  Índice 'status-send_at-index' ya existe"
+fi
+
+if ! gsi_exists "notifications" "status-next_attempt_at-index"; then
+    create_dynamodb_gsi "notifications" "status-next_attempt_at-index" "status" "next_attempt_at"
+else
+    echo "ℹ️  Índice 'status-next_attempt_at-index' ya existe"
+fi
+
 if ! resource_exists "dynamodb" "notification_templates"; then
     create_dynamodb_table "notification_templates" "id"
 else
     echo "ℹ️  Tabla 'notification_templates' ya existe"
 fi
 
+if ! gsi_exists "notification_templates" "name-updated_at-index"; then
+    create_dynamodb_gsi "notification_templates" "name-updated_at-index" "name" "updated_at"
+else
+    echo "ℹ️  Índice 'name-updated_at-index' ya existe"
+fi
+
+if ! resource_exists "dynamodb" "notification_template_versions"; then
+    create_dynamodb_table_with_sort_key "notification_template_versions" "template_id" "version"
+else
+    echo "ℹ️  Tabla 'notification_template_versions' ya existe"
+fi
+
+if ! resource_exists "dynamodb" "notification_template_names"; then
+    create_dynamodb_table "notification_template_names" "name"
+else
+    echo "ℹ️  Tabla 'notification_template_names' ya existe"
+fi
+
+if ! resource_exists "dynamodb" "webhook_subscriptions"; then
+    create_dynamodb_table "webhook_subscriptions" "id"
+else
+    echo "ℹ️  Tabla 'webhook_subscriptions' ya existe"
+fi
+
+if ! resource_exists "dynamodb" "webhook_deliveries"; then
+    create_dynamodb_table_with_sort_key "webhook_deliveries" "subscription_id" "created_at" "S"
+else
+    echo "ℹ️  Tabla 'webhook_deliveries' ya existe"
+fi
+
+if ! resource_exists "dynamodb" "user_preferences"; then
+    create_dynamodb_table "user_preferences" "recipient"
+else
+    echo "ℹ️  Tabla 'user_preferences' ya existe"
+fi
+
+if ! resource_exists "dynamodb" "device_tokens"; then
+    create_dynamodb_table_with_sort_key "device_tokens" "user_id" "token" "S"
+else
+    echo "ℹ️  Tabla 'device_tokens' ya existe"
+fi
+
+if ! gsi_exists "device_tokens" "token-user_id-index"; then
+    create_dynamodb_gsi "device_tokens" "token-user_id-index" "token" "user_id"
+else
+    echo "ℹ️  Índice 'token-user_id-index' ya existe"
+fi
+
+if ! resource_exists "dynamodb" "email_suppressions"; then
+    create_dynamodb_table "email_suppressions" "email"
+else
+    echo "ℹ️  Tabla 'email_suppressions' ya existe"
+fi
+
+if ! resource_exists "dynamodb" "email_messages"; then
+    create_dynamodb_table "email_messages" "message_id"
+    # Los mensajes se conservan 30 días para asociar rebotes y quejas tardíos
+    aws --endpoint-url=http://localhost:4566 dynamodb update-time-to-live \
+        --table-name "email_messages" \
+        --time-to-live-specification "Enabled=true,AttributeName=expires_at" \
+        --region us-east-1 > /dev/null
+else
+    echo "ℹ️  Tabla 'email_messages' ya existe"
+fi
+
+if ! resource_exists "dynamodb" "scheduled_reminders"; then
+    create_dynamodb_table_with_sort_key "scheduled_reminders" "event_id" "reminder_id" "S"
+else
+    echo "ℹ️  Tabla 'scheduled_reminders' ya existe"
+fi
+
+if ! gsi_exists "scheduled_reminders" "status-fire_at-index"; then
+    create_dynamodb_gsi "scheduled_reminders" "status-fire_at-index" "status" "fire_at"
+else
+    echo "ℹ️  Índice 'status-fire_at-index' ya existe"
+fi
+
+if ! resource_exists "dynamodb" "event_audience"; then
+    create_dynamodb_table_with_sort_key "event_audience" "event_id" "recipient" "S"
+else
+    echo "ℹ️  Tabla 'event_audience' ya existe"
+fi
+
+if ! resource_exists "dynamodb" "fanout_jobs"; then
+    create_dynamodb_table "fanout_jobs" "id"
+else
+    echo "ℹ️  Tabla 'fanout_jobs' ya existe"
+fi
+
+if ! gsi_exists "fanout_jobs" "status-created_at-index"; then
+    create_dynamodb_gsi "fanout_jobs" "status-created_at-index" "status" "created_at"
+else
+    echo "ℹ️  Índice 'status-created_at-index' ya existe"
+fi
+
+if ! resource_exists "dynamodb" "idempotency_keys"; then
+    create_dynamodb_table "idempotency_keys" "idempotency_key"
+    # Las claves se recuerdan IDEMPOTENCY_TTL_HOURS (24 por defecto)
+    aws --endpoint-url=http://localhost:4566 dynamodb update-time-to-live \
+        --table-name "idempotency_keys" \
+        --time-to-live-specification "Enabled=true,AttributeName=expires_at" \
+        --region us-east-1 > /dev/null
+else
+    echo "ℹ️  Tabla 'idempotency_keys' ya existe"
+fi
+
 # Crear colas SQS
 echo "📱 Configurando SQS..."
 
@@ -114,6 +286,28 @@ else
     echo "ℹ️  Cola 'reminder-notifications' ya existe"
 fi
 
+# Colas de mensajes fallidos (DLQ): el servicio mueve ahí los mensajes que agotan sus reintentos
+for dlq in event-notifications-dlq reservation-notifications-dlq reminder-notifications-dlq; do
+    if ! resource_exists "sqs" "$dlq"; then
+        create_sqs_queue "$dlq"
+    else
+        echo "ℹ️  Cola '$dlq' ya existe"
+    fi
+done
+
+# Configurar SNS
+echo "📣 Configurando SNS..."
+# create-topic es idempotente: si el topic existe devuelve su ARN
+aws --endpoint-url=http://localhost:4566 sns create-topic \
+    --name "notification-stream" \
+    --region us-east-1 > /dev/null
+echo "✅ Topic notification-stream listo"
+
+aws --endpoint-url=http://localhost:4566 sns create-topic \
+    --name "ses-feedback" \
+    --region us-east-1 > /dev/null
+echo "✅ Topic ses-feedback listo (suscriba POST /api/v1/ses/notifications)"
+
 # Configurar SES (simulado en LocalStack)
 echo "📧 Configurando SES..."
 echo "ℹ️  SES se configura automáticamente en LocalStack"
@@ -131,11 +325,26 @@ echo ""
 echo "🎉 Configuración completada exitosamente!"
 echo ""
 echo "📋 Resumen de recursos creados:"
-echo "   • Tabla DynamoDB: notifications"
-echo "   • Tabla DynamoDB: notification_templates"
+echo "   • Tabla DynamoDB: notifications (índices recipient-created_at-index, status-send_at-index y status-next_attempt_at-index)"
+echo "   • Tabla DynamoDB: notification_templates (índice name-updated_at-index)"
+echo "   • Tabla DynamoDB: notification_template_versions"
+echo "   • Tabla DynamoDB: notification_template_names"
+echo "   • Tabla DynamoDB: webhook_subscriptions"
+echo "   • Tabla DynamoDB: webhook_deliveries"
+echo "   • Tabla DynamoDB: user_preferences"
+echo "   • Tabla DynamoDB: device_tokens (índice token-user_id-index)"
 echo "   • Cola SQS: event-notifications"
 echo "   • Cola SQS: reservation-notifications"
 echo "   • Cola SQS: reminder-notifications"
+echo "   • Colas SQS de mensajes fallidos: event-notifications-dlq, reservation-notifications-dlq, reminder-notifications-dlq"
+echo "   • Tabla DynamoDB: email_suppressions"
+echo "   • Tabla DynamoDB: email_messages (TTL expires_at)"
+echo "   • Tabla DynamoDB: scheduled_reminders (índice status-fire_at-index)"
+echo "   • Tabla DynamoDB: event_audience"
+echo "   • Tabla DynamoDB: fanout_jobs (índice status-created_at-index)"
+echo "   • Tabla DynamoDB: idempotency_keys (TTL expires_at)"
+echo "   • Topic SNS: notification-stream"
+echo "   • Topic SNS: ses-feedback"
 echo ""
 echo "🚀 El servicio de notificaciones está listo para usar!"
 echo "   Puerto: 8085"